@@ -3,17 +3,32 @@ package main
 import (
 	"context"
 	"errors"
+	"expvar"
 	"fmt"
 	"golang.org/x/sync/errgroup"
+	"homework10/internal/adapters/adcache"
 	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/auditrepo"
+	"homework10/internal/adapters/favoritesrepo"
+	"homework10/internal/adapters/idempotencyrepo"
+	"homework10/internal/adapters/messagesrepo"
+	"homework10/internal/adapters/notifyhook"
 	"homework10/internal/adapters/usersrepo"
+	"homework10/internal/adapters/webhookrepo"
+	"homework10/internal/ads"
 	"homework10/internal/app"
+	"homework10/internal/idempotency"
+	"homework10/internal/mailer"
+	"homework10/internal/moderation"
 	"homework10/internal/ports/grpc"
 	"homework10/internal/ports/httpgin"
+	"homework10/internal/webhooks"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 )
@@ -21,13 +36,41 @@ import (
 const (
 	grpcPort = ":18020"
 	httpPort = ":18080"
+
+	schedulerInterval = time.Second
+
+	defaultCacheSize = 1024
+	defaultCacheTTL  = time.Minute
 )
 
 func main() {
-	a := app.NewApp(adrepo.New(), usersrepo.New())
+	dispatcher := webhooks.NewDispatcher(webhookrepo.New())
+	opts := []app.Option{
+		app.WithModeration(moderation.NewEngine(
+			moderation.BannedWords(envList("ADS_BANNED_WORDS")...),
+			moderation.Links(),
+			moderation.Phones(),
+			moderation.Duplicates(),
+		)),
+		app.WithAdmins(adminIDs()...),
+		app.WithAuditLog(auditrepo.New()),
+		app.WithMailer(logMailer()),
+		app.WithFavorites(favoritesrepo.New()),
+		app.WithMessages(messagesrepo.New()),
+		app.WithWebhooks(dispatcher),
+	}
+	// ADS_NOTIFY_WEBHOOK - адрес, на который отправляются уведомления по сохраненным поискам
+	var notifier *notifyhook.Notifier
+	if url := os.Getenv("ADS_NOTIFY_WEBHOOK"); url != "" {
+		notifier = notifyhook.New(url)
+		opts = append(opts, app.WithNotifier(notifier))
+	}
+	a := app.NewApp(adRepository(), usersrepo.New(), opts...)
 
-	httpServer := httpgin.NewHTTPServer(httpPort, a)
-	grpcServer := grpc.NewGRPCServer(grpcPort, &a)
+	// ключи идемпотентности общие для HTTP и gRPC
+	keeper := idempotency.NewKeeper(idempotencyrepo.New())
+	httpServer := httpgin.NewHTTPServer(httpPort, a, httpgin.WithIdempotency(keeper))
+	grpcServer := grpc.NewGRPCServer(grpcPort, &a, grpc.WithIdempotency(keeper))
 
 	eg, ctx := errgroup.WithContext(context.Background())
 
@@ -45,6 +88,32 @@ func main() {
 		}
 	})
 
+	// run ads scheduler
+	eg.Go(func() error {
+		log.Printf("starting ads scheduler, interval %s\n", schedulerInterval)
+		defer log.Println("stop ads scheduler")
+
+		return a.RunScheduler(ctx, schedulerInterval)
+	})
+
+	// run webhook delivery workers
+	eg.Go(func() error {
+		log.Println("starting webhook dispatcher")
+		defer log.Println("stop webhook dispatcher")
+
+		return dispatcher.Run(ctx)
+	})
+
+	// run saved search notifier
+	if notifier != nil {
+		eg.Go(func() error {
+			log.Println("starting saved search notifier")
+			defer log.Println("stop saved search notifier")
+
+			return notifier.Run(ctx)
+		})
+	}
+
 	// run grpc server
 	eg.Go(func() error {
 		log.Printf("starting grpc server, listening on %s\n", grpcPort)
@@ -72,6 +141,42 @@ func main() {
 		}
 	})
 
+	// ADS_ADMIN_ADDR - адрес служебного сервера с метриками expvar на GET /debug/vars, например ADS_ADMIN_ADDR=127.0.0.1:18090.
+	// По умолчанию служебный сервер не запускается: метрики не должны быть доступны на публичном адресе
+	if addr := os.Getenv("ADS_ADMIN_ADDR"); addr != "" {
+		adminServer := &http.Server{Addr: addr, Handler: adminHandler()}
+		eg.Go(func() error {
+			log.Printf("starting admin server, listening on %s\n", addr)
+			defer log.Printf("close admin server listening on %s\n", addr)
+
+			errCh := make(chan error)
+
+			defer func() {
+				shCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				defer cancel()
+
+				if err := adminServer.Shutdown(shCtx); err != nil {
+					log.Printf("can't close admin server listening on %s: %s", addr, err.Error())
+				}
+
+				close(errCh)
+			}()
+
+			go func() {
+				if err := adminServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+					errCh <- err
+				}
+			}()
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case err := <-errCh:
+				return fmt.Errorf("admin server can't listen and serve requests: %w", err)
+			}
+		})
+	}
+
 	// run http server
 	eg.Go(func() error {
 		log.Printf("starting http server, listening on %s\n", httpPort)
@@ -110,3 +215,93 @@ func main() {
 
 	log.Println("servers were successfully shutdown")
 }
+
+// envList читает из переменной окружения name список значений через запятую
+func envList(name string) []string {
+	res := make([]string, 0)
+	for _, v := range strings.Split(os.Getenv(name), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// adminIDs читает ID администраторов из ADS_ADMINS, например ADS_ADMINS=1,2.
+// Модераторов назначают администраторы через API. ID в запросах не аутентифицируется,
+// поэтому без шлюза с аутентификацией права администратора может получить любой клиент
+func adminIDs() []int64 {
+	res := make([]int64, 0)
+	for _, v := range envList("ADS_ADMINS") {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			log.Fatalf("invalid admin id %q in ADS_ADMINS", v)
+		}
+		res = append(res, id)
+	}
+	return res
+}
+
+// adRepository возвращает репозиторий объявлений с кешем чтения. Размер кеша задает ADS_CACHE_SIZE
+// (по умолчанию 1024, 0 отключает кеш), срок хранения - ADS_CACHE_TTL, например ADS_CACHE_TTL=30s.
+// Счетчики кеша публикуются в expvar как ads_cache и доступны на служебном адресе ADS_ADMIN_ADDR
+func adRepository() ads.Repository {
+	size := envInt("ADS_CACHE_SIZE", defaultCacheSize)
+	if size <= 0 {
+		return adrepo.New()
+	}
+
+	cache := adcache.New(adrepo.New(), adcache.WithSize(size), adcache.WithTTL(envDuration("ADS_CACHE_TTL", defaultCacheTTL)))
+	expvar.Publish("ads_cache", expvar.Func(func() any { return cache.Stats() }))
+	return cache
+}
+
+// adminHandler отдает только метрики expvar, без API и swagger
+func adminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	return mux
+}
+
+// envInt читает из переменной окружения name целое число или возвращает def, если она не задана
+func envInt(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Fatalf("invalid %s %q", name, v)
+	}
+	return n
+}
+
+// envDuration читает из переменной окружения name длительность или возвращает def, если она не задана
+func envDuration(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Fatalf("invalid %s %q", name, v)
+	}
+	return d
+}
+
+// logMailer пишет в лог получателя и тему писем: отправка почты в учебном сервисе не настроена.
+// Текст письма с токеном подтверждения в лог не попадает, иначе хранение только хеша токена
+// теряет смысл. ADS_MAIL_LOG_BODY=1 включает вывод текста для локальной разработки
+func logMailer() mailer.Mailer {
+	withBody := os.Getenv("ADS_MAIL_LOG_BODY") == "1"
+	return mailer.Func(func(m mailer.Message) error {
+		if withBody {
+			log.Printf("mail to %s: %s\n%s", m.To, m.Subject, m.Body)
+			return nil
+		}
+		log.Printf("mail to %s: %s", m.To, m.Subject)
+		return nil
+	})
+}
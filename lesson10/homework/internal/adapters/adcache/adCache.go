@@ -0,0 +1,216 @@
+// Package adcache - кеширующий декоратор ads.Repository
+package adcache
+
+import (
+	"container/list"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"homework10/internal/ads"
+)
+
+const (
+	defaultSize = 1024
+	defaultTTL  = time.Minute
+)
+
+// Stats - счетчики кеша с момента создания
+type Stats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+	// Size - число объявлений в кеше сейчас
+	Size int `json:"size"`
+}
+
+type Option func(c *Cache)
+
+// WithSize задает максимальное число объявлений в кеше, по умолчанию 1024
+func WithSize(n int) Option {
+	return func(c *Cache) {
+		c.size = n
+	}
+}
+
+// WithTTL задает, сколько объявление хранится в кеше после чтения из репозитория, по умолчанию минута
+func WithTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.ttl = ttl
+	}
+}
+
+// WithNow задает источник текущего времени
+func WithNow(now func() time.Time) Option {
+	return func(c *Cache) {
+		c.now = now
+	}
+}
+
+// Cache читает объявления через LRU-кеш с ограниченным сроком хранения. Записи через Cache
+// сбрасывают кеш, поэтому все изменения репозитория должны проходить через него.
+// Одновременные промахи по одному ID выполняют один запрос к репозиторию
+type Cache struct {
+	repo ads.Repository
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	group singleflight.Group
+
+	m     sync.Mutex
+	items map[int64]*list.Element
+	lru   *list.List
+	// gen увеличивается при каждой записи, чтобы не сохранить в кеш объявление,
+	// прочитанное до записи
+	gen uint64
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+}
+
+type entry struct {
+	id        int64
+	ad        ads.Ad
+	expiresAt time.Time
+}
+
+func New(repo ads.Repository, opts ...Option) *Cache {
+	c := &Cache{
+		repo:  repo,
+		size:  defaultSize,
+		ttl:   defaultTTL,
+		now:   time.Now,
+		items: make(map[int64]*list.Element),
+		lru:   list.New(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func (c *Cache) GetById(id int64) (ads.Ad, error) {
+	if ad, ok := c.get(id); ok {
+		c.hits.Add(1)
+		return ad, nil
+	}
+	c.misses.Add(1)
+
+	res, err, _ := c.group.Do(strconv.FormatInt(id, 10), func() (interface{}, error) {
+		c.m.Lock()
+		gen := c.gen
+		c.m.Unlock()
+
+		ad, err := c.repo.GetById(id)
+		if err != nil {
+			return ads.Ad{}, err
+		}
+
+		c.put(id, ad, gen)
+		return ad, nil
+	})
+	return res.(ads.Ad), err
+}
+
+func (c *Cache) AddAd(ad ads.Ad) int64 {
+	return c.repo.AddAd(ad)
+}
+
+func (c *Cache) ReplaceByID(id int64, ad ads.Ad) error {
+	defer c.invalidate(id)
+	return c.repo.ReplaceByID(id, ad)
+}
+
+func (c *Cache) GetSize() int64 {
+	return c.repo.GetSize()
+}
+
+// DeleteByID сбрасывает весь кеш: после удаления ID следующих объявлений сдвигаются
+func (c *Cache) DeleteByID(id int64) (ads.Ad, error) {
+	defer c.purge()
+	return c.repo.DeleteByID(id)
+}
+
+// Stats возвращает счетчики попаданий, промахов и вытеснений
+func (c *Cache) Stats() Stats {
+	c.m.Lock()
+	size := c.lru.Len()
+	c.m.Unlock()
+
+	return Stats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Size:      size,
+	}
+}
+
+func (c *Cache) get(id int64) (ads.Ad, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	el, ok := c.items[id]
+	if !ok {
+		return ads.Ad{}, false
+	}
+
+	e := el.Value.(*entry)
+	if !c.now().Before(e.expiresAt) {
+		c.remove(el)
+		return ads.Ad{}, false
+	}
+
+	c.lru.MoveToFront(el)
+	return e.ad, true
+}
+
+func (c *Cache) put(id int64, ad ads.Ad, gen uint64) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if gen != c.gen || c.size <= 0 {
+		return
+	}
+
+	if el, ok := c.items[id]; ok {
+		c.remove(el)
+	}
+	c.items[id] = c.lru.PushFront(&entry{id: id, ad: ad, expiresAt: c.now().Add(c.ttl)})
+
+	for c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+		c.evictions.Add(1)
+	}
+}
+
+func (c *Cache) invalidate(id int64) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.gen++
+	if el, ok := c.items[id]; ok {
+		c.remove(el)
+	}
+	c.group.Forget(strconv.FormatInt(id, 10))
+}
+
+func (c *Cache) purge() {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.gen++
+	c.items = make(map[int64]*list.Element)
+	c.lru.Init()
+}
+
+func (c *Cache) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.items, el.Value.(*entry).id)
+}
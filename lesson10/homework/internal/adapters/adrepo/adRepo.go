@@ -1,12 +1,12 @@
 package adrepo
 
 import (
-	"errors"
+	"fmt"
 	"homework10/internal/ads"
 	"sync"
 )
 
-var wrongIdErr = errors.New("id must be non-negative and must be less than repository size")
+var wrongIdErr = fmt.Errorf("%w: id must be non-negative and must be less than repository size", ads.ErrAdNotFound)
 
 func New() ads.Repository {
 	return &adRepo{repo: make([]ads.Ad, 0)}
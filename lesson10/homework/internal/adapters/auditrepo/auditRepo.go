@@ -0,0 +1,43 @@
+package auditrepo
+
+import (
+	"homework10/internal/audit"
+	"sync"
+)
+
+func New() audit.Repository {
+	return &auditRepo{repo: make([]audit.Entry, 0)}
+}
+
+// auditRepo хранит записи только на добавление: изменить или удалить запись нельзя
+type auditRepo struct {
+	repo []audit.Entry
+	m    sync.RWMutex
+}
+
+func (r *auditRepo) Append(e audit.Entry) (audit.Entry, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	e.Seq, e.PrevHash = int64(len(r.repo)+1), ""
+	if len(r.repo) > 0 {
+		e.PrevHash = r.repo[len(r.repo)-1].Hash
+	}
+	e.Hash = audit.Hash(e)
+
+	r.repo = append(r.repo, e)
+	return e, nil
+}
+
+func (r *auditRepo) List(f audit.Filter) ([]audit.Entry, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	res := make([]audit.Entry, 0)
+	for _, e := range r.repo {
+		if f.Match(e) {
+			res = append(res, e)
+		}
+	}
+	return res, nil
+}
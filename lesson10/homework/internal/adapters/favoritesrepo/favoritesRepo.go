@@ -0,0 +1,98 @@
+package favoritesrepo
+
+import (
+	"homework10/internal/favorites"
+	"sync"
+)
+
+func New() favorites.Repository {
+	return &favoritesRepo{favorites: make(map[int64][]int64),
+		searches: make(map[int64]favorites.Search)}
+}
+
+type favoritesRepo struct {
+	favorites map[int64][]int64
+	searches  map[int64]favorites.Search
+	lastID    int64
+	m         sync.RWMutex
+}
+
+func (r *favoritesRepo) AddFavorite(userID int64, adID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	for _, id := range r.favorites[userID] {
+		if id == adID {
+			return nil
+		}
+	}
+
+	r.favorites[userID] = append(r.favorites[userID], adID)
+	return nil
+}
+
+func (r *favoritesRepo) RemoveFavorite(userID int64, adID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	list := r.favorites[userID]
+	for i, id := range list {
+		if id == adID {
+			r.favorites[userID] = append(list[:i:i], list[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *favoritesRepo) Favorites(userID int64) ([]int64, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return append(make([]int64, 0, len(r.favorites[userID])), r.favorites[userID]...), nil
+}
+
+func (r *favoritesRepo) AddSearch(s favorites.Search) (favorites.Search, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.lastID++
+	s.ID = r.lastID
+	r.searches[s.ID] = s
+	return s, nil
+}
+
+func (r *favoritesRepo) DeleteSearch(userID int64, searchID int64) (favorites.Search, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	s, ok := r.searches[searchID]
+	if !ok || s.UserID != userID {
+		return favorites.Search{}, favorites.ErrSearchNotFound
+	}
+
+	delete(r.searches, searchID)
+	return s, nil
+}
+
+func (r *favoritesRepo) Searches(userID int64) ([]favorites.Search, error) {
+	return r.list(func(s favorites.Search) bool { return s.UserID == userID }), nil
+}
+
+func (r *favoritesRepo) AllSearches() ([]favorites.Search, error) {
+	return r.list(func(favorites.Search) bool { return true }), nil
+}
+
+// list возвращает подходящие поиски в порядке создания
+func (r *favoritesRepo) list(match func(s favorites.Search) bool) []favorites.Search {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	res := make([]favorites.Search, 0)
+	for id := int64(1); id <= r.lastID; id++ {
+		if s, ok := r.searches[id]; ok && match(s) {
+			res = append(res, s)
+		}
+	}
+	return res
+}
@@ -0,0 +1,84 @@
+package idempotencyrepo
+
+import (
+	"homework10/internal/idempotency"
+	"sync"
+	"time"
+)
+
+func New() idempotency.Repository {
+	return &idempotencyRepo{entries: make(map[string]idempotency.Entry)}
+}
+
+type idempotencyRepo struct {
+	entries map[string]idempotency.Entry
+	// order - ключи в порядке резервирования, по нему удаляются истекшие записи
+	order []reservation
+	m     sync.Mutex
+}
+
+// reservation - место ключа в order. После Delete и нового Reserve ключ встречается в order
+// еще раз, прежнее место узнается по другому сроку хранения и пропускается
+type reservation struct {
+	key       string
+	expiresAt time.Time
+}
+
+func (r *idempotencyRepo) Reserve(e idempotency.Entry, now time.Time) (idempotency.Entry, bool, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.expire(now)
+
+	if old, ok := r.entries[e.Key]; ok && now.Before(old.ExpiresAt) {
+		return old, false, nil
+	}
+
+	r.entries[e.Key] = e
+	r.order = append(r.order, reservation{key: e.Key, expiresAt: e.ExpiresAt})
+	return e, true, nil
+}
+
+func (r *idempotencyRepo) Complete(key string, fingerprint string, resp idempotency.Response) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	e, ok := r.entries[key]
+	if !ok {
+		return nil
+	}
+
+	if fingerprint != "" {
+		e.Fingerprint = fingerprint
+	}
+	e.Response = &resp
+	r.entries[key] = e
+	return nil
+}
+
+func (r *idempotencyRepo) Delete(key string) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	delete(r.entries, key)
+	return nil
+}
+
+// expire удаляет записи, истекшие к моменту now. Срок хранения у всех записей одинаковый,
+// поэтому истекшие записи всегда в начале order
+func (r *idempotencyRepo) expire(now time.Time) {
+	i := 0
+	for ; i < len(r.order); i++ {
+		res := r.order[i]
+		e, ok := r.entries[res.key]
+		if !ok || !e.ExpiresAt.Equal(res.expiresAt) {
+			// запись удалена или зарезервирована заново и стоит в order дальше
+			continue
+		}
+		if now.Before(e.ExpiresAt) {
+			break
+		}
+		delete(r.entries, res.key)
+	}
+	r.order = r.order[i:]
+}
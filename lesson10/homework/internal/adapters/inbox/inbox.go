@@ -0,0 +1,32 @@
+package inbox
+
+import (
+	"homework10/internal/notify"
+	"sync"
+)
+
+// Inbox - Notifier, который складывает уведомления в память по пользователям
+type Inbox struct {
+	notifications map[int64][]notify.Notification
+	m             sync.RWMutex
+}
+
+func New() *Inbox {
+	return &Inbox{notifications: make(map[int64][]notify.Notification)}
+}
+
+func (b *Inbox) Notify(n notify.Notification) error {
+	b.m.Lock()
+	b.notifications[n.UserID] = append(b.notifications[n.UserID], n)
+	b.m.Unlock()
+
+	return nil
+}
+
+// List возвращает уведомления пользователя в порядке поступления
+func (b *Inbox) List(userID int64) []notify.Notification {
+	b.m.RLock()
+	defer b.m.RUnlock()
+
+	return append(make([]notify.Notification, 0, len(b.notifications[userID])), b.notifications[userID]...)
+}
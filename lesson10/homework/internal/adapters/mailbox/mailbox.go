@@ -0,0 +1,47 @@
+package mailbox
+
+import (
+	"homework10/internal/mailer"
+	"sync"
+)
+
+// Mailbox - Mailer, который ничего не отправляет, а складывает письма в память. Используется в тестах
+type Mailbox struct {
+	messages []mailer.Message
+	m        sync.RWMutex
+}
+
+func New() *Mailbox {
+	return &Mailbox{messages: make([]mailer.Message, 0)}
+}
+
+func (b *Mailbox) Send(m mailer.Message) error {
+	b.m.Lock()
+	b.messages = append(b.messages, m)
+	b.m.Unlock()
+
+	return nil
+}
+
+// Messages возвращает все письма, отправленные на адрес to
+func (b *Mailbox) Messages(to string) []mailer.Message {
+	b.m.RLock()
+	defer b.m.RUnlock()
+
+	res := make([]mailer.Message, 0)
+	for _, m := range b.messages {
+		if m.To == to {
+			res = append(res, m)
+		}
+	}
+	return res
+}
+
+// Last возвращает последнее письмо, отправленное на адрес to
+func (b *Mailbox) Last(to string) (mailer.Message, bool) {
+	messages := b.Messages(to)
+	if len(messages) == 0 {
+		return mailer.Message{}, false
+	}
+	return messages[len(messages)-1], true
+}
@@ -0,0 +1,126 @@
+package messagesrepo
+
+import (
+	"homework10/internal/messages"
+	"sort"
+	"sync"
+)
+
+func New() messages.Repository {
+	return &messagesRepo{threads: make(map[int64]messages.Thread),
+		messages: make(map[int64][]messages.Message)}
+}
+
+type messagesRepo struct {
+	threads       map[int64]messages.Thread
+	messages      map[int64][]messages.Message
+	lastThreadID  int64
+	lastMessageID int64
+	m             sync.RWMutex
+}
+
+func (r *messagesRepo) FindThread(adID int64, buyerID int64) (messages.Thread, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	for _, t := range r.threads {
+		if t.AdID == adID && t.BuyerID == buyerID {
+			return t, nil
+		}
+	}
+	return messages.Thread{}, messages.ErrThreadNotFound
+}
+
+func (r *messagesRepo) AddThread(t messages.Thread) (messages.Thread, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.lastThreadID++
+	t.ID = r.lastThreadID
+	r.threads[t.ID] = t
+	return t, nil
+}
+
+func (r *messagesRepo) GetThread(id int64) (messages.Thread, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	t, ok := r.threads[id]
+	if !ok {
+		return messages.Thread{}, messages.ErrThreadNotFound
+	}
+	return t, nil
+}
+
+func (r *messagesRepo) Threads(userID int64) ([]messages.Thread, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	res := make([]messages.Thread, 0)
+	for _, t := range r.threads {
+		if t.Has(userID) {
+			res = append(res, t)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].LastMessageAt.Equal(res[j].LastMessageAt) {
+			return res[i].LastMessageAt.After(res[j].LastMessageAt)
+		}
+		return res[i].ID > res[j].ID
+	})
+	return res, nil
+}
+
+func (r *messagesRepo) AddMessage(m messages.Message) (messages.Message, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	t, ok := r.threads[m.ThreadID]
+	if !ok {
+		return messages.Message{}, messages.ErrThreadNotFound
+	}
+
+	r.lastMessageID++
+	m.ID = r.lastMessageID
+	r.messages[t.ID] = append(r.messages[t.ID], m)
+
+	t.LastMessageAt = m.SentAt
+	if m.SenderID == t.AuthorID {
+		t.BuyerUnread++
+	} else {
+		t.AuthorUnread++
+	}
+	r.threads[t.ID] = t
+
+	return m, nil
+}
+
+func (r *messagesRepo) Messages(threadID int64) ([]messages.Message, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	if _, ok := r.threads[threadID]; !ok {
+		return []messages.Message{}, messages.ErrThreadNotFound
+	}
+	return append(make([]messages.Message, 0, len(r.messages[threadID])), r.messages[threadID]...), nil
+}
+
+func (r *messagesRepo) MarkRead(threadID int64, userID int64) (messages.Thread, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	t, ok := r.threads[threadID]
+	if !ok {
+		return messages.Thread{}, messages.ErrThreadNotFound
+	}
+
+	switch userID {
+	case t.AuthorID:
+		t.AuthorUnread = 0
+	case t.BuyerID:
+		t.BuyerUnread = 0
+	}
+	r.threads[t.ID] = t
+	return t, nil
+}
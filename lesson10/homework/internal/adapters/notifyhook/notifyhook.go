@@ -0,0 +1,101 @@
+package notifyhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"homework10/internal/notify"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultQueueSize = 1024
+	defaultTimeout   = 5 * time.Second
+)
+
+// Notifier отправляет уведомления POST-запросом с JSON-телом на заданный URL.
+// Notify только ставит уведомление в очередь, доставкой занимается Run
+type Notifier struct {
+	url    string
+	client *http.Client
+	queue  chan notify.Notification
+}
+
+type Option func(n *Notifier)
+
+// WithHTTPClient задает http-клиент, по умолчанию используется клиент с таймаутом 5 секунд
+func WithHTTPClient(c *http.Client) Option {
+	return func(n *Notifier) {
+		n.client = c
+	}
+}
+
+// WithQueueSize задает размер очереди, при переполнении Notify возвращает notify.ErrQueueFull
+func WithQueueSize(size int) Option {
+	return func(n *Notifier) {
+		n.queue = make(chan notify.Notification, size)
+	}
+}
+
+func New(url string, opts ...Option) *Notifier {
+	n := &Notifier{url: url,
+		client: &http.Client{Timeout: defaultTimeout},
+		queue:  make(chan notify.Notification, defaultQueueSize)}
+
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	return n
+}
+
+func (n *Notifier) Notify(notification notify.Notification) error {
+	select {
+	case n.queue <- notification:
+		return nil
+	default:
+		return notify.ErrQueueFull
+	}
+}
+
+// Run доставляет уведомления из очереди, пока не отменен ctx.
+// Недоставленные уведомления не повторяются, ошибка только пишется в лог
+func (n *Notifier) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case notification := <-n.queue:
+			if err := n.send(ctx, notification); err != nil {
+				log.Printf("notifyhook: %s", err.Error())
+			}
+		}
+	}
+}
+
+func (n *Notifier) send(ctx context.Context, notification notify.Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
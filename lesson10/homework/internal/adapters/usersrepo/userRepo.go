@@ -1,79 +1,116 @@
 package usersrepo
 
 import (
-	"errors"
+	"fmt"
 	"homework10/internal/users"
+	"strings"
 	"sync"
 )
 
-var wrongIdErr = errors.New("id must be non-negative and must be less than repository size")
+var wrongIdErr = fmt.Errorf("%w: id must be non-negative and must be less than repository size", users.ErrUserNotFound)
 
 func New() users.Repository {
-	return &userRepo{repo: make(map[int64]users.User)}
+	return &userRepo{repo: make(map[int64]users.User),
+		nicknames: make(map[string]int64),
+		emails:    make(map[string]int64)}
 }
 
+// userRepo назначает пользователям ID по порядку, начиная с 1,
+// и хранит индексы никнеймов и email для проверки уникальности
 type userRepo struct {
-	repo map[int64]users.User
-	m    sync.RWMutex
+	repo      map[int64]users.User
+	nicknames map[string]int64
+	emails    map[string]int64
+	lastID    int64
+	m         sync.RWMutex
 }
 
-func (r *userRepo) checkID(id int64) error {
-	r.m.Lock()
-	defer r.m.Unlock()
+// email сравниваются без учета регистра
+func emailKey(email string) string {
+	return strings.ToLower(email)
+}
 
-	if _, ok := r.repo[id]; ok {
-		return nil
+// checkUnique проверяет, что никнейм и email не заняты другим пользователем. Вызывается под блокировкой
+func (r *userRepo) checkUnique(id int64, u users.User) error {
+	if owner, ok := r.nicknames[u.Nickname]; ok && owner != id {
+		return users.ErrNicknameTaken
+	}
+	if owner, ok := r.emails[emailKey(u.Email)]; ok && owner != id {
+		return users.ErrEmailTaken
 	}
+	return nil
+}
 
-	return wrongIdErr
+func (r *userRepo) index(u users.User) {
+	r.nicknames[u.Nickname] = u.ID
+	r.emails[emailKey(u.Email)] = u.ID
 }
 
-func (r *userRepo) AddUser(u users.User) error {
-	// если пользователь с данным ID уже существует
-	if r.checkID(u.ID) == nil {
-		return wrongIdErr
-	}
+func (r *userRepo) unindex(u users.User) {
+	delete(r.nicknames, u.Nickname)
+	delete(r.emails, emailKey(u.Email))
+}
 
+func (r *userRepo) AddUser(u users.User) (users.User, error) {
 	r.m.Lock()
+	defer r.m.Unlock()
+
+	if err := r.checkUnique(-1, u); err != nil {
+		return users.User{}, err
+	}
+
+	r.lastID++
+	u.ID = r.lastID
 	r.repo[u.ID] = u
-	r.m.Unlock()
+	r.index(u)
 
-	return nil
+	return u, nil
 }
 
 func (r *userRepo) GetById(id int64) (users.User, error) {
-	if err := r.checkID(id); err != nil {
-		return users.User{}, err
-	}
+	r.m.RLock()
+	defer r.m.RUnlock()
 
-	r.m.Lock()
-	user := r.repo[id]
-	r.m.Unlock()
+	user, ok := r.repo[id]
+	if !ok {
+		return users.User{}, wrongIdErr
+	}
 
 	return user, nil
 }
 
 func (r *userRepo) ReplaceByID(id int64, u users.User) error {
-	if err := r.checkID(id); err != nil {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	old, ok := r.repo[id]
+	if !ok {
+		return wrongIdErr
+	}
+
+	if err := r.checkUnique(id, u); err != nil {
 		return err
 	}
 
-	r.m.Lock()
+	u.ID = id
+	r.unindex(old)
 	r.repo[id] = u
-	r.m.Unlock()
+	r.index(u)
 
 	return nil
 }
 
 func (r *userRepo) DeleteByID(id int64) (users.User, error) {
-	if err := r.checkID(id); err != nil {
-		return users.User{}, err
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	u, ok := r.repo[id]
+	if !ok {
+		return users.User{}, wrongIdErr
 	}
 
-	r.m.Lock()
-	u := r.repo[id]
 	delete(r.repo, id)
-	r.m.Unlock()
+	r.unindex(u)
 
 	return u, nil
 }
@@ -0,0 +1,119 @@
+package webhookrepo
+
+import (
+	"homework10/internal/webhooks"
+	"sync"
+)
+
+func New() webhooks.Repository {
+	return &webhookRepo{subscriptions: make(map[int64]webhooks.Subscription),
+		deliveries: make(map[int64]webhooks.Delivery)}
+}
+
+type webhookRepo struct {
+	subscriptions      map[int64]webhooks.Subscription
+	deliveries         map[int64]webhooks.Delivery
+	lastSubscriptionID int64
+	lastDeliveryID     int64
+	m                  sync.RWMutex
+}
+
+func (r *webhookRepo) AddSubscription(s webhooks.Subscription) (webhooks.Subscription, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.lastSubscriptionID++
+	s.ID = r.lastSubscriptionID
+	s.Events = append([]string{}, s.Events...)
+	r.subscriptions[s.ID] = s
+	return s, nil
+}
+
+func (r *webhookRepo) GetSubscription(id int64) (webhooks.Subscription, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	s, ok := r.subscriptions[id]
+	if !ok {
+		return webhooks.Subscription{}, webhooks.ErrSubscriptionNotFound
+	}
+	return s, nil
+}
+
+func (r *webhookRepo) DeleteSubscription(id int64) (webhooks.Subscription, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	s, ok := r.subscriptions[id]
+	if !ok {
+		return webhooks.Subscription{}, webhooks.ErrSubscriptionNotFound
+	}
+
+	delete(r.subscriptions, id)
+	return s, nil
+}
+
+func (r *webhookRepo) Subscriptions() ([]webhooks.Subscription, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	res := make([]webhooks.Subscription, 0, len(r.subscriptions))
+	for id := int64(1); id <= r.lastSubscriptionID; id++ {
+		if s, ok := r.subscriptions[id]; ok {
+			res = append(res, s)
+		}
+	}
+	return res, nil
+}
+
+func (r *webhookRepo) AddDelivery(d webhooks.Delivery) (webhooks.Delivery, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.lastDeliveryID++
+	d.ID = r.lastDeliveryID
+	r.deliveries[d.ID] = copyDelivery(d)
+	return d, nil
+}
+
+func (r *webhookRepo) GetDelivery(id int64) (webhooks.Delivery, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	d, ok := r.deliveries[id]
+	if !ok {
+		return webhooks.Delivery{}, webhooks.ErrDeliveryNotFound
+	}
+	return copyDelivery(d), nil
+}
+
+func (r *webhookRepo) ReplaceDelivery(d webhooks.Delivery) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if _, ok := r.deliveries[d.ID]; !ok {
+		return webhooks.ErrDeliveryNotFound
+	}
+
+	r.deliveries[d.ID] = copyDelivery(d)
+	return nil
+}
+
+func (r *webhookRepo) Deliveries(f webhooks.Filter) ([]webhooks.Delivery, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	res := make([]webhooks.Delivery, 0)
+	for id := int64(1); id <= r.lastDeliveryID; id++ {
+		if d, ok := r.deliveries[id]; ok && f.Match(d) {
+			res = append(res, copyDelivery(d))
+		}
+	}
+	return res, nil
+}
+
+// copyDelivery не дает вызывающему менять попытки, сохраненные в хранилище
+func copyDelivery(d webhooks.Delivery) webhooks.Delivery {
+	d.Attempts = append([]webhooks.Attempt{}, d.Attempts...)
+	return d
+}
@@ -1,6 +1,12 @@
 package ads
 
-import "time"
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrAdNotFound = errors.New("ad not found")
 
 //go:generate go run github.com/vektra/mockery/v2@v2.20.2 --output=./tests/mocks --name=Repository
 type Repository interface {
@@ -11,6 +17,15 @@ type Repository interface {
 	DeleteByID(id int64) (Ad, error)
 }
 
+// ModerationStatus - результат проверки объявления модерацией
+type ModerationStatus string
+
+const (
+	ModerationPending  ModerationStatus = "pending_review"
+	ModerationApproved ModerationStatus = "approved"
+	ModerationRejected ModerationStatus = "rejected"
+)
+
 type Ad struct {
 	ID         int64
 	Title      string `validate:"min:1;max:99"`
@@ -19,4 +34,47 @@ type Ad struct {
 	Published  bool
 	CreateDate time.Time
 	LastUpdate time.Time
+	PublishAt  time.Time // нулевое значение - публикация не запланирована
+	ExpiresAt  time.Time // нулевое значение - снятие с публикации не запланировано
+
+	Moderation   ModerationStatus
+	Flags        []string // причины, по которым правила модерации отправили объявление на ручную проверку
+	RejectReason string
+}
+
+// Visible сообщает, видно ли объявление всем пользователям:
+// автор опубликовал его, а модерация одобрила
+func (ad Ad) Visible() bool {
+	return ad.Published && ad.Moderation == ModerationApproved
+}
+
+// Patch - частичное изменение объявления, nil-поля не меняются
+type Patch struct {
+	Title     *string
+	Text      *string
+	Published *bool
+}
+
+// Filter - критерии отбора объявлений, общие для GetFilteredAds и сохраненных поисков
+type Filter struct {
+	Published int    // 1 - только видимые всем объявления
+	AuthorID  int64  // -1 - любой автор
+	Date      string // дата создания в формате YYYY-MM-DD, пустая строка - любая
+	Title     string // подстрока заголовка, пустая строка - любой заголовок
+}
+
+func (f Filter) Match(ad Ad) bool {
+	if f.Published == 1 && !ad.Visible() {
+		return false
+	}
+
+	if f.AuthorID != -1 && ad.AuthorID != f.AuthorID {
+		return false
+	}
+
+	if f.Date != "" && f.Date != ad.CreateDate.Format(time.DateOnly) {
+		return false
+	}
+
+	return strings.Contains(ad.Title, f.Title)
 }
@@ -0,0 +1,340 @@
+package adsctl
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func newAdsCmd(c *cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ads",
+		Short: "Управление объявлениями",
+	}
+
+	cmd.AddCommand(
+		newAdsCreateCmd(c),
+		newAdsListCmd(c),
+		newAdsGetCmd(c),
+		newAdsUpdateCmd(c),
+		newAdsStatusCmd(c, "publish", true),
+		newAdsStatusCmd(c, "unpublish", false),
+		newAdsDeleteCmd(c),
+		newAdsSearchCmd(c),
+		newAdsFilterCmd(c),
+	)
+
+	return cmd
+}
+
+func parseID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q: %w", s, err)
+	}
+	return id, nil
+}
+
+func newAdsCreateCmd(c *cli) *cobra.Command {
+	var (
+		userID      int64
+		title, text string
+		file        string
+		format      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Создать объявление или загрузить объявления из csv/jsonl файла",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := c.adsClient()
+			if err != nil {
+				return err
+			}
+
+			if file == "" {
+				ctx, cancel := c.context(cmd)
+				defer cancel()
+
+				ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: userID, Title: title, Text: text})
+				if err != nil {
+					return err
+				}
+				return c.print(cmd.OutOrStdout(), adsTable{newAdView(ad)}, true)
+			}
+
+			created, failed := make(adsTable, 0), 0
+			err = readRecords(file, format, func(line int, rec record) error {
+				userID, err := rec.int64("user_id")
+				if err == nil {
+					ctx, cancel := c.context(cmd)
+					defer cancel()
+
+					var ad *grpcPort.AdResponse
+					ad, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: userID, Title: rec["title"], Text: rec["text"]})
+					if err == nil {
+						created = append(created, newAdView(ad))
+						return nil
+					}
+				}
+
+				failed++
+				cmd.PrintErrf("line %d: %s\n", line, err)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			if err := c.print(cmd.OutOrStdout(), created, false); err != nil {
+				return err
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d records failed", failed)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Int64Var(&userID, "user", 0, "ID автора")
+	cmd.Flags().StringVar(&title, "title", "", "заголовок")
+	cmd.Flags().StringVar(&text, "text", "", "текст")
+	cmd.Flags().StringVarP(&file, "file", "f", "", "файл с колонками user_id, title, text (\"-\" - stdin)")
+	cmd.Flags().StringVar(&format, "format", "", "формат файла: csv или jsonl (по умолчанию - по расширению)")
+	cmd.MarkFlagsMutuallyExclusive("file", "title")
+	cmd.MarkFlagsMutuallyExclusive("file", "text")
+	_ = cmd.MarkFlagFilename("file", "csv", "jsonl")
+
+	return cmd
+}
+
+func newAdsListCmd(c *cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "Список опубликованных объявлений",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := c.adsClient()
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := c.context(cmd)
+			defer cancel()
+
+			res, err := client.ListAds(ctx, &emptypb.Empty{})
+			if err != nil {
+				return err
+			}
+			return c.print(cmd.OutOrStdout(), newAdsTable(res.List), false)
+		},
+	}
+}
+
+func newAdsGetCmd(c *cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get AD_ID",
+		Short: "Получить опубликованное объявление",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			client, err := c.adsClient()
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := c.context(cmd)
+			defer cancel()
+
+			ad, err := client.GetAd(ctx, &grpcPort.GetAdRequest{AdId: id})
+			if err != nil {
+				return err
+			}
+			return c.print(cmd.OutOrStdout(), adsTable{newAdView(ad)}, true)
+		},
+	}
+}
+
+func newAdsUpdateCmd(c *cli) *cobra.Command {
+	var (
+		userID      int64
+		title, text string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "update AD_ID",
+		Short: "Изменить заголовок и текст объявления",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			client, err := c.adsClient()
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := c.context(cmd)
+			defer cancel()
+
+			ad, err := client.UpdateAd(ctx, &grpcPort.UpdateAdRequest{AdId: id, UserId: userID, Title: title, Text: text})
+			if err != nil {
+				return err
+			}
+			return c.print(cmd.OutOrStdout(), adsTable{newAdView(ad)}, true)
+		},
+	}
+
+	cmd.Flags().Int64Var(&userID, "user", 0, "ID автора")
+	cmd.Flags().StringVar(&title, "title", "", "новый заголовок")
+	cmd.Flags().StringVar(&text, "text", "", "новый текст")
+	_ = cmd.MarkFlagRequired("user")
+
+	return cmd
+}
+
+func newAdsStatusCmd(c *cli, use string, published bool) *cobra.Command {
+	var userID int64
+
+	short := "Опубликовать объявление"
+	if !published {
+		short = "Снять объявление с публикации"
+	}
+
+	cmd := &cobra.Command{
+		Use:   use + " AD_ID",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			client, err := c.adsClient()
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := c.context(cmd)
+			defer cancel()
+
+			ad, err := client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{AdId: id, UserId: userID, Published: published})
+			if err != nil {
+				return err
+			}
+			return c.print(cmd.OutOrStdout(), adsTable{newAdView(ad)}, true)
+		},
+	}
+
+	cmd.Flags().Int64Var(&userID, "user", 0, "ID автора")
+	_ = cmd.MarkFlagRequired("user")
+
+	return cmd
+}
+
+func newAdsDeleteCmd(c *cli) *cobra.Command {
+	var userID int64
+
+	cmd := &cobra.Command{
+		Use:   "delete AD_ID",
+		Short: "Удалить объявление",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			client, err := c.adsClient()
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := c.context(cmd)
+			defer cancel()
+
+			_, err = client.DeleteAd(ctx, &grpcPort.DeleteAdRequest{AdId: id, AuthorId: userID})
+			return err
+		},
+	}
+
+	cmd.Flags().Int64Var(&userID, "user", 0, "ID автора")
+	_ = cmd.MarkFlagRequired("user")
+
+	return cmd
+}
+
+func newAdsSearchCmd(c *cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "search TITLE",
+		Short: "Найти опубликованные объявления по заголовку",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := c.adsClient()
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := c.context(cmd)
+			defer cancel()
+
+			res, err := client.FindAds(ctx, &grpcPort.FindAdsRequest{Title: args[0]})
+			if err != nil {
+				return err
+			}
+			return c.print(cmd.OutOrStdout(), newAdsTable(res.List), false)
+		},
+	}
+}
+
+func newAdsFilterCmd(c *cli) *cobra.Command {
+	var (
+		published bool
+		authorID  int64
+		date      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "filter",
+		Short: "Отфильтровать объявления по статусу, автору и дате создания",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := c.adsClient()
+			if err != nil {
+				return err
+			}
+
+			req := &grpcPort.FilterAdsRequest{OnlyPublished: published, Date: date}
+			if cmd.Flags().Changed("author") {
+				req.AuthorId = &authorID
+			}
+
+			ctx, cancel := c.context(cmd)
+			defer cancel()
+
+			res, err := client.FilterAds(ctx, req)
+			if err != nil {
+				return err
+			}
+			return c.print(cmd.OutOrStdout(), newAdsTable(res.List), false)
+		},
+	}
+
+	cmd.Flags().BoolVar(&published, "published", false, "только опубликованные")
+	cmd.Flags().Int64Var(&authorID, "author", 0, "ID автора")
+	cmd.Flags().StringVar(&date, "date", "", "дата создания в формате YYYY-MM-DD")
+
+	return cmd
+}
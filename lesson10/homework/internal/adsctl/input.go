@@ -0,0 +1,114 @@
+package adsctl
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// record - одна запись из файла массового ввода, ключи - названия колонок
+type record map[string]string
+
+func (r record) int64(key string) (int64, error) {
+	var v int64
+	if _, err := fmt.Sscan(r[key], &v); err != nil {
+		return 0, fmt.Errorf("field %q: %w", key, err)
+	}
+	return v, nil
+}
+
+// readRecords построчно читает файл в формате csv (первая строка - заголовок) или jsonl
+// и вызывает fn для каждой записи, не загружая файл в память целиком
+func readRecords(path string, format string, fn func(line int, rec record) error) error {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+
+	switch format {
+	case "csv":
+		return readCSV(in, fn)
+	case "jsonl":
+		return readJSONL(in, fn)
+	default:
+		return fmt.Errorf("unknown input format %q, use csv or jsonl", format)
+	}
+}
+
+func readCSV(in io.Reader, fn func(line int, rec record) error) error {
+	r := csv.NewReader(in)
+	r.ReuseRecord = true
+
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("can't read csv header: %w", err)
+	}
+	header = append([]string(nil), header...)
+
+	for line := 2; ; line++ {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		rec := make(record, len(header))
+		for i, col := range header {
+			if i < len(row) {
+				rec[strings.TrimSpace(col)] = row[i]
+			}
+		}
+
+		if err := fn(line, rec); err != nil {
+			return err
+		}
+	}
+}
+
+func readJSONL(in io.Reader, fn func(line int, rec record) error) error {
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for line := 1; sc.Scan(); line++ {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(sc.Bytes(), &raw); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		rec := make(record, len(raw))
+		for k, v := range raw {
+			var s string
+			if err := json.Unmarshal(v, &s); err != nil {
+				s = string(v)
+			}
+			rec[k] = s
+		}
+
+		if err := fn(line, rec); err != nil {
+			return err
+		}
+	}
+
+	return sc.Err()
+}
@@ -0,0 +1,113 @@
+package adsctl
+
+import (
+	"github.com/spf13/cobra"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func newModerationCmd(c *cli) *cobra.Command {
+	var moderatorID int64
+
+	cmd := &cobra.Command{
+		Use:   "moderation",
+		Short: "Ручная проверка объявлений",
+	}
+
+	cmd.PersistentFlags().Int64Var(&moderatorID, "user", 0, "ID модератора")
+	_ = cmd.MarkPersistentFlagRequired("user")
+
+	cmd.AddCommand(
+		newModerationQueueCmd(c, &moderatorID),
+		newModerationApproveCmd(c, &moderatorID),
+		newModerationRejectCmd(c, &moderatorID),
+	)
+
+	return cmd
+}
+
+func newModerationQueueCmd(c *cli, moderatorID *int64) *cobra.Command {
+	return &cobra.Command{
+		Use:   "queue",
+		Short: "Объявления, ожидающие проверки",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := c.adsClient()
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := c.context(cmd)
+			defer cancel()
+
+			res, err := client.ModerationQueue(ctx, &grpcPort.ModerationQueueRequest{UserId: *moderatorID})
+			if err != nil {
+				return err
+			}
+			return c.print(cmd.OutOrStdout(), newAdsTable(res.List), false)
+		},
+	}
+}
+
+func newModerationApproveCmd(c *cli, moderatorID *int64) *cobra.Command {
+	return &cobra.Command{
+		Use:   "approve AD_ID",
+		Short: "Одобрить объявление",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			client, err := c.adsClient()
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := c.context(cmd)
+			defer cancel()
+
+			ad, err := client.ApproveAd(ctx, &grpcPort.ApproveAdRequest{AdId: id, UserId: *moderatorID})
+			if err != nil {
+				return err
+			}
+			return c.print(cmd.OutOrStdout(), adsTable{newAdView(ad)}, true)
+		},
+	}
+}
+
+func newModerationRejectCmd(c *cli, moderatorID *int64) *cobra.Command {
+	var reason string
+
+	cmd := &cobra.Command{
+		Use:   "reject AD_ID",
+		Short: "Отклонить объявление",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			client, err := c.adsClient()
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := c.context(cmd)
+			defer cancel()
+
+			ad, err := client.RejectAd(ctx, &grpcPort.RejectAdRequest{AdId: id, UserId: *moderatorID, Reason: reason})
+			if err != nil {
+				return err
+			}
+			return c.print(cmd.OutOrStdout(), adsTable{newAdView(ad)}, true)
+		},
+	}
+
+	cmd.Flags().StringVar(&reason, "reason", "", "Причина отклонения, ее увидит автор")
+	_ = cmd.MarkFlagRequired("reason")
+
+	return cmd
+}
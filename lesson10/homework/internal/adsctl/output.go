@@ -0,0 +1,164 @@
+package adsctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+type adView struct {
+	ID        int64  `json:"id" yaml:"id"`
+	Title     string `json:"title" yaml:"title"`
+	Text      string `json:"text" yaml:"text"`
+	AuthorID  int64  `json:"author_id" yaml:"author_id"`
+	Published bool   `json:"published" yaml:"published"`
+
+	Moderation   string   `json:"moderation" yaml:"moderation"`
+	Flags        []string `json:"flags,omitempty" yaml:"flags,omitempty"`
+	RejectReason string   `json:"reject_reason,omitempty" yaml:"reject_reason,omitempty"`
+}
+
+type userView struct {
+	ID       int64  `json:"id" yaml:"id"`
+	Nickname string `json:"nickname" yaml:"nickname"`
+	Email    string `json:"email" yaml:"email"`
+	Role     string `json:"role" yaml:"role"`
+
+	EmailVerified bool `json:"email_verified" yaml:"email_verified"`
+}
+
+// table - данные, которые умеют выводиться в виде таблицы
+type table interface {
+	header() []string
+	rows() [][]string
+}
+
+type adsTable []adView
+
+func (t adsTable) header() []string {
+	return []string{"ID", "TITLE", "TEXT", "AUTHOR", "PUBLISHED", "MODERATION", "REASON"}
+}
+
+func (t adsTable) rows() [][]string {
+	res := make([][]string, 0, len(t))
+	for _, ad := range t {
+		res = append(res, []string{strconv.FormatInt(ad.ID, 10), ad.Title, ad.Text,
+			strconv.FormatInt(ad.AuthorID, 10), strconv.FormatBool(ad.Published), ad.Moderation, adReason(ad)})
+	}
+	return res
+}
+
+type usersTable []userView
+
+func (t usersTable) header() []string {
+	return []string{"ID", "NICKNAME", "EMAIL", "VERIFIED", "ROLE"}
+}
+
+func (t usersTable) rows() [][]string {
+	res := make([][]string, 0, len(t))
+	for _, u := range t {
+		res = append(res, []string{strconv.FormatInt(u.ID, 10), u.Nickname, u.Email,
+			strconv.FormatBool(u.EmailVerified), u.Role})
+	}
+	return res
+}
+
+func newAdView(ad *grpcPort.AdResponse) adView {
+	return adView{
+		ID:        ad.Id,
+		Title:     ad.Title,
+		Text:      ad.Text,
+		AuthorID:  ad.AuthorId,
+		Published: ad.Published,
+
+		Moderation:   ad.Moderation,
+		Flags:        ad.Flags,
+		RejectReason: ad.RejectReason,
+	}
+}
+
+// adReason - почему объявление не прошло модерацию: решение модератора или сработавшие правила
+func adReason(ad adView) string {
+	if ad.RejectReason != "" {
+		return ad.RejectReason
+	}
+	return strings.Join(ad.Flags, "; ")
+}
+
+func newAdsTable(list []*grpcPort.AdResponse) adsTable {
+	res := make(adsTable, 0, len(list))
+	for _, ad := range list {
+		res = append(res, newAdView(ad))
+	}
+	return res
+}
+
+func newUserView(u *grpcPort.UserResponse) userView {
+	return userView{ID: u.Id, Nickname: u.Name, Email: u.Email, Role: u.Role, EmailVerified: u.EmailVerified}
+}
+
+type printer func(w io.Writer, t table, single bool) error
+
+var printers = map[string]printer{
+	"table": printTable,
+	"json":  printJSON,
+	"yaml":  printYAML,
+}
+
+// value возвращает единственную запись для single == true, иначе весь список
+func value(t table, single bool) any {
+	if !single {
+		return t
+	}
+	switch v := t.(type) {
+	case adsTable:
+		return v[0]
+	case usersTable:
+		return v[0]
+	default:
+		return t
+	}
+}
+
+func printTable(w io.Writer, t table, _ bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	write := func(cols []string) {
+		for i, col := range cols {
+			if i > 0 {
+				_, _ = fmt.Fprint(tw, "\t")
+			}
+			_, _ = fmt.Fprint(tw, col)
+		}
+		_, _ = fmt.Fprintln(tw)
+	}
+
+	write(t.header())
+	for _, row := range t.rows() {
+		write(row)
+	}
+
+	return tw.Flush()
+}
+
+func printJSON(w io.Writer, t table, single bool) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(value(t, single))
+}
+
+func printYAML(w io.Writer, t table, single bool) error {
+	enc := yaml.NewEncoder(w)
+	defer enc.Close()
+	return enc.Encode(value(t, single))
+}
+
+func (c *cli) print(w io.Writer, t table, single bool) error {
+	return printers[c.opts.output](w, t, single)
+}
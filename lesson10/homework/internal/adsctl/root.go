@@ -0,0 +1,84 @@
+// Package adsctl - консольная утилита администрирования сервиса объявлений,
+// работающая с ним по gRPC
+package adsctl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+type options struct {
+	addr    string
+	timeout time.Duration
+	output  string
+}
+
+type cli struct {
+	opts   options
+	client grpcPort.AdServiceClient
+	conn   *grpc.ClientConn
+}
+
+// NewRootCmd создает корневую команду adsctl. Если client равен nil,
+// соединение с сервисом устанавливается по адресу из флага --addr
+func NewRootCmd(client grpcPort.AdServiceClient) *cobra.Command {
+	c := &cli{client: client}
+
+	root := &cobra.Command{
+		Use:           "adsctl",
+		Short:         "Утилита администрирования сервиса объявлений",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if _, ok := printers[c.opts.output]; !ok {
+				return fmt.Errorf("unknown output format %q", c.opts.output)
+			}
+			return nil
+		},
+		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			if c.conn != nil {
+				return c.conn.Close()
+			}
+			return nil
+		},
+	}
+
+	root.PersistentFlags().StringVar(&c.opts.addr, "addr", "localhost:18020", "адрес gRPC сервера")
+	root.PersistentFlags().DurationVar(&c.opts.timeout, "timeout", 10*time.Second, "таймаут одного запроса")
+	root.PersistentFlags().StringVarP(&c.opts.output, "output", "o", "table", "формат вывода: table, json или yaml")
+	_ = root.RegisterFlagCompletionFunc("output", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{"table", "json", "yaml"}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	root.AddCommand(newAdsCmd(c), newUsersCmd(c), newModerationCmd(c))
+
+	return root
+}
+
+// adsClient лениво устанавливает соединение с сервером
+func (c *cli) adsClient() (grpcPort.AdServiceClient, error) {
+	if c.client != nil {
+		return c.client, nil
+	}
+
+	conn, err := grpc.Dial(c.opts.addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("can't connect to %s: %w", c.opts.addr, err)
+	}
+
+	c.conn = conn
+	c.client = grpcPort.NewAdServiceClient(conn)
+
+	return c.client, nil
+}
+
+func (c *cli) context(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(cmd.Context(), c.opts.timeout)
+}
@@ -0,0 +1,307 @@
+package adsctl
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func newUsersCmd(c *cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "users",
+		Short: "Управление пользователями",
+	}
+
+	cmd.AddCommand(
+		newUsersCreateCmd(c),
+		newUsersGetCmd(c),
+		newUsersUpdateCmd(c),
+		newUsersDeleteCmd(c),
+		newUsersRoleCmd(c),
+		newUsersVerifyCmd(c),
+		newUsersResendCmd(c),
+	)
+
+	return cmd
+}
+
+func newUsersCreateCmd(c *cli) *cobra.Command {
+	var (
+		nickname, email, password string
+		file                      string
+		format                    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Зарегистрировать пользователя или загрузить пользователей из csv/jsonl файла",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := c.adsClient()
+			if err != nil {
+				return err
+			}
+
+			if file == "" {
+				ctx, cancel := c.context(cmd)
+				defer cancel()
+
+				u, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: nickname, Email: email, Password: password})
+				if err != nil {
+					return err
+				}
+				return c.print(cmd.OutOrStdout(), usersTable{newUserView(u)}, true)
+			}
+
+			created, failed := make(usersTable, 0), 0
+			err = readRecords(file, format, func(line int, rec record) error {
+				ctx, cancel := c.context(cmd)
+				defer cancel()
+
+				u, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: rec["nickname"], Email: rec["email"],
+					Password: rec["password"]})
+				if err == nil {
+					created = append(created, newUserView(u))
+					return nil
+				}
+
+				failed++
+				cmd.PrintErrf("line %d: %s\n", line, err)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			if err := c.print(cmd.OutOrStdout(), created, false); err != nil {
+				return err
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d records failed", failed)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&nickname, "nickname", "", "никнейм")
+	cmd.Flags().StringVar(&email, "email", "", "email")
+	cmd.Flags().StringVar(&password, "password", "", "пароль, от 8 до 72 байт")
+	cmd.Flags().StringVarP(&file, "file", "f", "", "файл с колонками nickname, email, password (\"-\" - stdin)")
+	cmd.Flags().StringVar(&format, "format", "", "формат файла: csv или jsonl (по умолчанию - по расширению)")
+	cmd.MarkFlagsMutuallyExclusive("file", "nickname")
+	cmd.MarkFlagsMutuallyExclusive("file", "email")
+	cmd.MarkFlagsMutuallyExclusive("file", "password")
+	_ = cmd.MarkFlagFilename("file", "csv", "jsonl")
+
+	return cmd
+}
+
+func newUsersGetCmd(c *cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get USER_ID",
+		Short: "Получить пользователя",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			client, err := c.adsClient()
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := c.context(cmd)
+			defer cancel()
+
+			u, err := client.GetUser(ctx, &grpcPort.GetUserRequest{Id: id})
+			if err != nil {
+				return err
+			}
+			return c.print(cmd.OutOrStdout(), usersTable{newUserView(u)}, true)
+		},
+	}
+}
+
+func newUsersUpdateCmd(c *cli) *cobra.Command {
+	var (
+		nickname, email string
+		actorID         int64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "update USER_ID",
+		Short: "Изменить никнейм и email пользователя",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			client, err := c.adsClient()
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := c.context(cmd)
+			defer cancel()
+
+			request := &grpcPort.UpdateUserRequest{Id: id, Name: nickname, Email: email}
+			if cmd.Flags().Changed("as") {
+				request.ActorId = &actorID
+			}
+
+			u, err := client.UpdateUser(ctx, request)
+			if err != nil {
+				return err
+			}
+			return c.print(cmd.OutOrStdout(), usersTable{newUserView(u)}, true)
+		},
+	}
+
+	cmd.Flags().StringVar(&nickname, "nickname", "", "новый никнейм")
+	cmd.Flags().StringVar(&email, "email", "", "новый email")
+	cmd.Flags().Int64Var(&actorID, "as", 0, "ID пользователя, выполняющего изменение, по умолчанию сам пользователь")
+
+	return cmd
+}
+
+func newUsersDeleteCmd(c *cli) *cobra.Command {
+	var actorID int64
+
+	cmd := &cobra.Command{
+		Use:   "delete USER_ID",
+		Short: "Удалить пользователя",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			client, err := c.adsClient()
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := c.context(cmd)
+			defer cancel()
+
+			request := &grpcPort.DeleteUserRequest{Id: id}
+			if cmd.Flags().Changed("as") {
+				request.ActorId = &actorID
+			}
+
+			_, err = client.DeleteUser(ctx, request)
+			return err
+		},
+	}
+
+	cmd.Flags().Int64Var(&actorID, "as", 0, "ID пользователя, выполняющего удаление, по умолчанию сам пользователь")
+
+	return cmd
+}
+
+func newUsersRoleCmd(c *cli) *cobra.Command {
+	var (
+		actorID int64
+		role    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "role USER_ID",
+		Short: "Назначить роль пользователю",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			client, err := c.adsClient()
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := c.context(cmd)
+			defer cancel()
+
+			u, err := client.SetUserRole(ctx, &grpcPort.SetUserRoleRequest{Id: id, ActorId: actorID, Role: role})
+			if err != nil {
+				return err
+			}
+			return c.print(cmd.OutOrStdout(), usersTable{newUserView(u)}, true)
+		},
+	}
+
+	cmd.Flags().Int64Var(&actorID, "as", 0, "ID администратора")
+	cmd.Flags().StringVar(&role, "role", "", "user, moderator или admin")
+	_ = cmd.MarkFlagRequired("as")
+	_ = cmd.MarkFlagRequired("role")
+
+	return cmd
+}
+
+func newUsersVerifyCmd(c *cli) *cobra.Command {
+	var token string
+
+	cmd := &cobra.Command{
+		Use:   "verify USER_ID",
+		Short: "Подтвердить email пользователя кодом из письма",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			client, err := c.adsClient()
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := c.context(cmd)
+			defer cancel()
+
+			u, err := client.VerifyEmail(ctx, &grpcPort.VerifyEmailRequest{Id: id, Token: token})
+			if err != nil {
+				return err
+			}
+			return c.print(cmd.OutOrStdout(), usersTable{newUserView(u)}, true)
+		},
+	}
+
+	cmd.Flags().StringVar(&token, "token", "", "код подтверждения из письма")
+	_ = cmd.MarkFlagRequired("token")
+
+	return cmd
+}
+
+func newUsersResendCmd(c *cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "resend USER_ID",
+		Short: "Повторно отправить код подтверждения email",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			client, err := c.adsClient()
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := c.context(cmd)
+			defer cancel()
+
+			_, err = client.ResendVerification(ctx, &grpcPort.ResendVerificationRequest{Id: id})
+			return err
+		},
+	}
+}
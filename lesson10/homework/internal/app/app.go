@@ -1,18 +1,34 @@
 package app
 
 import (
+	"context"
 	"errors"
 	validator "github.com/Danil-devv/structValidator"
 	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"homework10/internal/favorites"
+	"homework10/internal/mailer"
+	"homework10/internal/messages"
+	"homework10/internal/moderation"
+	"homework10/internal/notify"
 	"homework10/internal/users"
+	"homework10/internal/webhooks"
+	"log"
 	"net/mail"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 var (
 	ValidationErr = errors.New("some fields does not pass the validation")
 	AccessErr     = errors.New("user can only change his ads")
+
+	VerificationErr = errors.New("verification token is invalid or expired")
+	ThrottledErr    = errors.New("too many requests, try again later")
+	DisabledErr     = errors.New("feature is not configured")
 )
 
 //go:generate go run github.com/vektra/mockery/v2@v2.20.2 --output=./tests/mocks --name=App
@@ -21,43 +37,100 @@ type App interface {
 	GetAds() ([]ads.Ad, error)
 	ChangeAdStatus(adID int64, userID int64, published bool) (ads.Ad, error)
 	UpdateAd(adID int64, userID int64, title string, text string) (ads.Ad, error)
+	PatchAd(adID int64, userID int64, p ads.Patch) (ads.Ad, error)
 	GetAd(adID int64) (ads.Ad, error)
 	GetAdsByTitle(title string) ([]ads.Ad, error)
 	GetFilteredAds(published int, authorID int64, date string) ([]ads.Ad, error)
-	CreateUser(id int64, nickname string, email string) (users.User, error)
+	CreateUser(nickname string, email string, password string) (users.User, error)
+	VerifyEmail(id int64, token string) (users.User, error)
+	ResendVerification(id int64) error
 	GetUser(id int64) (users.User, error)
-	UpdateUser(id int64, nickname string, email string) (users.User, error)
-	DeleteUser(id int64) (users.User, error)
+	UpdateUser(id int64, actorID int64, nickname string, email string) (users.User, error)
+	DeleteUser(id int64, actorID int64) (users.User, error)
+	SetUserRole(id int64, actorID int64, role users.Role) (users.User, error)
 	DeleteAd(adID int64, authorID int64) (ads.Ad, error)
+	ImportAd(title string, text string, authorID int64, dryRun bool) (ads.Ad, error)
+	ExportAds(actorID int64, fn func(ad ads.Ad) error) error
+	ScheduleAd(adID int64, userID int64, publishAt time.Time, expiresAt time.Time) (ads.Ad, error)
+	RunScheduler(ctx context.Context, interval time.Duration) error
+	ModerationQueue(moderatorID int64) ([]ads.Ad, error)
+	ApproveAd(adID int64, moderatorID int64) (ads.Ad, error)
+	RejectAd(adID int64, moderatorID int64, reason string) (ads.Ad, error)
+	AuditLog(actorID int64, f audit.Filter) ([]audit.Entry, error)
+	AddFavorite(userID int64, adID int64) error
+	RemoveFavorite(userID int64, adID int64) error
+	Favorites(userID int64) ([]ads.Ad, error)
+	SaveSearch(userID int64, name string, f ads.Filter) (favorites.Search, error)
+	SavedSearches(userID int64) ([]favorites.Search, error)
+	DeleteSearch(userID int64, searchID int64) error
+	RunSearch(userID int64, searchID int64) ([]ads.Ad, error)
+	ContactAuthor(adID int64, userID int64, text string) (messages.Message, error)
+	SendMessage(threadID int64, userID int64, text string) (messages.Message, error)
+	Threads(userID int64) ([]messages.Thread, error)
+	Messages(threadID int64, userID int64) ([]messages.Message, error)
+	MarkThreadRead(threadID int64, userID int64) (messages.Thread, error)
+	SubscribeMessages(userID int64) (<-chan messages.Message, func(), error)
+	CreateWebhook(actorID int64, url string, secret string, events []string) (webhooks.Subscription, error)
+	Webhooks(actorID int64) ([]webhooks.Subscription, error)
+	DeleteWebhook(actorID int64, id int64) error
+	WebhookDeliveries(actorID int64, f webhooks.Filter) ([]webhooks.Delivery, error)
+	RedeliverWebhook(actorID int64, deliveryID int64) (webhooks.Delivery, error)
+	WithRequestID(requestID string) App
 }
 
-func NewApp(adRepo ads.Repository, usersRepo users.Repository) App {
-	return &app{adRepo: adRepo,
-		usersRepo: usersRepo}
+func NewApp(adRepo ads.Repository, usersRepo users.Repository, opts ...Option) App {
+	a := &app{adRepo: adRepo,
+		usersRepo: usersRepo,
+		clock:     systemClock{},
+		policy:    DefaultPolicy(),
+		admins:    make(map[int64]struct{}),
+		chat:      newHub(),
+		adSeq:     new(atomic.Int64),
+
+		passwordCost:   bcrypt.DefaultCost,
+		verifyTTL:      24 * time.Hour,
+		resendInterval: time.Minute}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+	a.indexAds()
+	a.adSeq.Store(lastAdID(adRepo) + 1)
+
+	return a
 }
 
 type app struct {
 	adRepo    ads.Repository
 	usersRepo users.Repository
-}
+	clock     Clock
+	handlers  []EventHandler
 
-func (a *app) CreateUser(id int64, nickname string, email string) (users.User, error) {
-	_, err := mail.ParseAddress(email)
-	if id < 0 || len(nickname) == 0 || err != nil {
-		return users.User{}, ValidationErr
-	}
+	// adSeq выдает ID новых объявлений: ID не меняется после удаления других объявлений
+	// и не переиспользуется, в отличие от места объявления в репозитории
+	adSeq *atomic.Int64
 
-	u := users.User{
-		ID:       id,
-		Nickname: nickname,
-		Email:    email,
-	}
-	err = a.usersRepo.AddUser(u)
-	if err != nil {
-		return users.User{}, err
-	}
+	moderation *moderation.Engine
+	adIndex    *moderation.Index
 
-	return u, nil
+	policy Policy
+	admins map[int64]struct{}
+
+	auditRepo audit.Repository
+	requestID string
+
+	mailer         mailer.Mailer
+	passwordCost   int
+	verifyTTL      time.Duration
+	resendInterval time.Duration
+
+	favorites favorites.Repository
+	notifier  notify.Notifier
+
+	messages messages.Repository
+	chat     *hub
+
+	webhooks *webhooks.Dispatcher
 }
 
 func (a *app) GetUser(id int64) (users.User, error) {
@@ -69,12 +142,17 @@ func (a *app) GetUser(id int64) (users.User, error) {
 	return u, nil
 }
 
-func (a *app) UpdateUser(id int64, nickname string, email string) (users.User, error) {
+func (a *app) UpdateUser(id int64, actorID int64, nickname string, email string) (users.User, error) {
 	u, err := a.usersRepo.GetById(id)
 	if err != nil {
 		return users.User{}, err
 	}
 
+	if err := a.authorize(actorID, ActionUpdateUser, id); err != nil {
+		return users.User{}, err
+	}
+	before := u
+
 	if nickname != "" {
 		u.Nickname = nickname
 	}
@@ -88,22 +166,62 @@ func (a *app) UpdateUser(id int64, nickname string, email string) (users.User, e
 		u.Email = email
 	}
 
-	return u, a.usersRepo.ReplaceByID(id, u)
+	u.UpdatedAt = a.clock.Now().UTC()
+
+	// новый адрес нужно подтвердить заново
+	var token string
+	if u.Email != before.Email {
+		if token, err = a.issueVerifyToken(&u); err != nil {
+			return users.User{}, err
+		}
+	}
+
+	if err := a.usersRepo.ReplaceByID(id, u); err != nil {
+		return users.User{}, err
+	}
+
+	a.record(actorID, ActionUpdateUser, audit.Target("user", id), before, u)
+
+	if token != "" {
+		if err := a.sendVerification(u, token); err != nil {
+			log.Printf("mailer: %s", err.Error())
+		}
+	}
+	return u, nil
 }
 
 func (a *app) CreateAd(title string, text string, authorID int64) (ads.Ad, error) {
-	t := time.Now().UTC()
-	ad := ads.Ad{ID: a.adRepo.GetSize(), Title: title, Text: text, AuthorID: authorID,
+	t := a.clock.Now().UTC()
+	ad := ads.Ad{ID: a.adSeq.Add(1) - 1, Title: title, Text: text, AuthorID: authorID,
 		CreateDate: t, LastUpdate: t}
 
 	if err := validator.Validate(ad); err != nil {
 		return ads.Ad{}, ValidationErr
 	}
 
+	a.moderate(&ad)
 	a.adRepo.AddAd(ad)
+	a.trackAd(nil, &ad)
+
+	a.record(authorID, ActionCreateAd, audit.Target("ad", ad.ID), nil, ad)
+	a.emit(EventAdCreated, ad)
+	if ad.Visible() {
+		a.matchSearches(ad)
+	}
 	return ad, nil
 }
 
+// lastAdID возвращает наибольший ID объявления в repo или -1, если объявлений нет
+func lastAdID(repo ads.Repository) int64 {
+	res := int64(-1)
+	for i := int64(0); i < repo.GetSize(); i++ {
+		if ad, err := repo.GetById(i); err == nil && ad.ID > res {
+			res = ad.ID
+		}
+	}
+	return res
+}
+
 func (a *app) GetAds() ([]ads.Ad, error) {
 	res := make([]ads.Ad, 0)
 	for i := int64(0); i < a.adRepo.GetSize(); i++ {
@@ -111,7 +229,7 @@ func (a *app) GetAds() ([]ads.Ad, error) {
 		if err != nil {
 			return []ads.Ad{}, err
 		}
-		if r.Published {
+		if r.Visible() {
 			res = append(res, r)
 		}
 	}
@@ -125,45 +243,105 @@ func (a *app) GetAd(adID int64) (ads.Ad, error) {
 		return ads.Ad{}, err
 	}
 
-	if !ad.Published {
+	if !ad.Visible() {
 		return ads.Ad{}, AccessErr
 	}
 	return ad, nil
 }
 
 func (a *app) ChangeAdStatus(adID int64, userID int64, published bool) (ads.Ad, error) {
-	t := time.Now().UTC()
+	t := a.clock.Now().UTC()
 	ad, err := a.adRepo.GetById(adID)
 	if err != nil {
 		return ads.Ad{}, err
 	}
 
-	if ad.AuthorID != userID {
-		return ads.Ad{}, AccessErr
+	action := ActionPublishAd
+	if !published {
+		action = ActionUnpublishAd
+	}
+	if err := a.authorize(userID, action, ad.AuthorID); err != nil {
+		return ads.Ad{}, err
 	}
 
+	before := ad
 	ad.Published, ad.LastUpdate = published, t
-	return ad, a.adRepo.ReplaceByID(adID, ad)
+	return ad, a.replaceAd(adID, userID, action, before, ad)
 }
 
 func (a *app) UpdateAd(adID int64, userID int64, title string, text string) (ads.Ad, error) {
-	t := time.Now().UTC()
+	t := a.clock.Now().UTC()
 	ad, err := a.adRepo.GetById(adID)
 	if err != nil {
 		return ads.Ad{}, err
 	}
 
-	if ad.AuthorID != userID {
-		return ads.Ad{}, AccessErr
+	if err := a.authorize(userID, ActionUpdateAd, ad.AuthorID); err != nil {
+		return ads.Ad{}, err
 	}
 
+	before := ad
 	ad.Title, ad.Text, ad.LastUpdate = title, text, t
 
 	if err := validator.Validate(ad); err != nil {
 		return ads.Ad{}, ValidationErr
 	}
 
-	return ad, a.adRepo.ReplaceByID(adID, ad)
+	a.moderate(&ad)
+	return ad, a.replaceAd(adID, userID, ActionUpdateAd, before, ad)
+}
+
+// PatchAd меняет только заданные поля объявления: заголовок и текст через UpdateAd,
+// публикацию через ChangeAdStatus, поэтому проверки прав и модерация у них общие
+func (a *app) PatchAd(adID int64, userID int64, p ads.Patch) (ads.Ad, error) {
+	ad, err := a.adRepo.GetById(adID)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+
+	// даже пустое изменение возвращает объявление, поэтому доступно только тем, кто может его менять
+	if err := a.authorize(userID, ActionUpdateAd, ad.AuthorID); err != nil {
+		return ads.Ad{}, err
+	}
+
+	if p.Title != nil || p.Text != nil {
+		title, text := ad.Title, ad.Text
+		if p.Title != nil {
+			title = *p.Title
+		}
+		if p.Text != nil {
+			text = *p.Text
+		}
+
+		if ad, err = a.UpdateAd(adID, userID, title, text); err != nil {
+			return ads.Ad{}, err
+		}
+	}
+
+	if p.Published != nil && *p.Published != ad.Published {
+		return a.ChangeAdStatus(adID, userID, *p.Published)
+	}
+	return ad, nil
+}
+
+// replaceAd сохраняет измененное объявление на место adID, откуда оно было прочитано,
+// записывает операцию в журнал аудита по ID объявления, отправляет события изменения и рассылает
+// уведомления по сохраненным поискам, если объявление стало видно всем. После удаления ad.ID может
+// не совпадать с adID
+func (a *app) replaceAd(adID int64, actorID int64, action Action, before ads.Ad, after ads.Ad) error {
+	if err := a.adRepo.ReplaceByID(adID, after); err != nil {
+		return err
+	}
+	a.trackAd(&before, &after)
+
+	a.record(actorID, action, audit.Target("ad", after.ID), before, after)
+	for _, t := range adEvents(action, before, after) {
+		a.emit(t, after)
+	}
+	if !before.Visible() && after.Visible() {
+		a.matchSearches(after)
+	}
+	return nil
 }
 
 func (a *app) GetAdsByTitle(title string) ([]ads.Ad, error) {
@@ -171,7 +349,7 @@ func (a *app) GetAdsByTitle(title string) ([]ads.Ad, error) {
 
 	for i := int64(0); i < a.adRepo.GetSize(); i++ {
 		ad, _ := a.adRepo.GetById(i)
-		if strings.Contains(ad.Title, title) && ad.Published {
+		if strings.Contains(ad.Title, title) && ad.Visible() {
 			res = append(res, ad)
 		}
 	}
@@ -180,6 +358,10 @@ func (a *app) GetAdsByTitle(title string) ([]ads.Ad, error) {
 }
 
 func (a *app) GetFilteredAds(published int, authorID int64, date string) ([]ads.Ad, error) {
+	return a.filterAds(ads.Filter{Published: published, AuthorID: authorID, Date: date})
+}
+
+func (a *app) filterAds(f ads.Filter) ([]ads.Ad, error) {
 	res := make([]ads.Ad, 0)
 	for i := int64(0); i < a.adRepo.GetSize(); i++ {
 		r, err := a.adRepo.GetById(i)
@@ -187,38 +369,112 @@ func (a *app) GetFilteredAds(published int, authorID int64, date string) ([]ads.
 			return []ads.Ad{}, err
 		}
 
-		if published == 1 && !r.Published {
-			continue
+		if f.Match(r) {
+			res = append(res, r)
 		}
+	}
+	return res, nil
+}
 
-		if authorID != -1 && r.AuthorID != authorID {
-			continue
-		}
+func (a *app) DeleteUser(id int64, actorID int64) (users.User, error) {
+	if _, err := a.usersRepo.GetById(id); err != nil {
+		return users.User{}, err
+	}
 
-		if date != "" && date != r.CreateDate.Format(time.DateOnly) {
-			continue
-		}
+	if err := a.authorize(actorID, ActionDeleteUser, id); err != nil {
+		return users.User{}, err
+	}
 
-		res = append(res, r)
+	res, err := a.usersRepo.DeleteByID(id)
+	if err != nil {
+		return users.User{}, err
 	}
+
+	a.record(actorID, ActionDeleteUser, audit.Target("user", id), res, nil)
 	return res, nil
 }
 
-func (a *app) DeleteUser(id int64) (users.User, error) {
-	res, err := a.usersRepo.DeleteByID(id)
+// SetUserRole назначает пользователю роль, по умолчанию доступно только администраторам
+func (a *app) SetUserRole(id int64, actorID int64, role users.Role) (users.User, error) {
+	if !role.Valid() {
+		return users.User{}, ValidationErr
+	}
+
+	u, err := a.usersRepo.GetById(id)
 	if err != nil {
 		return users.User{}, err
 	}
-	return res, nil
+
+	if err := a.authorize(actorID, ActionSetRole, -1); err != nil {
+		return users.User{}, err
+	}
+
+	before := u
+	u.Role, u.UpdatedAt = role, a.clock.Now().UTC()
+	if err := a.usersRepo.ReplaceByID(id, u); err != nil {
+		return users.User{}, err
+	}
+
+	a.record(actorID, ActionSetRole, audit.Target("user", id), before, u)
+	return u, nil
 }
 
 func (a *app) DeleteAd(adID int64, authorID int64) (ads.Ad, error) {
-	ad, err := a.adRepo.DeleteByID(adID)
+	ad, err := a.adRepo.GetById(adID)
 	if err != nil {
 		return ads.Ad{}, err
 	}
-	if ad.AuthorID != authorID {
-		return ads.Ad{}, AccessErr
+
+	if err := a.authorize(authorID, ActionDeleteAd, ad.AuthorID); err != nil {
+		return ads.Ad{}, err
+	}
+
+	ad, err = a.adRepo.DeleteByID(adID)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	a.trackAd(&ad, nil)
+
+	a.record(authorID, ActionDeleteAd, audit.Target("ad", ad.ID), ad, nil)
+	a.emit(EventAdDeleted, ad)
+	return ad, nil
+}
+
+// ImportAd проверяет объявление по тем же правилам, что и CreateAd,
+// и сохраняет его, если dryRun == false
+func (a *app) ImportAd(title string, text string, authorID int64, dryRun bool) (ads.Ad, error) {
+	if !dryRun {
+		return a.CreateAd(title, text, authorID)
+	}
+
+	t := a.clock.Now().UTC()
+	ad := ads.Ad{Title: title, Text: text, AuthorID: authorID, CreateDate: t, LastUpdate: t}
+
+	if err := validator.Validate(ad); err != nil {
+		return ads.Ad{}, ValidationErr
 	}
+
 	return ad, nil
 }
+
+// ExportAds по одному передает в fn объявления, не собирая их в память.
+// Все объявления, включая неопубликованные, получает только тот, кому политика
+// разрешает ActionExportAds, остальные - только видимые всем
+func (a *app) ExportAds(actorID int64, fn func(ad ads.Ad) error) error {
+	all := a.policy.Allows(a.actor(actorID).Role, ActionExportAds)
+
+	for i := int64(0); i < a.adRepo.GetSize(); i++ {
+		ad, err := a.adRepo.GetById(i)
+		if err != nil {
+			return err
+		}
+		if !all && !ad.Visible() {
+			continue
+		}
+
+		if err := fn(ad); err != nil {
+			return err
+		}
+	}
+	return nil
+}
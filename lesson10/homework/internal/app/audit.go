@@ -0,0 +1,52 @@
+package app
+
+import (
+	"homework10/internal/audit"
+	"log"
+)
+
+// record добавляет в журнал аудита запись об успешной изменяющей операции.
+// before и after - состояние объекта до и после операции, nil для созданных и удаленных объектов
+func (a *app) record(actorID int64, action Action, target string, before any, after any) {
+	if a.auditRepo == nil {
+		return
+	}
+
+	role := "system"
+	if actorID != audit.SystemActor {
+		role = string(a.actor(actorID).Role)
+	}
+
+	_, err := a.auditRepo.Append(audit.Entry{
+		At:        a.clock.Now().UTC(),
+		ActorID:   actorID,
+		Role:      role,
+		Action:    string(action),
+		Target:    target,
+		Before:    audit.Snapshot(before),
+		After:     audit.Snapshot(after),
+		RequestID: a.requestID,
+	})
+	if err != nil {
+		log.Printf("audit: %s", err.Error())
+	}
+}
+
+// AuditLog возвращает записи журнала аудита, по умолчанию доступно только администраторам
+func (a *app) AuditLog(actorID int64, f audit.Filter) ([]audit.Entry, error) {
+	if !a.policy.Allows(a.actor(actorID).Role, ActionReadAudit) {
+		return []audit.Entry{}, AccessErr
+	}
+
+	if a.auditRepo == nil {
+		return []audit.Entry{}, nil
+	}
+	return a.auditRepo.List(f)
+}
+
+// WithRequestID возвращает копию app, которая помечает записи аудита идентификатором запроса
+func (a *app) WithRequestID(requestID string) App {
+	cp := *a
+	cp.requestID = requestID
+	return &cp
+}
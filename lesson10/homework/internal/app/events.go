@@ -0,0 +1,73 @@
+package app
+
+import (
+	"homework10/internal/ads"
+	"log"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventAdCreated     EventType = "ad.created"
+	EventAdUpdated     EventType = "ad.updated"
+	EventAdDeleted     EventType = "ad.deleted"
+	EventAdPublished   EventType = "ad.published"
+	EventAdUnpublished EventType = "ad.unpublished"
+	EventAdApproved    EventType = "ad.approved"
+	EventAdRejected    EventType = "ad.rejected"
+)
+
+// EventTypes - все события, на которые можно подписать webhook
+var EventTypes = []EventType{EventAdCreated, EventAdUpdated, EventAdDeleted, EventAdPublished,
+	EventAdUnpublished, EventAdApproved, EventAdRejected}
+
+// Event - переход объявления из одного состояния в другое
+type Event struct {
+	Type EventType
+	Ad   ads.Ad
+	At   time.Time
+}
+
+// EventHandler вызывается синхронно, поэтому не должен блокироваться надолго
+type EventHandler func(e Event)
+
+// adEvents возвращает события изменения объявления действием action: правка текста,
+// решение модератора и смена публикации, вручную или по расписанию
+func adEvents(action Action, before ads.Ad, after ads.Ad) []EventType {
+	var res []EventType
+	if action == ActionUpdateAd {
+		res = append(res, EventAdUpdated)
+	}
+
+	if action == ActionModerateAd {
+		switch after.Moderation {
+		case ads.ModerationApproved:
+			res = append(res, EventAdApproved)
+		case ads.ModerationRejected:
+			res = append(res, EventAdRejected)
+		}
+	}
+
+	if before.Published != after.Published {
+		if after.Published {
+			res = append(res, EventAdPublished)
+		} else {
+			res = append(res, EventAdUnpublished)
+		}
+	}
+	return res
+}
+
+func (a *app) emit(t EventType, ad ads.Ad) {
+	e := Event{Type: t, Ad: ad, At: a.clock.Now().UTC()}
+	for _, h := range a.handlers {
+		h(e)
+	}
+
+	if a.webhooks != nil {
+		if err := a.webhooks.Publish(string(e.Type), e.At, e.Ad); err != nil {
+			log.Printf("webhooks: %s", err.Error())
+		}
+	}
+}
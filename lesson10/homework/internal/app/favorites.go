@@ -0,0 +1,169 @@
+package app
+
+import (
+	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"homework10/internal/favorites"
+	"homework10/internal/notify"
+	"log"
+	"time"
+)
+
+// AddFavorite добавляет видимое всем объявление в избранное пользователя
+func (a *app) AddFavorite(userID int64, adID int64) error {
+	if err := a.checkFavorites(userID); err != nil {
+		return err
+	}
+
+	ad, err := a.GetAd(adID)
+	if err != nil {
+		return err
+	}
+
+	if err := a.favorites.AddFavorite(userID, adID); err != nil {
+		return err
+	}
+
+	a.record(userID, ActionAddFavorite, audit.Target("ad", ad.ID), nil, nil)
+	return nil
+}
+
+func (a *app) RemoveFavorite(userID int64, adID int64) error {
+	if err := a.checkFavorites(userID); err != nil {
+		return err
+	}
+
+	if err := a.favorites.RemoveFavorite(userID, adID); err != nil {
+		return err
+	}
+
+	// журнал ведется по ID объявления, а удаленное объявление известно только по месту
+	target := adID
+	if ad, err := a.adRepo.GetById(adID); err == nil {
+		target = ad.ID
+	}
+	a.record(userID, ActionRemoveFavorite, audit.Target("ad", target), nil, nil)
+	return nil
+}
+
+// Favorites возвращает избранные объявления пользователя. Удаленные и снятые с публикации
+// объявления остаются в избранном, но не возвращаются, пока снова не станут видны
+func (a *app) Favorites(userID int64) ([]ads.Ad, error) {
+	if err := a.checkFavorites(userID); err != nil {
+		return []ads.Ad{}, err
+	}
+
+	ids, err := a.favorites.Favorites(userID)
+	if err != nil {
+		return []ads.Ad{}, err
+	}
+
+	res := make([]ads.Ad, 0, len(ids))
+	for _, id := range ids {
+		if ad, err := a.adRepo.GetById(id); err == nil && ad.Visible() {
+			res = append(res, ad)
+		}
+	}
+	return res, nil
+}
+
+// SaveSearch сохраняет поиск по критериям GetFilteredAds. Поиск всегда идет только
+// по видимым всем объявлениям, о новых подходящих объявлениях пользователь получает уведомления
+func (a *app) SaveSearch(userID int64, name string, f ads.Filter) (favorites.Search, error) {
+	if err := a.checkFavorites(userID); err != nil {
+		return favorites.Search{}, err
+	}
+
+	if name == "" {
+		return favorites.Search{}, ValidationErr
+	}
+	if f.Date != "" {
+		if _, err := time.Parse(time.DateOnly, f.Date); err != nil {
+			return favorites.Search{}, ValidationErr
+		}
+	}
+
+	f.Published = 1
+	s, err := a.favorites.AddSearch(favorites.Search{UserID: userID, Name: name, Filter: f,
+		CreatedAt: a.clock.Now().UTC()})
+	if err != nil {
+		return favorites.Search{}, err
+	}
+
+	a.record(userID, ActionSaveSearch, audit.Target("search", s.ID), nil, s)
+	return s, nil
+}
+
+func (a *app) SavedSearches(userID int64) ([]favorites.Search, error) {
+	if err := a.checkFavorites(userID); err != nil {
+		return []favorites.Search{}, err
+	}
+
+	return a.favorites.Searches(userID)
+}
+
+func (a *app) DeleteSearch(userID int64, searchID int64) error {
+	if err := a.checkFavorites(userID); err != nil {
+		return err
+	}
+
+	s, err := a.favorites.DeleteSearch(userID, searchID)
+	if err != nil {
+		return err
+	}
+
+	a.record(userID, ActionDeleteSearch, audit.Target("search", searchID), s, nil)
+	return nil
+}
+
+// RunSearch возвращает объявления, подходящие под сохраненный поиск пользователя
+func (a *app) RunSearch(userID int64, searchID int64) ([]ads.Ad, error) {
+	list, err := a.SavedSearches(userID)
+	if err != nil {
+		return []ads.Ad{}, err
+	}
+
+	for _, s := range list {
+		if s.ID == searchID {
+			return a.filterAds(s.Filter)
+		}
+	}
+	return []ads.Ad{}, favorites.ErrSearchNotFound
+}
+
+// checkFavorites проверяет, что избранное настроено и пользователь существует
+func (a *app) checkFavorites(userID int64) error {
+	if a.favorites == nil {
+		return DisabledErr
+	}
+
+	_, err := a.usersRepo.GetById(userID)
+	return err
+}
+
+// matchSearches уведомляет владельцев сохраненных поисков о том, что ad стало видно всем.
+// Автор не получает уведомлений о своих объявлениях
+func (a *app) matchSearches(ad ads.Ad) {
+	if a.favorites == nil || a.notifier == nil {
+		return
+	}
+
+	list, err := a.favorites.AllSearches()
+	if err != nil {
+		log.Printf("saved searches: %s", err.Error())
+		return
+	}
+
+	now := a.clock.Now().UTC()
+	for _, s := range list {
+		if s.UserID == ad.AuthorID || !s.Filter.Match(ad) {
+			continue
+		}
+
+		err := a.notifier.Notify(notify.Notification{UserID: s.UserID, SearchID: s.ID, SearchName: s.Name,
+			AdID: ad.ID, AdTitle: ad.Title, At: now})
+		if err != nil {
+			log.Printf("notifier: %s", err.Error())
+		}
+	}
+}
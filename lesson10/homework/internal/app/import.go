@@ -0,0 +1,37 @@
+package app
+
+// MaxImportErrors - сколько ошибок импорта сохраняется в отчете,
+// чтобы при импорте миллионов некорректных строк отчет не занимал всю память
+const MaxImportErrors = 1000
+
+type ImportError struct {
+	Line int64
+	Err  error
+}
+
+// ImportReport - итог массового импорта объявлений
+type ImportReport struct {
+	Total    int64
+	Imported int64
+	Failed   int64
+	DryRun   bool
+	Errors   []ImportError
+}
+
+func NewImportReport(dryRun bool) *ImportReport {
+	return &ImportReport{DryRun: dryRun, Errors: make([]ImportError, 0)}
+}
+
+// Add учитывает результат обработки строки line
+func (r *ImportReport) Add(line int64, err error) {
+	r.Total++
+	if err == nil {
+		r.Imported++
+		return
+	}
+
+	r.Failed++
+	if len(r.Errors) < MaxImportErrors {
+		r.Errors = append(r.Errors, ImportError{Line: line, Err: err})
+	}
+}
@@ -0,0 +1,196 @@
+package app
+
+import (
+	"errors"
+	"homework10/internal/audit"
+	"homework10/internal/messages"
+	"log"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	maxMessageLen = 1000
+	// subscriptionBuffer - сколько сообщений может накопиться у медленного подписчика,
+	// прежде чем новые сообщения начнут для него теряться
+	subscriptionBuffer = 64
+)
+
+// ContactAuthor отправляет сообщение автору видимого всем объявления. Переписка покупателя
+// по объявлению создается при первом сообщении, последующие сообщения попадают в нее же
+func (a *app) ContactAuthor(adID int64, userID int64, text string) (messages.Message, error) {
+	if err := a.checkMessages(userID); err != nil {
+		return messages.Message{}, err
+	}
+
+	ad, err := a.GetAd(adID)
+	if err != nil {
+		return messages.Message{}, err
+	}
+	if ad.AuthorID == userID {
+		return messages.Message{}, ValidationErr
+	}
+	if err := validateMessage(text); err != nil {
+		return messages.Message{}, err
+	}
+
+	t, err := a.messages.FindThread(adID, userID)
+	if errors.Is(err, messages.ErrThreadNotFound) {
+		t, err = a.messages.AddThread(messages.Thread{AdID: adID, AuthorID: ad.AuthorID, BuyerID: userID,
+			CreatedAt: a.clock.Now().UTC()})
+	}
+	if err != nil {
+		return messages.Message{}, err
+	}
+
+	return a.send(t, userID, text)
+}
+
+// SendMessage отправляет сообщение в переписку, в которой участвует пользователь
+func (a *app) SendMessage(threadID int64, userID int64, text string) (messages.Message, error) {
+	t, err := a.thread(threadID, userID)
+	if err != nil {
+		return messages.Message{}, err
+	}
+	if err := validateMessage(text); err != nil {
+		return messages.Message{}, err
+	}
+
+	return a.send(t, userID, text)
+}
+
+// Threads возвращает переписки пользователя, начиная с последней активной
+func (a *app) Threads(userID int64) ([]messages.Thread, error) {
+	if err := a.checkMessages(userID); err != nil {
+		return []messages.Thread{}, err
+	}
+
+	return a.messages.Threads(userID)
+}
+
+func (a *app) Messages(threadID int64, userID int64) ([]messages.Message, error) {
+	if _, err := a.thread(threadID, userID); err != nil {
+		return []messages.Message{}, err
+	}
+
+	return a.messages.Messages(threadID)
+}
+
+// MarkThreadRead отмечает прочитанными все сообщения переписки, адресованные пользователю
+func (a *app) MarkThreadRead(threadID int64, userID int64) (messages.Thread, error) {
+	if _, err := a.thread(threadID, userID); err != nil {
+		return messages.Thread{}, err
+	}
+
+	return a.messages.MarkRead(threadID, userID)
+}
+
+// SubscribeMessages возвращает канал, в который приходят все новые сообщения в переписках
+// пользователя, включая отправленные им самим. После вызова cancel канал закрывается
+func (a *app) SubscribeMessages(userID int64) (<-chan messages.Message, func(), error) {
+	if err := a.checkMessages(userID); err != nil {
+		return nil, nil, err
+	}
+
+	ch, cancel := a.chat.subscribe(userID)
+	return ch, cancel, nil
+}
+
+// checkMessages проверяет, что переписка настроена и пользователь существует
+func (a *app) checkMessages(userID int64) error {
+	if a.messages == nil {
+		return DisabledErr
+	}
+
+	_, err := a.usersRepo.GetById(userID)
+	return err
+}
+
+// thread возвращает переписку, если пользователь в ней участвует
+func (a *app) thread(threadID int64, userID int64) (messages.Thread, error) {
+	if err := a.checkMessages(userID); err != nil {
+		return messages.Thread{}, err
+	}
+
+	t, err := a.messages.GetThread(threadID)
+	if err != nil {
+		return messages.Thread{}, err
+	}
+	if !t.Has(userID) {
+		return messages.Thread{}, AccessErr
+	}
+	return t, nil
+}
+
+func (a *app) send(t messages.Thread, senderID int64, text string) (messages.Message, error) {
+	m, err := a.messages.AddMessage(messages.Message{ThreadID: t.ID, SenderID: senderID, Text: text,
+		SentAt: a.clock.Now().UTC()})
+	if err != nil {
+		return messages.Message{}, err
+	}
+
+	// текст переписки в журнал не попадает
+	a.record(senderID, ActionSendMessage, audit.Target("thread", t.ID), nil, nil)
+
+	a.chat.publish(senderID, m)
+	a.chat.publish(t.Peer(senderID), m)
+	return m, nil
+}
+
+func validateMessage(text string) error {
+	if strings.TrimSpace(text) == "" || utf8.RuneCountInString(text) > maxMessageLen {
+		return ValidationErr
+	}
+	return nil
+}
+
+// hub рассылает новые сообщения подписчикам SubscribeMessages
+type hub struct {
+	subs map[int64]map[chan messages.Message]struct{}
+	m    sync.Mutex
+}
+
+func newHub() *hub {
+	return &hub{subs: make(map[int64]map[chan messages.Message]struct{})}
+}
+
+func (h *hub) subscribe(userID int64) (<-chan messages.Message, func()) {
+	ch := make(chan messages.Message, subscriptionBuffer)
+
+	h.m.Lock()
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[chan messages.Message]struct{})
+	}
+	h.subs[userID][ch] = struct{}{}
+	h.m.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.m.Lock()
+			defer h.m.Unlock()
+
+			delete(h.subs[userID], ch)
+			if len(h.subs[userID]) == 0 {
+				delete(h.subs, userID)
+			}
+			close(ch)
+		})
+	}
+}
+
+// publish не блокируется: если подписчик не успевает читать, сообщение для него теряется,
+// но остается в переписке
+func (h *hub) publish(userID int64, m messages.Message) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	for ch := range h.subs[userID] {
+		select {
+		case ch <- m:
+		default:
+			log.Printf("chat: subscriber of user %d is too slow, message %d dropped", userID, m.ID)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"homework10/internal/ads"
+	"homework10/internal/moderation"
+)
+
+// moderate проверяет объявление правилами модерации: без нарушений оно одобряется сразу,
+// иначе попадает в очередь ручной проверки. Прежнее решение модератора сбрасывается
+func (a *app) moderate(ad *ads.Ad) {
+	repo := a.adRepo
+	if a.adIndex != nil {
+		repo = a.adIndex.Indexed(repo)
+	}
+	ad.Flags = a.moderation.Review(*ad, repo)
+	ad.RejectReason = ""
+
+	ad.Moderation = ads.ModerationApproved
+	if len(ad.Flags) > 0 {
+		ad.Moderation = ads.ModerationPending
+	}
+}
+
+// indexAds строит индекс объявлений для правил модерации по уже сохраненным объявлениям
+func (a *app) indexAds() {
+	if a.moderation == nil {
+		return
+	}
+
+	a.adIndex = moderation.NewIndex()
+	for i := int64(0); i < a.adRepo.GetSize(); i++ {
+		if ad, err := a.adRepo.GetById(i); err == nil {
+			a.adIndex.Track(nil, &ad)
+		}
+	}
+}
+
+// trackAd обновляет индекс модерации после создания, изменения или удаления объявления
+func (a *app) trackAd(before, after *ads.Ad) {
+	if a.adIndex != nil {
+		a.adIndex.Track(before, after)
+	}
+}
+
+// ModerationQueue возвращает объявления, ожидающие ручной проверки
+func (a *app) ModerationQueue(moderatorID int64) ([]ads.Ad, error) {
+	if !a.policy.Allows(a.actor(moderatorID).Role, ActionModerateAd) {
+		return []ads.Ad{}, AccessErr
+	}
+
+	res := make([]ads.Ad, 0)
+	for i := int64(0); i < a.adRepo.GetSize(); i++ {
+		ad, err := a.adRepo.GetById(i)
+		if err != nil {
+			return []ads.Ad{}, err
+		}
+		if ad.Moderation == ads.ModerationPending {
+			res = append(res, ad)
+		}
+	}
+	return res, nil
+}
+
+func (a *app) ApproveAd(adID int64, moderatorID int64) (ads.Ad, error) {
+	return a.review(adID, moderatorID, ads.ModerationApproved, "")
+}
+
+// RejectAd отклоняет объявление, причина reason обязательна и показывается автору
+func (a *app) RejectAd(adID int64, moderatorID int64, reason string) (ads.Ad, error) {
+	if reason == "" {
+		return ads.Ad{}, ValidationErr
+	}
+	return a.review(adID, moderatorID, ads.ModerationRejected, reason)
+}
+
+func (a *app) review(adID int64, moderatorID int64, status ads.ModerationStatus, reason string) (ads.Ad, error) {
+	ad, err := a.adRepo.GetById(adID)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+
+	if err := a.authorize(moderatorID, ActionModerateAd, ad.AuthorID); err != nil {
+		return ads.Ad{}, err
+	}
+
+	before := ad
+	ad.Moderation, ad.RejectReason, ad.LastUpdate = status, reason, a.clock.Now().UTC()
+	if err := a.replaceAd(adID, moderatorID, ActionModerateAd, before, ad); err != nil {
+		return ads.Ad{}, err
+	}
+	return ad, nil
+}
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"homework10/internal/audit"
+	"homework10/internal/favorites"
+	"homework10/internal/mailer"
+	"homework10/internal/messages"
+	"homework10/internal/moderation"
+	"homework10/internal/notify"
+	"homework10/internal/webhooks"
+	"time"
+)
+
+// Clock - источник текущего времени, в тестах подменяется управляемыми часами
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+type Option func(a *app)
+
+// WithClock задает часы, по которым app проставляет даты и запускает отложенные публикации
+func WithClock(c Clock) Option {
+	return func(a *app) {
+		a.clock = c
+	}
+}
+
+// WithEventHandler добавляет обработчик событий жизненного цикла объявлений
+func WithEventHandler(h EventHandler) Option {
+	return func(a *app) {
+		a.handlers = append(a.handlers, h)
+	}
+}
+
+// WithModeration задает правила, по которым новые и измененные объявления одобряются автоматически
+// или отправляются в очередь ручной проверки. Без этой опции все объявления одобряются сразу
+func WithModeration(e *moderation.Engine) Option {
+	return func(a *app) {
+		a.moderation = e
+	}
+}
+
+// WithPolicy заменяет политику доступа DefaultPolicy
+func WithPolicy(p Policy) Option {
+	return func(a *app) {
+		a.policy = p
+	}
+}
+
+// WithAdmins задает пользователей, которые всегда считаются администраторами.
+// Через них назначаются роли остальным пользователям
+func WithAdmins(ids ...int64) Option {
+	return func(a *app) {
+		for _, id := range ids {
+			a.admins[id] = struct{}{}
+		}
+	}
+}
+
+// WithAuditLog задает журнал, в который записываются все изменяющие операции.
+// Без этой опции журнал не ведется
+func WithAuditLog(r audit.Repository) Option {
+	return func(a *app) {
+		a.auditRepo = r
+	}
+}
+
+// WithMailer задает способ доставки писем с токенами подтверждения email.
+// Без этой опции письма не отправляются
+func WithMailer(m mailer.Mailer) Option {
+	return func(a *app) {
+		a.mailer = m
+	}
+}
+
+// WithVerificationTTL задает срок действия токена подтверждения email, по умолчанию 24 часа
+func WithVerificationTTL(ttl time.Duration) Option {
+	return func(a *app) {
+		a.verifyTTL = ttl
+	}
+}
+
+// WithResendInterval задает, как часто пользователь может запрашивать новый код подтверждения email,
+// по умолчанию раз в минуту. Каждый повтор отменяет уже отправленный код, поэтому без ограничения
+// любой мог бы не давать пользователю подтвердить email
+func WithResendInterval(d time.Duration) Option {
+	return func(a *app) {
+		a.resendInterval = d
+	}
+}
+
+// WithPasswordCost задает сложность bcrypt-хеширования паролей, по умолчанию bcrypt.DefaultCost
+func WithPasswordCost(cost int) Option {
+	return func(a *app) {
+		a.passwordCost = cost
+	}
+}
+
+// WithFavorites задает хранилище избранного и сохраненных поисков.
+// Без этой опции соответствующие методы возвращают DisabledErr
+func WithFavorites(r favorites.Repository) Option {
+	return func(a *app) {
+		a.favorites = r
+	}
+}
+
+// WithNotifier задает, куда отправляются уведомления о новых объявлениях по сохраненным поискам
+func WithNotifier(n notify.Notifier) Option {
+	return func(a *app) {
+		a.notifier = n
+	}
+}
+
+// WithMessages задает хранилище переписки покупателей с авторами объявлений.
+// Без этой опции соответствующие методы возвращают DisabledErr
+func WithMessages(r messages.Repository) Option {
+	return func(a *app) {
+		a.messages = r
+	}
+}
+
+// WithWebhooks задает, через что события объявлений доставляются партнерам.
+// Без этой опции методы управления webhook возвращают DisabledErr
+func WithWebhooks(d *webhooks.Dispatcher) Option {
+	return func(a *app) {
+		a.webhooks = d
+	}
+}
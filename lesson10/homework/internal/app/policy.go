@@ -0,0 +1,101 @@
+package app
+
+import (
+	"homework10/internal/users"
+)
+
+// Action - действие над объявлением или пользователем, которое проверяется политикой доступа
+type Action string
+
+const (
+	ActionUpdateAd    Action = "ad.update"
+	ActionPublishAd   Action = "ad.publish"
+	ActionUnpublishAd Action = "ad.unpublish"
+	ActionScheduleAd  Action = "ad.schedule"
+	ActionDeleteAd    Action = "ad.delete"
+	ActionModerateAd  Action = "ad.moderate"
+	ActionUpdateUser  Action = "user.update"
+	ActionDeleteUser  Action = "user.delete"
+	ActionSetRole     Action = "user.set_role"
+	ActionReadAudit   Action = "audit.read"
+	ActionManageHooks Action = "webhook.manage"
+	ActionExportAds   Action = "ad.export"
+
+	// действия, доступные всем и записываемые только в журнал аудита
+	ActionCreateAd    Action = "ad.create"
+	ActionCreateUser  Action = "user.create"
+	ActionVerifyEmail Action = "user.verify_email"
+
+	// действия над собственным избранным и сохраненными поисками
+	ActionAddFavorite    Action = "favorite.add"
+	ActionRemoveFavorite Action = "favorite.remove"
+	ActionSaveSearch     Action = "search.create"
+	ActionDeleteSearch   Action = "search.delete"
+
+	// отправка сообщения в собственную переписку
+	ActionSendMessage Action = "message.send"
+)
+
+// ownerActions - действия, которые пользователь может выполнять над своими ресурсами независимо от роли
+var ownerActions = map[Action]bool{
+	ActionUpdateAd:    true,
+	ActionPublishAd:   true,
+	ActionUnpublishAd: true,
+	ActionScheduleAd:  true,
+	ActionDeleteAd:    true,
+	ActionUpdateUser:  true,
+	ActionDeleteUser:  true,
+}
+
+// Policy - действия, которые роль может выполнять над чужими ресурсами
+type Policy map[users.Role][]Action
+
+// DefaultPolicy: модератор может снимать с публикации любые объявления и проверять их,
+// администратор может все
+func DefaultPolicy() Policy {
+	return Policy{
+		users.RoleModerator: {ActionUnpublishAd, ActionModerateAd},
+		users.RoleAdmin: {ActionUpdateAd, ActionPublishAd, ActionUnpublishAd, ActionScheduleAd, ActionDeleteAd,
+			ActionModerateAd, ActionUpdateUser, ActionDeleteUser, ActionSetRole, ActionReadAudit,
+			ActionManageHooks, ActionExportAds},
+	}
+}
+
+func (p Policy) Allows(role users.Role, action Action) bool {
+	for _, a := range p[role] {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// actor возвращает пользователя, выполняющего действие. Неизвестные пользователи
+// считаются обычными, администраторы из WithAdmins - администраторами даже без регистрации
+func (a *app) actor(id int64) users.User {
+	u, err := a.usersRepo.GetById(id)
+	if err != nil {
+		u = users.User{ID: id}
+	}
+
+	if _, ok := a.admins[id]; ok {
+		u.Role = users.RoleAdmin
+	}
+	if u.Role == "" {
+		u.Role = users.RoleUser
+	}
+	return u
+}
+
+// authorize проверяет, может ли actorID выполнить action над ресурсом пользователя ownerID.
+// actorID приходит от клиента и не аутентифицируется: проверка защищает только от ошибок честных клиентов
+func (a *app) authorize(actorID int64, action Action, ownerID int64) error {
+	if actorID == ownerID && ownerActions[action] {
+		return nil
+	}
+
+	if !a.policy.Allows(a.actor(actorID).Role, action) {
+		return AccessErr
+	}
+	return nil
+}
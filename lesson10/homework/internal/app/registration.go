@@ -0,0 +1,155 @@
+package app
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"homework10/internal/audit"
+	"homework10/internal/mailer"
+	"homework10/internal/users"
+	"log"
+	"net/mail"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	minPasswordLen = 8
+	// bcrypt учитывает только первые 72 байта пароля
+	maxPasswordLen = 72
+)
+
+func validPassword(password string) bool {
+	return len(password) >= minPasswordLen && len(password) <= maxPasswordLen
+}
+
+func (a *app) CreateUser(nickname string, email string, password string) (users.User, error) {
+	_, err := mail.ParseAddress(email)
+	if len(nickname) == 0 || err != nil || !validPassword(password) {
+		return users.User{}, ValidationErr
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), a.passwordCost)
+	if err != nil {
+		return users.User{}, err
+	}
+
+	t := a.clock.Now().UTC()
+	u := users.User{
+		Nickname:     nickname,
+		Email:        email,
+		Role:         users.RoleUser,
+		PasswordHash: hash,
+		CreatedAt:    t,
+		UpdatedAt:    t,
+	}
+	token, err := a.issueVerifyToken(&u)
+	if err != nil {
+		return users.User{}, err
+	}
+
+	u, err = a.usersRepo.AddUser(u)
+	if err != nil {
+		return users.User{}, err
+	}
+
+	a.record(u.ID, ActionCreateUser, audit.Target("user", u.ID), nil, u)
+
+	// пользователь уже создан, поэтому ошибка доставки не отменяет регистрацию:
+	// письмо можно запросить повторно через ResendVerification
+	if err := a.sendVerification(u, token); err != nil {
+		log.Printf("mailer: %s", err.Error())
+	}
+	return u, nil
+}
+
+// VerifyEmail подтверждает email пользователя токеном из письма
+func (a *app) VerifyEmail(id int64, token string) (users.User, error) {
+	u, err := a.usersRepo.GetById(id)
+	if err != nil {
+		return users.User{}, err
+	}
+
+	if u.EmailVerified {
+		return u, nil
+	}
+
+	if u.VerifyToken == "" || a.clock.Now().After(u.VerifyExpires) ||
+		subtle.ConstantTimeCompare([]byte(u.VerifyToken), []byte(hashToken(token))) != 1 {
+		return users.User{}, VerificationErr
+	}
+
+	before := u
+	u.EmailVerified, u.VerifyToken, u.VerifyExpires = true, "", time.Time{}
+	u.UpdatedAt = a.clock.Now().UTC()
+	if err := a.usersRepo.ReplaceByID(id, u); err != nil {
+		return users.User{}, err
+	}
+
+	a.record(id, ActionVerifyEmail, audit.Target("user", id), before, u)
+	return u, nil
+}
+
+// ResendVerification выпускает новый токен подтверждения email, предыдущий токен перестает действовать.
+// Новый токен можно получить не чаще раза в resendInterval, иначе возвращается ThrottledErr
+func (a *app) ResendVerification(id int64) error {
+	u, err := a.usersRepo.GetById(id)
+	if err != nil {
+		return err
+	}
+
+	if u.EmailVerified {
+		return ValidationErr
+	}
+
+	// время выпуска текущего токена отдельно не хранится, но отстоит от срока действия на verifyTTL
+	issuedAt := u.VerifyExpires.Add(-a.verifyTTL)
+	if !u.VerifyExpires.IsZero() && a.clock.Now().Before(issuedAt.Add(a.resendInterval)) {
+		return ThrottledErr
+	}
+
+	token, err := a.issueVerifyToken(&u)
+	if err != nil {
+		return err
+	}
+	if err := a.usersRepo.ReplaceByID(id, u); err != nil {
+		return err
+	}
+
+	return a.sendVerification(u, token)
+}
+
+// issueVerifyToken генерирует токен подтверждения email. В пользователе сохраняется только хеш токена
+func (a *app) issueVerifyToken(u *users.User) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	token := hex.EncodeToString(buf)
+	u.EmailVerified = false
+	u.VerifyToken = hashToken(token)
+	u.VerifyExpires = a.clock.Now().Add(a.verifyTTL).UTC()
+	return token, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (a *app) sendVerification(u users.User, token string) error {
+	if a.mailer == nil {
+		return nil
+	}
+
+	return a.mailer.Send(mailer.Message{
+		To:      u.Email,
+		Subject: "Подтверждение email",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nКод подтверждения для пользователя %d: %s\n"+
+			"Код действителен до %s.\n", u.Nickname, u.ID, token, u.VerifyExpires.Format("2006-01-02 15:04 MST")),
+	})
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"log"
+	"time"
+)
+
+// ScheduleAd задает время автоматической публикации и снятия с публикации объявления.
+// Нулевое время означает, что соответствующий переход не запланирован
+func (a *app) ScheduleAd(adID int64, userID int64, publishAt time.Time, expiresAt time.Time) (ads.Ad, error) {
+	ad, err := a.adRepo.GetById(adID)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+
+	if err := a.authorize(userID, ActionScheduleAd, ad.AuthorID); err != nil {
+		return ads.Ad{}, err
+	}
+	before := ad
+
+	now := a.clock.Now().UTC()
+	if !expiresAt.IsZero() && (!expiresAt.After(now) || !publishAt.IsZero() && !expiresAt.After(publishAt)) {
+		return ads.Ad{}, ValidationErr
+	}
+
+	ad.PublishAt, ad.ExpiresAt, ad.LastUpdate = publishAt.UTC(), expiresAt.UTC(), now
+	if publishAt.IsZero() {
+		ad.PublishAt = time.Time{}
+	}
+	if expiresAt.IsZero() {
+		ad.ExpiresAt = time.Time{}
+	}
+
+	return ad, a.replaceAd(adID, userID, ActionScheduleAd, before, ad)
+}
+
+// RunScheduler раз в interval публикует и снимает с публикации объявления, время которых подошло.
+// Расписание хранится в самих объявлениях, поэтому после перезапуска с постоянным хранилищем
+// пропущенные переходы выполняются на первом же тике
+func (a *app) RunScheduler(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := a.applySchedule(); err != nil {
+			log.Printf("scheduler: %s", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *app) applySchedule() error {
+	now := a.clock.Now().UTC()
+
+	for i := int64(0); i < a.adRepo.GetSize(); i++ {
+		ad, err := a.adRepo.GetById(i)
+		if err != nil {
+			return err
+		}
+
+		before := ad
+		if !scheduleTransition(&ad, now) {
+			continue
+		}
+
+		action := ActionPublishAd
+		if !ad.Published {
+			action = ActionUnpublishAd
+		}
+
+		ad.LastUpdate = now
+		// события публикации отправляет replaceAd
+		if err := a.replaceAd(i, audit.SystemActor, action, before, ad); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scheduleTransition применяет к ad наступившие переходы и сбрасывает выполненные,
+// чтобы повторный тик не выполнял их снова
+func scheduleTransition(ad *ads.Ad, now time.Time) bool {
+	if !ad.ExpiresAt.IsZero() && !now.Before(ad.ExpiresAt) {
+		ad.Published, ad.PublishAt, ad.ExpiresAt = false, time.Time{}, time.Time{}
+		return true
+	}
+
+	if !ad.PublishAt.IsZero() && !now.Before(ad.PublishAt) {
+		ad.Published, ad.PublishAt = true, time.Time{}
+		return true
+	}
+
+	return false
+}
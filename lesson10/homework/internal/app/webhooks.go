@@ -0,0 +1,114 @@
+package app
+
+import (
+	"homework10/internal/audit"
+	"homework10/internal/webhooks"
+	"net/url"
+)
+
+// minSecretLen - минимальная длина ключа подписи webhook
+const minSecretLen = 16
+
+// CreateWebhook подписывает партнерский url на события объявлений. Пустой events - все события.
+// Запросы подписываются HMAC-SHA256 с ключом secret
+func (a *app) CreateWebhook(actorID int64, rawURL string, secret string, events []string) (webhooks.Subscription, error) {
+	if err := a.checkWebhooks(actorID); err != nil {
+		return webhooks.Subscription{}, err
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return webhooks.Subscription{}, ValidationErr
+	}
+	if len(secret) < minSecretLen {
+		return webhooks.Subscription{}, ValidationErr
+	}
+	for _, e := range events {
+		if !knownEvent(e) {
+			return webhooks.Subscription{}, ValidationErr
+		}
+	}
+
+	s, err := a.webhooks.Subscribe(webhooks.Subscription{URL: rawURL, Secret: secret, Events: events,
+		CreatedAt: a.clock.Now().UTC()})
+	if err != nil {
+		return webhooks.Subscription{}, err
+	}
+
+	a.record(actorID, ActionManageHooks, audit.Target("webhook", s.ID), nil, s)
+	return s, nil
+}
+
+func (a *app) Webhooks(actorID int64) ([]webhooks.Subscription, error) {
+	if err := a.checkWebhooks(actorID); err != nil {
+		return []webhooks.Subscription{}, err
+	}
+
+	return a.webhooks.Subscriptions()
+}
+
+func (a *app) DeleteWebhook(actorID int64, id int64) error {
+	if err := a.checkWebhooks(actorID); err != nil {
+		return err
+	}
+
+	s, err := a.webhooks.Unsubscribe(id)
+	if err != nil {
+		return err
+	}
+
+	a.record(actorID, ActionManageHooks, audit.Target("webhook", id), s, nil)
+	return nil
+}
+
+// WebhookDeliveries возвращает доставки событий вместе с попытками. С фильтром
+// по статусу webhooks.StatusDead - список недоставленных событий
+func (a *app) WebhookDeliveries(actorID int64, f webhooks.Filter) ([]webhooks.Delivery, error) {
+	if err := a.checkWebhooks(actorID); err != nil {
+		return []webhooks.Delivery{}, err
+	}
+
+	switch f.Status {
+	case "", webhooks.StatusPending, webhooks.StatusDelivered, webhooks.StatusDead:
+	default:
+		return []webhooks.Delivery{}, ValidationErr
+	}
+
+	return a.webhooks.Deliveries(f)
+}
+
+// RedeliverWebhook повторно отправляет событие из доставки, например из списка недоставленных
+func (a *app) RedeliverWebhook(actorID int64, deliveryID int64) (webhooks.Delivery, error) {
+	if err := a.checkWebhooks(actorID); err != nil {
+		return webhooks.Delivery{}, err
+	}
+
+	d, err := a.webhooks.Redeliver(deliveryID)
+	if err != nil {
+		return webhooks.Delivery{}, err
+	}
+
+	a.record(actorID, ActionManageHooks, audit.Target("webhook", d.SubscriptionID), nil, d)
+	return d, nil
+}
+
+// checkWebhooks проверяет, что webhook настроены и пользователь может ими управлять
+func (a *app) checkWebhooks(actorID int64) error {
+	if a.webhooks == nil {
+		return DisabledErr
+	}
+
+	if !a.policy.Allows(a.actor(actorID).Role, ActionManageHooks) {
+		return AccessErr
+	}
+	return nil
+}
+
+func knownEvent(e string) bool {
+	for _, t := range EventTypes {
+		if string(t) == e {
+			return true
+		}
+	}
+	return false
+}
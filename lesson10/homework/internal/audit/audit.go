@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SystemActor - ActorID записей о действиях, которые сервис выполняет сам, например планировщик
+const SystemActor int64 = -1
+
+var ErrBrokenChain = errors.New("audit log hash chain is broken")
+
+//go:generate go run github.com/vektra/mockery/v2@v2.20.2 --output=./tests/mocks --name=Repository
+type Repository interface {
+	// Append присваивает записи номер, связывает ее с предыдущей через PrevHash и сохраняет
+	Append(e Entry) (Entry, error)
+	// List возвращает записи, подходящие под фильтр, в порядке добавления
+	List(f Filter) ([]Entry, error)
+}
+
+// Entry - запись об изменяющей операции. Before и After - состояние объекта до и после операции,
+// null у созданных и удаленных объектов соответственно
+type Entry struct {
+	Seq       int64           `json:"seq"`
+	At        time.Time       `json:"at"`
+	ActorID   int64           `json:"actor_id"`
+	Role      string          `json:"role"`
+	Action    string          `json:"action"`
+	Target    string          `json:"target"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	RequestID string          `json:"request_id,omitempty"`
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `json:"hash"`
+}
+
+// Filter - критерии выборки записей, нулевые значения полей не фильтруют
+type Filter struct {
+	ActorID *int64
+	Target  string
+	From    time.Time
+	To      time.Time
+}
+
+func (f Filter) Match(e Entry) bool {
+	if f.ActorID != nil && e.ActorID != *f.ActorID {
+		return false
+	}
+	if f.Target != "" && e.Target != f.Target {
+		return false
+	}
+	if !f.From.IsZero() && e.At.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !e.At.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+// Target возвращает идентификатор объекта записи, например "ad:42"
+func Target(kind string, id int64) string {
+	return fmt.Sprintf("%s:%d", kind, id)
+}
+
+// Snapshot сериализует состояние объекта для полей Before и After
+func Snapshot(v any) json.RawMessage {
+	if v == nil {
+		return json.RawMessage("null")
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return json.RawMessage("null")
+	}
+	return data
+}
+
+// Hash вычисляет хеш записи вместе с PrevHash, поэтому изменение любой записи
+// меняет хеши всех последующих
+func Hash(e Entry) string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify проверяет, что записи идут подряд с первой и образуют неразорванную цепочку хешей
+func Verify(entries []Entry) error {
+	prev := ""
+	for i, e := range entries {
+		if e.Seq != int64(i+1) || e.PrevHash != prev || e.Hash != Hash(e) {
+			return fmt.Errorf("%w at entry %d", ErrBrokenChain, e.Seq)
+		}
+		prev = e.Hash
+	}
+	return nil
+}
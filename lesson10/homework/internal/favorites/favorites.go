@@ -0,0 +1,37 @@
+package favorites
+
+import (
+	"errors"
+	"homework10/internal/ads"
+	"time"
+)
+
+var ErrSearchNotFound = errors.New("saved search not found")
+
+// Repository хранит избранные объявления и сохраненные поиски пользователей
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.20.2 --output=./tests/mocks --name=Repository
+type Repository interface {
+	// AddFavorite идемпотентна: повторное добавление не возвращает ошибку
+	AddFavorite(userID int64, adID int64) error
+	RemoveFavorite(userID int64, adID int64) error
+	// Favorites возвращает ID избранных объявлений в порядке добавления
+	Favorites(userID int64) ([]int64, error)
+
+	// AddSearch назначает поиску ID
+	AddSearch(s Search) (Search, error)
+	DeleteSearch(userID int64, searchID int64) (Search, error)
+	Searches(userID int64) ([]Search, error)
+	// AllSearches возвращает поиски всех пользователей, по ним новые объявления рассылаются подписчикам
+	AllSearches() ([]Search, error)
+}
+
+// Search - сохраненный пользователем поиск. О новых объявлениях, подходящих под Filter,
+// пользователь получает уведомления
+type Search struct {
+	ID        int64
+	UserID    int64
+	Name      string
+	Filter    ads.Filter
+	CreatedAt time.Time
+}
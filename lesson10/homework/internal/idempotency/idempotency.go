@@ -0,0 +1,199 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"time"
+)
+
+var (
+	// ErrKeyReused - ключ уже использован с другим телом запроса
+	ErrKeyReused = errors.New("idempotency key was already used with a different payload")
+	// ErrInProgress - запрос с этим ключом еще выполняется
+	ErrInProgress = errors.New("request with this idempotency key is in progress")
+	// ErrInvalidKey - пустой или слишком длинный ключ
+	ErrInvalidKey = errors.New("idempotency key must be from 1 to 255 characters")
+)
+
+const (
+	maxKeyLen  = 255
+	defaultTTL = 24 * time.Hour
+)
+
+// Repository хранит ответы на запросы с ключом идемпотентности
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.20.2 --output=./tests/mocks --name=Repository
+type Repository interface {
+	// Reserve сохраняет e, если записи с таким ключом нет или она истекла к моменту now,
+	// и возвращает true. Иначе возвращает существующую запись и false
+	Reserve(e Entry, now time.Time) (Entry, bool, error)
+	// Complete сохраняет ответ на запрос, зарезервированный Reserve. Непустой fingerprint
+	// заменяет сохраненный отпечаток: у потоковых запросов он известен только после выполнения
+	Complete(key string, fingerprint string, r Response) error
+	// Delete освобождает ключ, например после ошибки сервера, чтобы запрос можно было повторить
+	Delete(key string) error
+}
+
+// Entry - запрос с ключом идемпотентности. Response равен nil, пока запрос выполняется
+type Entry struct {
+	Key         string
+	Fingerprint string
+	Response    *Response
+	ExpiresAt   time.Time
+}
+
+// Response - сохраненный ответ. Смысл Status зависит от транспорта: для HTTP это код ответа,
+// для gRPC - код статуса
+type Response struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// Keeper решает, выполнять ли запрос с ключом идемпотентности или повторить сохраненный ответ
+type Keeper struct {
+	repo Repository
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+type Option func(k *Keeper)
+
+// WithTTL задает, сколько хранится ответ, по умолчанию 24 часа
+func WithTTL(ttl time.Duration) Option {
+	return func(k *Keeper) {
+		k.ttl = ttl
+	}
+}
+
+// WithNow задает источник текущего времени
+func WithNow(now func() time.Time) Option {
+	return func(k *Keeper) {
+		k.now = now
+	}
+}
+
+func NewKeeper(repo Repository, opts ...Option) *Keeper {
+	k := &Keeper{repo: repo, ttl: defaultTTL, now: time.Now}
+
+	for _, opt := range opts {
+		opt(k)
+	}
+
+	return k
+}
+
+// Begin резервирует key за запросом с отпечатком fingerprint. Если запрос с этим ключом
+// и отпечатком уже выполнен, возвращает сохраненный ответ, который нужно вернуть клиенту вместо
+// выполнения. Иначе после выполнения нужно вызвать Finish или Abort
+func (k *Keeper) Begin(key string, fingerprint string) (*Response, error) {
+	if key == "" || len(key) > maxKeyLen {
+		return nil, ErrInvalidKey
+	}
+
+	now := k.now()
+	e, reserved, err := k.repo.Reserve(Entry{Key: key, Fingerprint: fingerprint, ExpiresAt: now.Add(k.ttl)}, now)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case reserved:
+		return nil, nil
+	case e.Fingerprint != fingerprint:
+		return nil, ErrKeyReused
+	case e.Response == nil:
+		return nil, ErrInProgress
+	default:
+		return e.Response, nil
+	}
+}
+
+// Finish сохраняет ответ на запрос, начатый Begin
+func (k *Keeper) Finish(key string, r Response) error {
+	return k.repo.Complete(key, "", r)
+}
+
+// BeginStream резервирует key за потоковым запросом, отпечаток которого известен только после
+// чтения всего тела, поэтому тело не нужно держать в памяти. Если запрос с этим ключом уже выполнен,
+// возвращает сохраненную запись: запрос выполнять не нужно, а ответ для него возвращает Replay.
+// Иначе после выполнения нужно вызвать FinishStream или Abort
+func (k *Keeper) BeginStream(key string) (*Entry, error) {
+	if key == "" || len(key) > maxKeyLen {
+		return nil, ErrInvalidKey
+	}
+
+	now := k.now()
+	e, reserved, err := k.repo.Reserve(Entry{Key: key, ExpiresAt: now.Add(k.ttl)}, now)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case reserved:
+		return nil, nil
+	case e.Response == nil:
+		return nil, ErrInProgress
+	default:
+		return &e, nil
+	}
+}
+
+// Replay возвращает ответ выполненного запроса e для повтора с отпечатком fingerprint
+func Replay(e *Entry, fingerprint string) (*Response, error) {
+	if e.Fingerprint != fingerprint {
+		return nil, ErrKeyReused
+	}
+	return e.Response, nil
+}
+
+// FinishStream сохраняет отпечаток и ответ запроса, начатого BeginStream
+func (k *Keeper) FinishStream(key string, fingerprint string, r Response) error {
+	return k.repo.Complete(key, fingerprint, r)
+}
+
+// Abort освобождает ключ запроса, начатого Begin, без сохранения ответа
+func (k *Keeper) Abort(key string) error {
+	return k.repo.Delete(key)
+}
+
+// Fingerprint возвращает отпечаток запроса по его частям, например методу, пути и телу
+func Fingerprint(parts ...[]byte) string {
+	f := NewStreamFingerprint(parts...)
+	return f.Sum()
+}
+
+// StreamFingerprint считает отпечаток запроса по мере чтения: сначала части, известные
+// заранее, затем сообщения потока через Part и тело через Write
+type StreamFingerprint struct {
+	h hash.Hash
+}
+
+func NewStreamFingerprint(parts ...[]byte) *StreamFingerprint {
+	f := &StreamFingerprint{h: sha256.New()}
+	for _, p := range parts {
+		f.Part(p)
+	}
+	return f
+}
+
+// Part добавляет к отпечатку часть p целиком
+func (f *StreamFingerprint) Part(p []byte) {
+	// длина перед каждой частью не дает разным разбиениям дать одинаковый отпечаток
+	var n [8]byte
+	binary.LittleEndian.PutUint64(n[:], uint64(len(p)))
+	f.h.Write(n[:])
+	f.h.Write(p)
+}
+
+// Write добавляет к отпечатку очередной кусок тела. Тело должно быть последней частью
+func (f *StreamFingerprint) Write(p []byte) (int, error) {
+	return f.h.Write(p)
+}
+
+func (f *StreamFingerprint) Sum() string {
+	return hex.EncodeToString(f.h.Sum(nil))
+}
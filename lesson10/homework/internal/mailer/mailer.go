@@ -0,0 +1,20 @@
+package mailer
+
+// Message - письмо пользователю
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer доставляет письма, например, со ссылкой для подтверждения email
+type Mailer interface {
+	Send(m Message) error
+}
+
+// Func позволяет использовать обычную функцию в качестве Mailer
+type Func func(m Message) error
+
+func (f Func) Send(m Message) error {
+	return f(m)
+}
@@ -0,0 +1,74 @@
+package messages
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrThreadNotFound = errors.New("thread not found")
+
+// Repository хранит переписку покупателей с авторами объявлений
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.20.2 --output=./tests/mocks --name=Repository
+type Repository interface {
+	// FindThread ищет переписку покупателя buyerID по объявлению adID
+	FindThread(adID int64, buyerID int64) (Thread, error)
+	// AddThread назначает переписке ID
+	AddThread(t Thread) (Thread, error)
+	GetThread(id int64) (Thread, error)
+	// Threads возвращает переписки, в которых участвует пользователь, начиная с последней активной
+	Threads(userID int64) ([]Thread, error)
+
+	// AddMessage назначает сообщению ID, обновляет время последнего сообщения
+	// и увеличивает счетчик непрочитанных у получателя
+	AddMessage(m Message) (Message, error)
+	// Messages возвращает сообщения переписки в порядке отправки
+	Messages(threadID int64) ([]Message, error)
+	// MarkRead обнуляет счетчик непрочитанных сообщений пользователя в переписке
+	MarkRead(threadID int64, userID int64) (Thread, error)
+}
+
+// Thread - переписка по объявлению AdID между его автором и покупателем
+type Thread struct {
+	ID            int64
+	AdID          int64
+	AuthorID      int64
+	BuyerID       int64
+	CreatedAt     time.Time
+	LastMessageAt time.Time
+
+	AuthorUnread int
+	BuyerUnread  int
+}
+
+// Has проверяет, участвует ли пользователь в переписке
+func (t Thread) Has(userID int64) bool {
+	return userID == t.AuthorID || userID == t.BuyerID
+}
+
+// Peer возвращает второго участника переписки
+func (t Thread) Peer(userID int64) int64 {
+	if userID == t.AuthorID {
+		return t.BuyerID
+	}
+	return t.AuthorID
+}
+
+// Unread возвращает число непрочитанных пользователем сообщений
+func (t Thread) Unread(userID int64) int {
+	switch userID {
+	case t.AuthorID:
+		return t.AuthorUnread
+	case t.BuyerID:
+		return t.BuyerUnread
+	}
+	return 0
+}
+
+type Message struct {
+	ID       int64
+	ThreadID int64
+	SenderID int64
+	Text     string
+	SentAt   time.Time
+}
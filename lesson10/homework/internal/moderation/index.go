@@ -0,0 +1,79 @@
+package moderation
+
+import (
+	"sync"
+
+	"homework10/internal/ads"
+)
+
+// DuplicateFinder находит объявление того же автора с таким же заголовком и текстом,
+// не обходя весь репозиторий
+type DuplicateFinder interface {
+	FindDuplicate(ad ads.Ad) (int64, bool)
+}
+
+type indexKey struct {
+	authorID int64
+	text     string
+}
+
+// Index хранит ID неотклоненных объявлений по автору и нормализованным заголовку и тексту,
+// чтобы Duplicates не обходил репозиторий при каждом создании и импорте объявления
+type Index struct {
+	mu    sync.Mutex
+	byKey map[indexKey]map[int64]struct{}
+}
+
+func NewIndex() *Index {
+	return &Index{byKey: make(map[indexKey]map[int64]struct{})}
+}
+
+func keyOf(ad ads.Ad) indexKey {
+	return indexKey{authorID: ad.AuthorID, text: normalize(ad)}
+}
+
+// Track обновляет индекс после изменения объявления: before == nil - объявление создано,
+// after == nil - удалено
+func (i *Index) Track(before, after *ads.Ad) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if before != nil {
+		key := keyOf(*before)
+		delete(i.byKey[key], before.ID)
+		if len(i.byKey[key]) == 0 {
+			delete(i.byKey, key)
+		}
+	}
+	if after != nil && after.Moderation != ads.ModerationRejected {
+		key := keyOf(*after)
+		if i.byKey[key] == nil {
+			i.byKey[key] = make(map[int64]struct{})
+		}
+		i.byKey[key][after.ID] = struct{}{}
+	}
+}
+
+// FindDuplicate возвращает наименьший ID другого объявления с тем же ключом
+func (i *Index) FindDuplicate(ad ads.Ad) (int64, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	res, found := int64(0), false
+	for id := range i.byKey[keyOf(ad)] {
+		if id != ad.ID && (!found || id < res) {
+			res, found = id, true
+		}
+	}
+	return res, found
+}
+
+// Indexed возвращает repo, в котором Duplicates ищет дубликаты по индексу i
+func (i *Index) Indexed(repo ads.Repository) ads.Repository {
+	return indexedRepo{Repository: repo, Index: i}
+}
+
+type indexedRepo struct {
+	ads.Repository
+	*Index
+}
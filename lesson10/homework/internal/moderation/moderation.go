@@ -0,0 +1,118 @@
+package moderation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"homework10/internal/ads"
+)
+
+// Rule возвращает причину, по которой объявление нужно отправить на ручную проверку,
+// или пустую строку, если нарушений нет
+type Rule func(ad ads.Ad, repo ads.Repository) string
+
+// Engine последовательно применяет правила к объявлению
+type Engine struct {
+	rules []Rule
+}
+
+func NewEngine(rules ...Rule) *Engine {
+	return &Engine{rules: rules}
+}
+
+// Review возвращает причины, по которым объявление отправлено на ручную проверку.
+// Пустой результат означает, что объявление одобрено автоматически
+func (e *Engine) Review(ad ads.Ad, repo ads.Repository) []string {
+	if e == nil {
+		return nil
+	}
+
+	var flags []string
+	for _, rule := range e.rules {
+		if reason := rule(ad, repo); reason != "" {
+			flags = append(flags, reason)
+		}
+	}
+	return flags
+}
+
+// BannedWords отмечает объявления, в заголовке или тексте которых есть одно из слов words.
+// Слова сравниваются без учета регистра и только целиком
+func BannedWords(words ...string) Rule {
+	banned := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		banned[strings.ToLower(w)] = struct{}{}
+	}
+
+	return func(ad ads.Ad, _ ads.Repository) string {
+		for _, w := range splitWords(ad.Title + " " + ad.Text) {
+			if _, ok := banned[w]; ok {
+				return fmt.Sprintf("banned word %q", w)
+			}
+		}
+		return ""
+	}
+}
+
+// Regexp отмечает объявления, заголовок или текст которых совпадает с re
+func Regexp(reason string, re *regexp.Regexp) Rule {
+	return func(ad ads.Ad, _ ads.Repository) string {
+		if re.MatchString(ad.Title) || re.MatchString(ad.Text) {
+			return reason
+		}
+		return ""
+	}
+}
+
+var (
+	linkRe  = regexp.MustCompile(`(?i)(https?://|www\.)\S+|\b[a-z0-9-]+\.(com|net|org|ru|io|me)\b`)
+	phoneRe = regexp.MustCompile(`\+?\d(?:[\s()-]*\d){9,}`)
+)
+
+// Links отмечает объявления со ссылками
+func Links() Rule {
+	return Regexp("contains a link", linkRe)
+}
+
+// Phones отмечает объявления с номерами телефонов
+func Phones() Rule {
+	return Regexp("contains a phone number", phoneRe)
+}
+
+// Duplicates отмечает объявление, если у того же автора уже есть объявление
+// с таким же заголовком и текстом без учета регистра и пробелов.
+// Если repo реализует DuplicateFinder, дубликат ищется по индексу, иначе обходом repo
+func Duplicates() Rule {
+	return func(ad ads.Ad, repo ads.Repository) string {
+		if finder, ok := repo.(DuplicateFinder); ok {
+			if id, found := finder.FindDuplicate(ad); found {
+				return fmt.Sprintf("duplicate of ad %d", id)
+			}
+			return ""
+		}
+
+		key := normalize(ad)
+		for i := int64(0); i < repo.GetSize(); i++ {
+			other, err := repo.GetById(i)
+			if err != nil || other.ID == ad.ID || other.AuthorID != ad.AuthorID {
+				continue
+			}
+			if other.Moderation != ads.ModerationRejected && normalize(other) == key {
+				return fmt.Sprintf("duplicate of ad %d", other.ID)
+			}
+		}
+		return ""
+	}
+}
+
+func splitWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func normalize(ad ads.Ad) string {
+	return strings.Join(splitWords(ad.Title), " ") + "\n" + strings.Join(splitWords(ad.Text), " ")
+}
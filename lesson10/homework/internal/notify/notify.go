@@ -0,0 +1,24 @@
+package notify
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrQueueFull = errors.New("notification queue is full")
+
+// Notification - уведомление пользователя о новом объявлении, подходящем под его сохраненный поиск
+type Notification struct {
+	UserID     int64     `json:"user_id"`
+	SearchID   int64     `json:"search_id"`
+	SearchName string    `json:"search_name"`
+	AdID       int64     `json:"ad_id"`
+	AdTitle    string    `json:"ad_title"`
+	At         time.Time `json:"at"`
+}
+
+// Notifier ставит уведомление в очередь доставки. Вызывается синхронно из обработки запроса,
+// поэтому не должен ждать самой доставки
+type Notifier interface {
+	Notify(n Notification) error
+}
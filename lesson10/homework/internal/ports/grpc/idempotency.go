@@ -0,0 +1,218 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"homework10/internal/idempotency"
+)
+
+const idempotencyKeyMetadata = "idempotency-key"
+
+// idempotentMethods - методы, которые учитывают ключ идемпотентности, и типы их ответов
+var idempotentMethods = map[string]func() proto.Message{
+	"/ad.AdService/CreateAd":   func() proto.Message { return &AdResponse{} },
+	"/ad.AdService/CreateUser": func() proto.Message { return &UserResponse{} },
+}
+
+const importAdsMethod = "/ad.AdService/ImportAds"
+
+// IdempotencyInterceptor выполняет CreateAd и CreateUser с метаданными idempotency-key один раз:
+// повтор с тем же ключом и запросом получает сохраненный ответ или ошибку, повтор с другим
+// запросом - FailedPrecondition, а пока первый запрос выполняется - Aborted
+func IdempotencyInterceptor(k *idempotency.Keeper) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		newResponse, ok := idempotentMethods[info.FullMethod]
+		key := idempotencyKey(ctx)
+		if !ok || key == "" {
+			return handler(ctx, req)
+		}
+
+		body, err := proto.MarshalOptions{Deterministic: true}.Marshal(req.(proto.Message))
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		key = info.FullMethod + " " + key
+		replay, err := k.Begin(key, idempotency.Fingerprint([]byte(info.FullMethod), body))
+		if err != nil {
+			return nil, idempotencyErr(err)
+		}
+		if replay != nil {
+			res := newResponse()
+			return res, replayResponse(replay, res)
+		}
+
+		res, err := handler(ctx, req)
+		var msg proto.Message
+		if err == nil {
+			msg = res.(proto.Message)
+		}
+		finish(k, key, msg, err)
+		return res, err
+	}
+}
+
+// IdempotencyStreamInterceptor выполняет ImportAds с метаданными idempotency-key один раз.
+// Сообщения передаются обработчику по мере получения, отпечаток считается по ним же,
+// поэтому поток не держится в памяти. Повтор читает поток до конца только ради отпечатка
+func IdempotencyStreamInterceptor(k *idempotency.Keeper) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		key := idempotencyKey(ss.Context())
+		if info.FullMethod != importAdsMethod || key == "" {
+			return handler(srv, ss)
+		}
+
+		key = info.FullMethod + " " + key
+		done, err := k.BeginStream(key)
+		if err != nil {
+			return idempotencyErr(err)
+		}
+
+		stream := &hashingStream{ServerStream: ss,
+			fingerprint: idempotency.NewStreamFingerprint([]byte(info.FullMethod))}
+		if done != nil {
+			if err := stream.drain(); err != nil {
+				return err
+			}
+
+			replay, err := idempotency.Replay(done, stream.fingerprint.Sum())
+			if err != nil {
+				return idempotencyErr(err)
+			}
+			res := &ImportAdsResponse{}
+			if err := replayResponse(replay, res); err != nil {
+				return err
+			}
+			return ss.SendMsg(res)
+		}
+
+		err = handler(srv, stream)
+		// обработчик мог прервать чтение на ошибке, а отпечаток считается по всему потоку
+		if drainErr := stream.drain(); drainErr != nil {
+			if abortErr := k.Abort(key); abortErr != nil {
+				log.Printf("idempotency: %s", abortErr.Error())
+			}
+			return err
+		}
+		finishStream(k, key, stream.fingerprint.Sum(), stream.response, err)
+		return err
+	}
+}
+
+func idempotencyKey(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(idempotencyKeyMetadata); len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+func idempotencyErr(err error) error {
+	switch err {
+	case idempotency.ErrKeyReused:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case idempotency.ErrInProgress:
+		return status.Error(codes.Aborted, err.Error())
+	case idempotency.ErrInvalidKey:
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// replayResponse восстанавливает сохраненный ответ в res или возвращает сохраненную ошибку.
+// Для ошибок в Body хранится ее текст
+func replayResponse(r *idempotency.Response, res proto.Message) error {
+	if code := codes.Code(r.Status); code != codes.OK {
+		return status.Error(code, string(r.Body))
+	}
+	if err := proto.Unmarshal(r.Body, res); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return nil
+}
+
+// finish сохраняет ответ обработчика. Ошибки сервера не сохраняются, чтобы запрос можно было повторить
+func finish(k *idempotency.Keeper, key string, res proto.Message, handlerErr error) {
+	finishStream(k, key, "", res, handlerErr)
+}
+
+// finishStream сохраняет ответ обработчика, как finish, вместе с отпечатком потокового запроса
+func finishStream(k *idempotency.Keeper, key string, fingerprint string, res proto.Message, handlerErr error) {
+	var err error
+	st := status.Convert(handlerErr)
+	switch st.Code() {
+	case codes.OK:
+		var body []byte
+		if res != nil {
+			body, err = proto.Marshal(res)
+		}
+		if err == nil {
+			err = k.FinishStream(key, fingerprint, idempotency.Response{Status: int(codes.OK), Body: body})
+		}
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+		err = k.Abort(key)
+	default:
+		err = k.FinishStream(key, fingerprint, idempotency.Response{Status: int(st.Code()), Body: []byte(st.Message())})
+	}
+	if err != nil {
+		log.Printf("idempotency: %s", err.Error())
+	}
+}
+
+// hashingStream добавляет к отпечатку каждое полученное сообщение и запоминает ответ обработчика
+type hashingStream struct {
+	grpc.ServerStream
+	fingerprint *idempotency.StreamFingerprint
+	response    proto.Message
+	eof         bool
+}
+
+func (s *hashingStream) RecvMsg(m interface{}) error {
+	if s.eof {
+		return io.EOF
+	}
+
+	err := s.ServerStream.RecvMsg(m)
+	if errors.Is(err, io.EOF) {
+		s.eof = true
+	}
+	if err != nil {
+		return err
+	}
+
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(m.(proto.Message))
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	s.fingerprint.Part(b)
+	return nil
+}
+
+func (s *hashingStream) SendMsg(m interface{}) error {
+	s.response = m.(proto.Message)
+	return s.ServerStream.SendMsg(m)
+}
+
+// drain дочитывает оставшиеся сообщения, чтобы отпечаток учитывал весь поток
+func (s *hashingStream) drain() error {
+	for {
+		err := s.RecvMsg(&ImportAdRequest{})
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
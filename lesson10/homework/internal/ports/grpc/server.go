@@ -2,8 +2,12 @@ package grpc
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	"homework10/internal/app"
+	"homework10/internal/idempotency"
 	"log"
 	"net"
 )
@@ -13,14 +17,38 @@ type Server struct {
 	lis net.Listener
 }
 
-func NewGRPCServer(port string, a *app.App) Server {
+type Option func(o *options)
+
+type options struct {
+	keeper *idempotency.Keeper
+}
+
+// WithIdempotency включает метаданные idempotency-key для CreateAd, CreateUser и ImportAds
+func WithIdempotency(k *idempotency.Keeper) Option {
+	return func(o *options) {
+		o.keeper = k
+	}
+}
+
+func NewGRPCServer(port string, a *app.App, opts ...Option) Server {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	lis, err := net.Listen("tcp", port)
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
 	svc := NewService(*a)
-	server := grpc.NewServer(grpc.ChainUnaryInterceptor(UnaryServerInterceptor))
+	unary := []grpc.UnaryServerInterceptor{UnaryServerInterceptor, RequestIDInterceptor}
+	var stream []grpc.StreamServerInterceptor
+	if o.keeper != nil {
+		unary = append(unary, IdempotencyInterceptor(o.keeper))
+		stream = append(stream, IdempotencyStreamInterceptor(o.keeper))
+	}
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...))
 
 	RegisterAdServiceServer(server, svc)
 
@@ -43,3 +71,39 @@ func UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.Una
 
 	return handler(ctx, req)
 }
+
+const requestIDMetadata = "x-request-id"
+
+type requestIDKey struct{}
+
+// RequestIDInterceptor берет идентификатор запроса из метаданных x-request-id или создает новый
+// и возвращает его клиенту в заголовке ответа
+func RequestIDInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	id := requestID(ctx)
+	if id == "" {
+		id = newRequestID()
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, id))
+	return handler(context.WithValue(ctx, requestIDKey{}, id), req)
+}
+
+// requestID возвращает идентификатор, назначенный RequestIDInterceptor, или переданный клиентом в метаданных
+func requestID(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return id
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(requestIDMetadata); len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -2,18 +2,35 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"homework10/internal/ads"
 	"homework10/internal/app"
+	"homework10/internal/favorites"
+	"homework10/internal/messages"
+	"homework10/internal/users"
+	"io"
+	"time"
 )
 
 func errorHandler(err error) error {
 	switch err {
 	case app.AccessErr:
 		return status.New(codes.PermissionDenied, err.Error()).Err()
-	case app.ValidationErr:
+	case app.ValidationErr, app.VerificationErr:
 		return status.New(codes.InvalidArgument, err.Error()).Err()
+	case users.ErrNicknameTaken, users.ErrEmailTaken:
+		return status.New(codes.AlreadyExists, err.Error()).Err()
+	case favorites.ErrSearchNotFound, messages.ErrThreadNotFound:
+		return status.New(codes.NotFound, err.Error()).Err()
+	case app.DisabledErr:
+		return status.New(codes.Unimplemented, err.Error()).Err()
+	case app.ThrottledErr:
+		return status.New(codes.ResourceExhausted, err.Error()).Err()
 	case nil:
 		return status.New(codes.OK, "success").Err()
 	default:
@@ -29,43 +46,33 @@ type AdService struct {
 	app app.App
 }
 
+// appFor возвращает app, который помечает записи аудита идентификатором текущего запроса
+func (s *AdService) appFor(ctx context.Context) app.App {
+	return s.app.WithRequestID(requestID(ctx))
+}
+
 func (s *AdService) CreateAd(ctx context.Context, request *CreateAdRequest) (*AdResponse, error) {
-	ad, err := s.app.CreateAd(request.Title, request.Text, request.UserId)
+	ad, err := s.appFor(ctx).CreateAd(request.Title, request.Text, request.UserId)
 	if err != nil {
 		return nil, errorHandler(err)
 	}
-	return &AdResponse{
-		Id: ad.ID, Title: ad.Title,
-		Text:      ad.Text,
-		Published: ad.Published,
-		AuthorId:  ad.AuthorID,
-	}, nil
+	return newAdResponse(&ad), nil
 }
 
 func (s *AdService) ChangeAdStatus(ctx context.Context, request *ChangeAdStatusRequest) (*AdResponse, error) {
-	ad, err := s.app.ChangeAdStatus(request.AdId, request.UserId, request.Published)
+	ad, err := s.appFor(ctx).ChangeAdStatus(request.AdId, request.UserId, request.Published)
 	if err != nil {
 		return nil, errorHandler(err)
 	}
-	return &AdResponse{
-		Id: ad.ID, Title: ad.Title,
-		Text:      ad.Text,
-		Published: ad.Published,
-		AuthorId:  ad.AuthorID,
-	}, nil
+	return newAdResponse(&ad), nil
 }
 
 func (s *AdService) UpdateAd(ctx context.Context, request *UpdateAdRequest) (*AdResponse, error) {
-	ad, err := s.app.UpdateAd(request.AdId, request.UserId, request.Title, request.Text)
+	ad, err := s.appFor(ctx).UpdateAd(request.AdId, request.UserId, request.Title, request.Text)
 	if err != nil {
 		return nil, errorHandler(err)
 	}
-	return &AdResponse{
-		Id: ad.ID, Title: ad.Title,
-		Text:      ad.Text,
-		Published: ad.Published,
-		AuthorId:  ad.AuthorID,
-	}, nil
+	return newAdResponse(&ad), nil
 }
 
 func (s *AdService) ListAds(ctx context.Context, empty *emptypb.Empty) (*ListAdResponse, error) {
@@ -73,26 +80,62 @@ func (s *AdService) ListAds(ctx context.Context, empty *emptypb.Empty) (*ListAdR
 	if err != nil {
 		return nil, errorHandler(err)
 	}
+	return listAdResponse(ads), nil
+}
+
+func listAdResponse(list []ads.Ad) *ListAdResponse {
 	res := ListAdResponse{
 		List: make([]*AdResponse, 0),
 	}
-	for _, ad := range ads {
-		res.List = append(res.List, &AdResponse{
-			Id: ad.ID, Title: ad.Title,
-			Text:      ad.Text,
-			Published: ad.Published,
-			AuthorId:  ad.AuthorID,
-		})
+	for i := range list {
+		res.List = append(res.List, newAdResponse(&list[i]))
 	}
-	return &res, nil
+	return &res
+}
+
+func newAdResponse(ad *ads.Ad) *AdResponse {
+	return &AdResponse{
+		Id: ad.ID, Title: ad.Title,
+		Text:      ad.Text,
+		Published: ad.Published,
+		AuthorId:  ad.AuthorID,
+		PublishAt: timestampOrNil(ad.PublishAt),
+		ExpiresAt: timestampOrNil(ad.ExpiresAt),
+
+		Moderation:   string(ad.Moderation),
+		Flags:        ad.Flags,
+		RejectReason: ad.RejectReason,
+	}
+}
+
+func timestampOrNil(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func (s *AdService) ScheduleAd(ctx context.Context, request *ScheduleAdRequest) (*AdResponse, error) {
+	var publishAt, expiresAt time.Time
+	if request.PublishAt != nil {
+		publishAt = request.PublishAt.AsTime()
+	}
+	if request.ExpiresAt != nil {
+		expiresAt = request.ExpiresAt.AsTime()
+	}
+	ad, err := s.appFor(ctx).ScheduleAd(request.AdId, request.UserId, publishAt, expiresAt)
+	if err != nil {
+		return nil, errorHandler(err)
+	}
+	return newAdResponse(&ad), nil
 }
 
 func (s *AdService) CreateUser(ctx context.Context, request *CreateUserRequest) (*UserResponse, error) {
-	user, err := s.app.CreateUser(request.Id, request.Name, request.Email)
+	user, err := s.appFor(ctx).CreateUser(request.Name, request.Email, request.Password)
 	if err != nil {
 		return nil, errorHandler(err)
 	}
-	return &UserResponse{Name: user.Nickname, Id: user.ID}, nil
+	return newUserResponse(&user), nil
 }
 
 func (s *AdService) GetUser(ctx context.Context, request *GetUserRequest) (*UserResponse, error) {
@@ -100,15 +143,387 @@ func (s *AdService) GetUser(ctx context.Context, request *GetUserRequest) (*User
 	if err != nil {
 		return nil, errorHandler(err)
 	}
-	return &UserResponse{Name: user.Nickname, Id: user.ID}, nil
+	return newUserResponse(&user), nil
 }
 
 func (s *AdService) DeleteUser(ctx context.Context, request *DeleteUserRequest) (*emptypb.Empty, error) {
-	_, err := s.app.DeleteUser(request.Id)
+	_, err := s.appFor(ctx).DeleteUser(request.Id, actorOrSelf(request.ActorId, request.Id))
 	return &emptypb.Empty{}, errorHandler(err)
 }
 
 func (s *AdService) DeleteAd(ctx context.Context, request *DeleteAdRequest) (*emptypb.Empty, error) {
-	_, err := s.app.DeleteAd(request.AdId, request.AuthorId)
+	_, err := s.appFor(ctx).DeleteAd(request.AdId, request.AuthorId)
+	return &emptypb.Empty{}, errorHandler(err)
+}
+
+func (s *AdService) GetAd(ctx context.Context, request *GetAdRequest) (*AdResponse, error) {
+	ad, err := s.app.GetAd(request.AdId)
+	if err != nil {
+		return nil, errorHandler(err)
+	}
+	return newAdResponse(&ad), nil
+}
+
+func (s *AdService) FindAds(ctx context.Context, request *FindAdsRequest) (*ListAdResponse, error) {
+	ads, err := s.app.GetAdsByTitle(request.Title)
+	if err != nil {
+		return nil, errorHandler(err)
+	}
+	return listAdResponse(ads), nil
+}
+
+func (s *AdService) FilterAds(ctx context.Context, request *FilterAdsRequest) (*ListAdResponse, error) {
+	published, authorID := 0, int64(-1)
+	if request.OnlyPublished {
+		published = 1
+	}
+	if request.AuthorId != nil {
+		authorID = *request.AuthorId
+	}
+
+	if request.Date != "" {
+		if _, err := time.Parse(time.DateOnly, request.Date); err != nil {
+			return nil, status.New(codes.InvalidArgument, err.Error()).Err()
+		}
+	}
+
+	ads, err := s.app.GetFilteredAds(published, authorID, request.Date)
+	if err != nil {
+		return nil, errorHandler(err)
+	}
+	return listAdResponse(ads), nil
+}
+
+func (s *AdService) UpdateUser(ctx context.Context, request *UpdateUserRequest) (*UserResponse, error) {
+	user, err := s.appFor(ctx).UpdateUser(request.Id, actorOrSelf(request.ActorId, request.Id), request.Name, request.Email)
+	if err != nil {
+		return nil, errorHandler(err)
+	}
+	return newUserResponse(&user), nil
+}
+
+func (s *AdService) ImportAds(stream AdService_ImportAdsServer) error {
+	var report *app.ImportReport
+	for line := int64(1); ; line++ {
+		request, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if report == nil {
+			report = app.NewImportReport(request.DryRun)
+		}
+
+		_, err = s.appFor(stream.Context()).ImportAd(request.Title, request.Text, request.UserId, report.DryRun)
+		report.Add(line, err)
+	}
+
+	if report == nil {
+		report = app.NewImportReport(false)
+	}
+
+	res := &ImportAdsResponse{
+		Total:    report.Total,
+		Imported: report.Imported,
+		Failed:   report.Failed,
+		DryRun:   report.DryRun,
+		Errors:   make([]*ImportError, 0, len(report.Errors)),
+	}
+	for _, e := range report.Errors {
+		res.Errors = append(res.Errors, &ImportError{Line: e.Line, Error: e.Err.Error()})
+	}
+
+	return stream.SendAndClose(res)
+}
+
+func (s *AdService) ModerationQueue(ctx context.Context, request *ModerationQueueRequest) (*ListAdResponse, error) {
+	ads, err := s.app.ModerationQueue(request.UserId)
+	if err != nil {
+		return nil, errorHandler(err)
+	}
+	return listAdResponse(ads), nil
+}
+
+func (s *AdService) ApproveAd(ctx context.Context, request *ApproveAdRequest) (*AdResponse, error) {
+	ad, err := s.appFor(ctx).ApproveAd(request.AdId, request.UserId)
+	if err != nil {
+		return nil, errorHandler(err)
+	}
+	return newAdResponse(&ad), nil
+}
+
+func (s *AdService) RejectAd(ctx context.Context, request *RejectAdRequest) (*AdResponse, error) {
+	ad, err := s.appFor(ctx).RejectAd(request.AdId, request.UserId, request.Reason)
+	if err != nil {
+		return nil, errorHandler(err)
+	}
+	return newAdResponse(&ad), nil
+}
+
+func (s *AdService) SetUserRole(ctx context.Context, request *SetUserRoleRequest) (*UserResponse, error) {
+	user, err := s.appFor(ctx).SetUserRole(request.Id, request.ActorId, users.Role(request.Role))
+	if err != nil {
+		return nil, errorHandler(err)
+	}
+	return newUserResponse(&user), nil
+}
+
+func (s *AdService) VerifyEmail(ctx context.Context, request *VerifyEmailRequest) (*UserResponse, error) {
+	user, err := s.appFor(ctx).VerifyEmail(request.Id, request.Token)
+	if err != nil {
+		return nil, errorHandler(err)
+	}
+	return newUserResponse(&user), nil
+}
+
+func (s *AdService) ResendVerification(ctx context.Context, request *ResendVerificationRequest) (*emptypb.Empty, error) {
+	err := s.appFor(ctx).ResendVerification(request.Id)
 	return &emptypb.Empty{}, errorHandler(err)
 }
+
+func newUserResponse(user *users.User) *UserResponse {
+	return &UserResponse{Name: user.Nickname, Id: user.ID, Email: user.Email, Role: string(user.Role),
+		EmailVerified: user.EmailVerified}
+}
+
+// actorOrSelf возвращает ID пользователя, выполняющего действие над пользователем id
+func actorOrSelf(actorID *int64, id int64) int64 {
+	if actorID == nil {
+		return id
+	}
+	return *actorID
+}
+
+func (s *AdService) AddFavorite(ctx context.Context, request *FavoriteRequest) (*ListAdResponse, error) {
+	if err := s.appFor(ctx).AddFavorite(request.UserId, request.AdId); err != nil {
+		return nil, errorHandler(err)
+	}
+	return s.ListFavorites(ctx, &ListFavoritesRequest{UserId: request.UserId})
+}
+
+func (s *AdService) RemoveFavorite(ctx context.Context, request *FavoriteRequest) (*ListAdResponse, error) {
+	if err := s.appFor(ctx).RemoveFavorite(request.UserId, request.AdId); err != nil {
+		return nil, errorHandler(err)
+	}
+	return s.ListFavorites(ctx, &ListFavoritesRequest{UserId: request.UserId})
+}
+
+func (s *AdService) ListFavorites(ctx context.Context, request *ListFavoritesRequest) (*ListAdResponse, error) {
+	list, err := s.app.Favorites(request.UserId)
+	if err != nil {
+		return nil, errorHandler(err)
+	}
+	return listAdResponse(list), nil
+}
+
+func (s *AdService) SaveSearch(ctx context.Context, request *SaveSearchRequest) (*SearchResponse, error) {
+	f := ads.Filter{AuthorID: -1, Date: request.Date, Title: request.Title}
+	if request.AuthorId != nil {
+		f.AuthorID = *request.AuthorId
+	}
+
+	search, err := s.appFor(ctx).SaveSearch(request.UserId, request.Name, f)
+	if err != nil {
+		return nil, errorHandler(err)
+	}
+	return newSearchResponse(&search), nil
+}
+
+func (s *AdService) ListSearches(ctx context.Context, request *ListSearchesRequest) (*ListSearchResponse, error) {
+	list, err := s.app.SavedSearches(request.UserId)
+	if err != nil {
+		return nil, errorHandler(err)
+	}
+
+	res := &ListSearchResponse{List: make([]*SearchResponse, 0, len(list))}
+	for i := range list {
+		res.List = append(res.List, newSearchResponse(&list[i]))
+	}
+	return res, nil
+}
+
+func (s *AdService) DeleteSearch(ctx context.Context, request *SearchRequest) (*emptypb.Empty, error) {
+	err := s.appFor(ctx).DeleteSearch(request.UserId, request.SearchId)
+	return &emptypb.Empty{}, errorHandler(err)
+}
+
+func (s *AdService) RunSearch(ctx context.Context, request *SearchRequest) (*ListAdResponse, error) {
+	list, err := s.app.RunSearch(request.UserId, request.SearchId)
+	if err != nil {
+		return nil, errorHandler(err)
+	}
+	return listAdResponse(list), nil
+}
+
+func newSearchResponse(search *favorites.Search) *SearchResponse {
+	res := &SearchResponse{Id: search.ID, UserId: search.UserID, Name: search.Name,
+		Date: search.Filter.Date, Title: search.Filter.Title, CreatedAt: timestampOrNil(search.CreatedAt)}
+	if search.Filter.AuthorID != -1 {
+		authorID := search.Filter.AuthorID
+		res.AuthorId = &authorID
+	}
+	return res
+}
+
+func (s *AdService) ContactAuthor(ctx context.Context, request *ContactAuthorRequest) (*MessageResponse, error) {
+	m, err := s.appFor(ctx).ContactAuthor(request.AdId, request.UserId, request.Text)
+	if err != nil {
+		return nil, errorHandler(err)
+	}
+	return newMessageResponse(&m), nil
+}
+
+func (s *AdService) SendMessage(ctx context.Context, request *SendMessageRequest) (*MessageResponse, error) {
+	m, err := s.appFor(ctx).SendMessage(request.ThreadId, request.UserId, request.Text)
+	if err != nil {
+		return nil, errorHandler(err)
+	}
+	return newMessageResponse(&m), nil
+}
+
+func (s *AdService) ListThreads(ctx context.Context, request *ListThreadsRequest) (*ListThreadResponse, error) {
+	list, err := s.app.Threads(request.UserId)
+	if err != nil {
+		return nil, errorHandler(err)
+	}
+
+	res := &ListThreadResponse{List: make([]*ThreadResponse, 0, len(list))}
+	for i := range list {
+		res.List = append(res.List, newThreadResponse(&list[i], request.UserId))
+	}
+	return res, nil
+}
+
+func (s *AdService) ListMessages(ctx context.Context, request *ThreadRequest) (*ListMessageResponse, error) {
+	list, err := s.app.Messages(request.ThreadId, request.UserId)
+	if err != nil {
+		return nil, errorHandler(err)
+	}
+
+	res := &ListMessageResponse{List: make([]*MessageResponse, 0, len(list))}
+	for i := range list {
+		res.List = append(res.List, newMessageResponse(&list[i]))
+	}
+	return res, nil
+}
+
+func (s *AdService) MarkThreadRead(ctx context.Context, request *ThreadRequest) (*ThreadResponse, error) {
+	t, err := s.app.MarkThreadRead(request.ThreadId, request.UserId)
+	if err != nil {
+		return nil, errorHandler(err)
+	}
+	return newThreadResponse(&t, request.UserId), nil
+}
+
+// Chat читает запросы клиента в отдельной горутине, а в текущей пересылает клиенту новые сообщения.
+// Поток завершается, когда клиент закрывает отправку, или с ошибкой первого неудачного запроса
+func (s *AdService) Chat(stream AdService_ChatServer) error {
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	userID := first.UserId
+	incoming, cancel, err := s.app.SubscribeMessages(userID)
+	if err != nil {
+		return errorHandler(err)
+	}
+	defer cancel()
+
+	// заголовки ответа сообщают клиенту, что подписка оформлена
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		request := first
+		for {
+			if err := s.chatSend(stream.Context(), userID, request); err != nil {
+				errCh <- err
+				return
+			}
+
+			var err error
+			request, err = stream.Recv()
+			if errors.Is(err, io.EOF) {
+				errCh <- nil
+				return
+			}
+			if err != nil {
+				errCh <- err
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case m := <-incoming:
+			if err := stream.Send(newMessageResponse(&m)); err != nil {
+				return err
+			}
+		case err := <-errCh:
+			if err != nil {
+				return err
+			}
+			return flushChat(stream, incoming)
+		}
+	}
+}
+
+// chatSend отправляет сообщение из запроса потока Chat от имени пользователя потока
+func (s *AdService) chatSend(ctx context.Context, userID int64, request *ChatRequest) error {
+	if request.UserId != 0 && request.UserId != userID {
+		return status.New(codes.InvalidArgument, "user_id can't change within a chat").Err()
+	}
+	if request.Text == "" {
+		return nil
+	}
+
+	var err error
+	switch to := request.To.(type) {
+	case *ChatRequest_ThreadId:
+		_, err = s.appFor(ctx).SendMessage(to.ThreadId, userID, request.Text)
+	case *ChatRequest_AdId:
+		_, err = s.appFor(ctx).ContactAuthor(to.AdId, userID, request.Text)
+	default:
+		return status.New(codes.InvalidArgument, "thread_id or ad_id is required").Err()
+	}
+	if err != nil {
+		return errorHandler(err)
+	}
+	return nil
+}
+
+// flushChat досылает клиенту уже полученные сообщения, в том числе отправленные им самим
+// перед закрытием потока
+func flushChat(stream AdService_ChatServer, incoming <-chan messages.Message) error {
+	for {
+		select {
+		case m := <-incoming:
+			if err := stream.Send(newMessageResponse(&m)); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+func newMessageResponse(m *messages.Message) *MessageResponse {
+	return &MessageResponse{Id: m.ID, ThreadId: m.ThreadID, SenderId: m.SenderID, Text: m.Text,
+		SentAt: timestampOrNil(m.SentAt)}
+}
+
+func newThreadResponse(t *messages.Thread, userID int64) *ThreadResponse {
+	return &ThreadResponse{Id: t.ID, AdId: t.AdID, AuthorId: t.AuthorID, BuyerId: t.BuyerID,
+		CreatedAt: timestampOrNil(t.CreatedAt), LastMessageAt: timestampOrNil(t.LastMessageAt),
+		Unread: int64(t.Unread(userID))}
+}
@@ -0,0 +1,204 @@
+package httpgin
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"homework10/internal/ads"
+	"homework10/internal/app"
+)
+
+const (
+	formatJSONL = "jsonl"
+	formatCSV   = "csv"
+
+	maxJSONLLine = 1024 * 1024
+)
+
+var (
+	errUnknownFormat = errors.New("unknown format, use jsonl or csv")
+	errNoUserID      = errors.New("user_id is required")
+)
+
+type importAdRecord struct {
+	Title    string `json:"title"`
+	Text     string `json:"text"`
+	UserID   *int64 `json:"user_id"`
+	AuthorID *int64 `json:"author_id"`
+}
+
+// author возвращает user_id, а если его нет - author_id, чтобы файл экспорта можно было импортировать обратно
+func (r importAdRecord) author() (int64, error) {
+	switch {
+	case r.UserID != nil:
+		return *r.UserID, nil
+	case r.AuthorID != nil:
+		return *r.AuthorID, nil
+	default:
+		return 0, errNoUserID
+	}
+}
+
+type exportAdRecord struct {
+	ID         int64     `json:"id"`
+	Title      string    `json:"title"`
+	Text       string    `json:"text"`
+	AuthorID   int64     `json:"author_id"`
+	Published  bool      `json:"published"`
+	CreateDate time.Time `json:"create_date"`
+	LastUpdate time.Time `json:"last_update"`
+}
+
+var csvHeader = []string{"id", "title", "text", "author_id", "published", "create_date", "last_update"}
+
+func newExportAdRecord(ad ads.Ad) exportAdRecord {
+	return exportAdRecord{
+		ID:         ad.ID,
+		Title:      ad.Title,
+		Text:       ad.Text,
+		AuthorID:   ad.AuthorID,
+		Published:  ad.Published,
+		CreateDate: ad.CreateDate,
+		LastUpdate: ad.LastUpdate,
+	}
+}
+
+func (r exportAdRecord) csv() []string {
+	return []string{
+		strconv.FormatInt(r.ID, 10),
+		r.Title,
+		r.Text,
+		strconv.FormatInt(r.AuthorID, 10),
+		strconv.FormatBool(r.Published),
+		r.CreateDate.Format(time.RFC3339),
+		r.LastUpdate.Format(time.RFC3339),
+	}
+}
+
+// readImport построчно читает записи из in и вызывает fn для каждой из них.
+// Ошибки разбора отдельной строки передаются в fn, ошибка чтения потока прерывает импорт
+func readImport(in io.Reader, format string, fn func(line int64, rec importAdRecord, err error)) error {
+	switch format {
+	case formatJSONL:
+		return readImportJSONL(in, fn)
+	case formatCSV:
+		return readImportCSV(in, fn)
+	default:
+		return errUnknownFormat
+	}
+}
+
+func readImportJSONL(in io.Reader, fn func(line int64, rec importAdRecord, err error)) error {
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 64*1024), maxJSONLLine)
+
+	for line := int64(1); sc.Scan(); line++ {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+
+		var rec importAdRecord
+		err := json.Unmarshal(sc.Bytes(), &rec)
+		fn(line, rec, err)
+	}
+
+	return sc.Err()
+}
+
+func readImportCSV(in io.Reader, fn func(line int64, rec importAdRecord, err error)) error {
+	r := csv.NewReader(in)
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("can't read csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, col := range header {
+		columns[strings.TrimSpace(col)] = i
+	}
+
+	get := func(row []string, col string) (string, bool) {
+		i, ok := columns[col]
+		if !ok || i >= len(row) {
+			return "", false
+		}
+		return row[i], true
+	}
+
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			fn(int64(parseErr.StartLine), importAdRecord{}, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		line, _ := r.FieldPos(0)
+
+		var rec importAdRecord
+		rec.Title, _ = get(row, "title")
+		rec.Text, _ = get(row, "text")
+
+		for _, col := range []string{"user_id", "author_id"} {
+			v, ok := get(row, col)
+			if !ok || v == "" {
+				continue
+			}
+
+			id, convErr := strconv.ParseInt(v, 10, 64)
+			if convErr != nil {
+				err = fmt.Errorf("%s: %w", col, convErr)
+				break
+			}
+			rec.UserID = &id
+			break
+		}
+
+		fn(int64(line), rec, err)
+	}
+}
+
+// importFormat выбирает формат по query-параметру format или по Content-Type
+func importFormat(format string, contentType string) string {
+	if format != "" {
+		return format
+	}
+	if strings.HasPrefix(contentType, "text/csv") {
+		return formatCSV
+	}
+	return formatJSONL
+}
+
+func importAds(a app.App, in io.Reader, format string, dryRun bool) (*app.ImportReport, error) {
+	report := app.NewImportReport(dryRun)
+
+	err := readImport(in, format, func(line int64, rec importAdRecord, err error) {
+		if err == nil {
+			var authorID int64
+			authorID, err = rec.author()
+			if err == nil {
+				_, err = a.ImportAd(rec.Title, rec.Text, authorID, dryRun)
+			}
+		}
+		report.Add(line, err)
+	})
+
+	return report, err
+}
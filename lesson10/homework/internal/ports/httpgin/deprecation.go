@@ -0,0 +1,20 @@
+package httpgin
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated помечает ответы на запросы с путем, начинающимся с prefix, заголовком Deprecation
+// и ссылкой Link на версию API successor, которая их заменяет
+func Deprecated(prefix string, successor string) gin.HandlerFunc {
+	link := "<" + successor + `>; rel="successor-version"`
+	return func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, prefix) {
+			c.Header("Deprecation", "true")
+			c.Header("Link", link)
+		}
+		c.Next()
+	}
+}
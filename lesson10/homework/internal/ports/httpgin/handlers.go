@@ -1,14 +1,24 @@
 package httpgin
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"homework10/internal/ads"
 	"homework10/internal/app"
+	"homework10/internal/audit"
+	"homework10/internal/favorites"
+	"homework10/internal/messages"
+	"homework10/internal/users"
+	"homework10/internal/webhooks"
 )
 
 func handleErr(err error) int {
@@ -17,12 +27,36 @@ func handleErr(err error) int {
 		return http.StatusBadRequest
 	case app.AccessErr:
 		return http.StatusForbidden
+	case app.VerificationErr:
+		return http.StatusBadRequest
+	case users.ErrNicknameTaken, users.ErrEmailTaken:
+		return http.StatusConflict
+	case favorites.ErrSearchNotFound, messages.ErrThreadNotFound,
+		webhooks.ErrSubscriptionNotFound, webhooks.ErrDeliveryNotFound:
+		return http.StatusNotFound
+	case app.DisabledErr:
+		return http.StatusNotImplemented
+	case app.ThrottledErr:
+		return http.StatusTooManyRequests
 	default:
 		return http.StatusInternalServerError
 	}
 }
 
 // Метод для создания объявления (ad)
+//
+//	@Summary	Создание объявления
+//	@Tags		ads
+//	@Accept		json
+//	@Produce	json
+//	@Param		request			body		createAdRequest	true	"Данные объявления"
+//	@Param		Idempotency-Key	header		string			false	"Ключ идемпотентности, повтор с тем же ключом вернет сохраненный ответ"
+//	@Success	200				{object}	response{data=adResponse}
+//	@Failure	400				{object}	errorResponse
+//	@Failure	409				{object}	errorResponse	"Запрос с этим ключом еще выполняется"
+//	@Failure	422				{object}	errorResponse	"Ключ использован с другим телом запроса"
+//	@Failure	500				{object}	errorResponse
+//	@Router		/api/v1/ads [post]
 func createAd(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody createAdRequest
@@ -33,7 +67,7 @@ func createAd(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		ad, err := a.CreateAd(reqBody.Title, reqBody.Text, reqBody.UserID)
+		ad, err := withRequest(c, a).CreateAd(reqBody.Title, reqBody.Text, reqBody.UserID)
 
 		if err != nil {
 			c.JSON(handleErr(err), AdErrorResponse(err))
@@ -45,6 +79,13 @@ func createAd(a app.App) gin.HandlerFunc {
 }
 
 // Метод получения всех опубликованных объявлений
+//
+//	@Summary	Список опубликованных объявлений
+//	@Tags		ads
+//	@Produce	json
+//	@Success	200	{object}	response{data=[]adResponse}
+//	@Failure	500	{object}	errorResponse
+//	@Router		/api/v1/ads [get]
 func getAds(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
@@ -60,6 +101,16 @@ func getAds(a app.App) gin.HandlerFunc {
 }
 
 // Метод получения объявления по его ID
+//
+//	@Summary	Получение объявления
+//	@Tags		ads
+//	@Produce	json
+//	@Param		ad_id	path		int	true	"ID объявления"
+//	@Success	200		{object}	response{data=adResponse}
+//	@Failure	400		{object}	errorResponse
+//	@Failure	403		{object}	errorResponse	"Объявление не опубликовано"
+//	@Failure	500		{object}	errorResponse
+//	@Router		/api/v1/ads/{ad_id} [get]
 func getAdByID(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
@@ -81,6 +132,14 @@ func getAdByID(a app.App) gin.HandlerFunc {
 }
 
 // Метод для получения объявлений по названию
+//
+//	@Summary	Поиск опубликованных объявлений по заголовку
+//	@Tags		ads
+//	@Produce	json
+//	@Param		title	path		string	true	"Подстрока заголовка"
+//	@Success	200		{object}	response{data=[]adResponse}
+//	@Failure	500		{object}	errorResponse
+//	@Router		/api/v1/ads/find/{title} [get]
 func getAdsByTitle(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
@@ -98,6 +157,17 @@ func getAdsByTitle(a app.App) gin.HandlerFunc {
 }
 
 // Метод для получения отфильтрованного списка объявлений
+//
+//	@Summary	Фильтрация объявлений
+//	@Tags		ads
+//	@Produce	json
+//	@Param		published	query		int		false	"1 - только опубликованные"
+//	@Param		author		query		int		false	"ID автора"
+//	@Param		date		query		string	false	"Дата создания в формате YYYY-MM-DD"
+//	@Success	200			{object}	response{data=[]adResponse}
+//	@Failure	400			{object}	errorResponse
+//	@Failure	500			{object}	errorResponse
+//	@Router		/api/v1/ads/filter [get]
 func getFilteredAds(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var (
@@ -141,6 +211,18 @@ func getFilteredAds(a app.App) gin.HandlerFunc {
 }
 
 // Метод для изменения статуса объявления (опубликовано - Published = true или снято с публикации Published = false)
+//
+//	@Summary	Изменение статуса публикации объявления
+//	@Tags		ads
+//	@Accept		json
+//	@Produce	json
+//	@Param		ad_id	path		int						true	"ID объявления"
+//	@Param		request	body		changeAdStatusRequest	true	"Новый статус"
+//	@Success	200		{object}	response{data=adResponse}
+//	@Failure	400		{object}	errorResponse
+//	@Failure	403		{object}	errorResponse	"Объявление принадлежит другому пользователю"
+//	@Failure	500		{object}	errorResponse
+//	@Router		/api/v1/ads/{ad_id}/status [put]
 func changeAdStatus(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody changeAdStatusRequest
@@ -155,7 +237,54 @@ func changeAdStatus(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		ad, err := a.ChangeAdStatus(int64(adID), reqBody.UserID, reqBody.Published)
+		ad, err := withRequest(c, a).ChangeAdStatus(int64(adID), reqBody.UserID, reqBody.Published)
+
+		if err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
+
+// Метод для планирования публикации и снятия с публикации объявления
+//
+//	@Summary		Расписание публикации объявления
+//	@Description	Отсутствующее поле отменяет соответствующий переход
+//	@Tags			ads
+//	@Accept			json
+//	@Produce		json
+//	@Param			ad_id	path		int					true	"ID объявления"
+//	@Param			request	body		scheduleAdRequest	true	"Время публикации и снятия с публикации"
+//	@Success		200		{object}	response{data=adResponse}
+//	@Failure		400		{object}	errorResponse
+//	@Failure		403		{object}	errorResponse	"Объявление принадлежит другому пользователю"
+//	@Failure		500		{object}	errorResponse
+//	@Router			/api/v1/ads/{ad_id}/schedule [put]
+func scheduleAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody scheduleAdRequest
+		if err := c.BindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		adID, err := strconv.Atoi(c.Param("ad_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		var publishAt, expiresAt time.Time
+		if reqBody.PublishAt != nil {
+			publishAt = *reqBody.PublishAt
+		}
+		if reqBody.ExpiresAt != nil {
+			expiresAt = *reqBody.ExpiresAt
+		}
+
+		ad, err := withRequest(c, a).ScheduleAd(int64(adID), reqBody.UserID, publishAt, expiresAt)
 
 		if err != nil {
 			c.JSON(handleErr(err), AdErrorResponse(err))
@@ -167,6 +296,18 @@ func changeAdStatus(a app.App) gin.HandlerFunc {
 }
 
 // Метод для обновления текста(Text) или заголовка(Title) объявления
+//
+//	@Summary	Обновление объявления
+//	@Tags		ads
+//	@Accept		json
+//	@Produce	json
+//	@Param		ad_id	path		int				true	"ID объявления"
+//	@Param		request	body		updateAdRequest	true	"Новые заголовок и текст"
+//	@Success	200		{object}	response{data=adResponse}
+//	@Failure	400		{object}	errorResponse
+//	@Failure	403		{object}	errorResponse	"Объявление принадлежит другому пользователю"
+//	@Failure	500		{object}	errorResponse
+//	@Router		/api/v1/ads/{ad_id} [put]
 func updateAd(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody updateAdRequest
@@ -181,7 +322,7 @@ func updateAd(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		ad, err := a.UpdateAd(int64(adID), reqBody.UserID, reqBody.Title, reqBody.Text)
+		ad, err := withRequest(c, a).UpdateAd(int64(adID), reqBody.UserID, reqBody.Title, reqBody.Text)
 
 		if err != nil {
 			c.JSON(handleErr(err), AdErrorResponse(err))
@@ -193,6 +334,20 @@ func updateAd(a app.App) gin.HandlerFunc {
 }
 
 // Метод для создания пользователя (user)
+//
+//	@Summary		Создание пользователя
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Description	ID назначается сервером, на email отправляется код подтверждения
+//	@Param			request			body		createUserRequest	true	"Данные пользователя"
+//	@Param			Idempotency-Key	header		string				false	"Ключ идемпотентности, повтор с тем же ключом вернет сохраненный ответ"
+//	@Success		200				{object}	response{data=userResponse}
+//	@Failure		400				{object}	errorResponse
+//	@Failure		409				{object}	errorResponse	"Никнейм или email заняты либо запрос с этим ключом еще выполняется"
+//	@Failure		422				{object}	errorResponse	"Ключ использован с другим телом запроса"
+//	@Failure		500				{object}	errorResponse
+//	@Router			/api/v1/users [post]
 func createUser(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody createUserRequest
@@ -203,7 +358,7 @@ func createUser(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		user, err := a.CreateUser(reqBody.UserID, reqBody.Nickname, reqBody.Email)
+		user, err := withRequest(c, a).CreateUser(reqBody.Nickname, reqBody.Email, reqBody.Password)
 
 		if err != nil {
 			c.JSON(handleErr(err), AdErrorResponse(err))
@@ -215,6 +370,15 @@ func createUser(a app.App) gin.HandlerFunc {
 }
 
 // метод для получения пользователя по id
+//
+//	@Summary	Получение пользователя
+//	@Tags		users
+//	@Produce	json
+//	@Param		user_id	path		int	true	"ID пользователя"
+//	@Success	200		{object}	response{data=userResponse}
+//	@Failure	400		{object}	errorResponse
+//	@Failure	500		{object}	errorResponse
+//	@Router		/api/v1/users/{user_id} [get]
 func getUser(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id, err := strconv.Atoi(c.Param("user_id"))
@@ -234,6 +398,21 @@ func getUser(a app.App) gin.HandlerFunc {
 }
 
 // метод для обновления пользователя по id
+//
+//	@Summary		Обновление пользователя
+//	@Description	ID пользователя читается из query-параметра user_id, а не из пути
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			user_id		path		int					true	"ID пользователя (не используется)"
+//	@Param			user_id		query		int					true	"ID пользователя"
+//	@Param			actor_id	query		int					false	"ID пользователя, выполняющего изменение, по умолчанию user_id"
+//	@Param			request		body		updateUserRequest	true	"Новые никнейм и email"
+//	@Success		200			{object}	response{data=userResponse}
+//	@Failure		400			{object}	errorResponse
+//	@Failure		403			{object}	errorResponse	"Недостаточно прав"
+//	@Failure		500			{object}	errorResponse
+//	@Router			/api/v1/users/{user_id} [post]
 func updateUser(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody updateUserRequest
@@ -248,7 +427,13 @@ func updateUser(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		u, err := a.UpdateUser(int64(id), reqBody.Nickname, reqBody.Email)
+		actorID, err := actorParam(c, int64(id))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		u, err := withRequest(c, a).UpdateUser(int64(id), actorID, reqBody.Nickname, reqBody.Email)
 		if err != nil {
 			c.JSON(handleErr(err), AdErrorResponse(err))
 			return
@@ -259,6 +444,17 @@ func updateUser(a app.App) gin.HandlerFunc {
 }
 
 // Метод для удаления пользователя (user)
+//
+//	@Summary	Удаление пользователя
+//	@Tags		users
+//	@Produce	json
+//	@Param		user_id		path		int	true	"ID пользователя"
+//	@Param		actor_id	query		int	false	"ID пользователя, выполняющего удаление, по умолчанию user_id"
+//	@Success	200			{object}	response{data=userResponse}
+//	@Failure	400			{object}	errorResponse
+//	@Failure	403			{object}	errorResponse	"Недостаточно прав"
+//	@Failure	500			{object}	errorResponse
+//	@Router		/api/v1/users/{user_id} [delete]
 func deleteUser(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id, err := strconv.Atoi(c.Param("user_id"))
@@ -267,7 +463,95 @@ func deleteUser(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		u, err := a.DeleteUser(int64(id))
+		actorID, err := actorParam(c, int64(id))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		u, err := withRequest(c, a).DeleteUser(int64(id), actorID)
+		if err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, UserSuccessResponse(&u))
+	}
+}
+
+// actorParam возвращает ID пользователя, выполняющего действие над пользователем, из query-параметра actor_id.
+// Без параметра пользователь действует сам над собой
+func actorParam(c *gin.Context, def int64) (int64, error) {
+	if c.Query("actor_id") == "" {
+		return def, nil
+	}
+	return strconv.ParseInt(c.Query("actor_id"), 10, 64)
+}
+
+// Метод для назначения роли пользователю
+//
+//	@Summary	Назначение роли пользователю
+//	@Tags		users
+//	@Accept		json
+//	@Produce	json
+//	@Param		user_id	path		int					true	"ID пользователя"
+//	@Param		request	body		setUserRoleRequest	true	"ID администратора и новая роль"
+//	@Success	200		{object}	response{data=userResponse}
+//	@Failure	400		{object}	errorResponse
+//	@Failure	403		{object}	errorResponse	"Недостаточно прав"
+//	@Failure	500		{object}	errorResponse
+//	@Router		/api/v1/users/{user_id}/role [put]
+func setUserRole(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody setUserRoleRequest
+		if err := c.BindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		id, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		u, err := withRequest(c, a).SetUserRole(int64(id), reqBody.ActorID, users.Role(reqBody.Role))
+		if err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, UserSuccessResponse(&u))
+	}
+}
+
+// Метод для подтверждения email пользователя
+//
+//	@Summary	Подтверждение email
+//	@Tags		users
+//	@Accept		json
+//	@Produce	json
+//	@Param		user_id	path		int					true	"ID пользователя"
+//	@Param		request	body		verifyEmailRequest	true	"Код из письма"
+//	@Success	200		{object}	response{data=userResponse}
+//	@Failure	400		{object}	errorResponse	"Неверный или просроченный код"
+//	@Failure	500		{object}	errorResponse
+//	@Router		/api/v1/users/{user_id}/verify [post]
+func verifyEmail(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody verifyEmailRequest
+		if err := c.BindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		id, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		u, err := withRequest(c, a).VerifyEmail(int64(id), reqBody.Token)
 		if err != nil {
 			c.JSON(handleErr(err), AdErrorResponse(err))
 			return
@@ -277,7 +561,49 @@ func deleteUser(a app.App) gin.HandlerFunc {
 	}
 }
 
+// Метод для повторной отправки кода подтверждения email
+//
+//	@Summary		Повторная отправка кода подтверждения
+//	@Description	Предыдущий код перестает действовать
+//	@Tags			users
+//	@Produce		json
+//	@Param			user_id	path		int	true	"ID пользователя"
+//	@Success		200		{object}	response
+//	@Failure		400		{object}	errorResponse	"Email уже подтвержден"
+//	@Failure		429		{object}	errorResponse	"Код запрашивался слишком недавно"
+//	@Failure		500		{object}	errorResponse
+//	@Router			/api/v1/users/{user_id}/verification [post]
+func resendVerification(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		if err := withRequest(c, a).ResendVerification(int64(id)); err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, &response{})
+	}
+}
+
 // Метод для удаления объявления (ad)
+//
+//	@Summary		Удаление объявления
+//	@Description	ID объявления и автора передаются в теле запроса
+//	@Tags			ads
+//	@Accept			json
+//	@Produce		json
+//	@Param			ad_id	path		int					true	"ID объявления (не используется)"
+//	@Param			request	body		deleteAdResponse	true	"ID объявления и автора"
+//	@Success		200		{object}	response{data=adResponse}
+//	@Failure		400		{object}	errorResponse
+//	@Failure		403		{object}	errorResponse	"Объявление принадлежит другому пользователю"
+//	@Failure		500		{object}	errorResponse
+//	@Router			/api/v1/ads/{ad_id} [delete]
 func deleteAd(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody deleteAdResponse
@@ -286,7 +612,228 @@ func deleteAd(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		ad, err := a.DeleteAd(reqBody.ID, reqBody.AuthorID)
+		ad, err := withRequest(c, a).DeleteAd(reqBody.ID, reqBody.AuthorID)
+		if err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
+
+// Метод для массового импорта объявлений из jsonl или csv
+//
+//	@Summary		Массовый импорт объявлений
+//	@Description	Тело читается потоково: jsonl (по объекту {"title", "text", "user_id"} на строку) или csv с заголовком title,text,user_id.
+//	@Description	Каждая запись проверяется по тем же правилам, что и при создании объявления.
+//	@Tags			ads
+//	@Accept			application/x-ndjson
+//	@Accept			text/csv
+//	@Produce		json
+//	@Param			format			query		string	false	"Формат тела, по умолчанию определяется по Content-Type"	Enums(jsonl, csv)
+//	@Param			dry_run			query		bool	false	"Только проверить записи, не сохраняя их"
+//	@Param			request			body		string	true	"Записи объявлений"
+//	@Param			Idempotency-Key	header		string	false	"Ключ идемпотентности, повтор с тем же ключом вернет сохраненный ответ"
+//	@Success		200				{object}	response{data=importResponse}
+//	@Failure		400				{object}	errorResponse
+//	@Failure		409				{object}	errorResponse	"Запрос с этим ключом еще выполняется"
+//	@Failure		422				{object}	errorResponse	"Ключ использован с другим телом запроса"
+//	@Router			/api/v1/ads:import [post]
+func importAdsHandler(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		dryRun := false
+		if c.Query("dry_run") != "" {
+			var err error
+			dryRun, err = strconv.ParseBool(c.Query("dry_run"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+				return
+			}
+		}
+
+		format := importFormat(c.Query("format"), c.ContentType())
+		report, err := importAds(withRequest(c, a), c.Request.Body, format, dryRun)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, ImportSuccessResponse(report))
+	}
+}
+
+// Метод для массового экспорта объявлений в jsonl или csv
+//
+//	@Summary		Массовый экспорт объявлений
+//	@Description	Без actor_id или для обычного пользователя отдаются только опубликованные объявления, администратору - все, по одному на строку
+//	@Tags			ads
+//	@Produce		application/x-ndjson
+//	@Produce		text/csv
+//	@Param			format	query		string	false	"Формат ответа"	Enums(jsonl, csv)	default(jsonl)
+//	@Param			actor_id	query		int		false	"ID пользователя, выполняющего экспорт"
+//	@Success		200		{array}		exportAdRecord
+//	@Failure		400		{object}	errorResponse
+//	@Router			/api/v1/ads:export [get]
+func exportAdsHandler(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.DefaultQuery("format", formatJSONL)
+
+		// анонимный экспорт получает только видимые всем объявления
+		actorID := int64(-1)
+		if c.Query("actor_id") != "" {
+			id, err := strconv.ParseInt(c.Query("actor_id"), 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+				return
+			}
+			actorID = id
+		}
+
+		var write func(ad ads.Ad) error
+		switch format {
+		case formatJSONL:
+			c.Header("Content-Type", "application/x-ndjson")
+			enc := json.NewEncoder(c.Writer)
+			write = func(ad ads.Ad) error {
+				return enc.Encode(newExportAdRecord(ad))
+			}
+		case formatCSV:
+			c.Header("Content-Type", "text/csv")
+			w := csv.NewWriter(c.Writer)
+			defer w.Flush()
+			if err := w.Write(csvHeader); err != nil {
+				return
+			}
+			write = func(ad ads.Ad) error {
+				return w.Write(newExportAdRecord(ad).csv())
+			}
+		default:
+			c.JSON(http.StatusBadRequest, AdErrorResponse(errUnknownFormat))
+			return
+		}
+
+		c.Status(http.StatusOK)
+		err := a.ExportAds(actorID, func(ad ads.Ad) error {
+			if err := write(ad); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+			return nil
+		})
+		if err != nil {
+			// заголовки уже отправлены, остается только оборвать поток
+			log.Printf("export ads: %s", err.Error())
+		}
+	}
+}
+
+// customMethod защищает маршрут вида /collection:method, который gin
+// регистрирует как параметр method, от запросов с другим суффиксом
+func customMethod(method string, h gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Param(method) != ":"+method {
+			c.JSON(http.StatusNotFound, AdErrorResponse(errors.New("route not found")))
+			return
+		}
+		h(c)
+	}
+}
+
+// Метод получения очереди объявлений, ожидающих ручной проверки
+//
+//	@Summary	Очередь модерации
+//	@Tags		moderation
+//	@Produce	json
+//	@Param		user_id	query		int	true	"ID модератора"
+//	@Success	200		{object}	response{data=[]adResponse}
+//	@Failure	400		{object}	errorResponse
+//	@Failure	403		{object}	errorResponse	"Пользователь не модератор"
+//	@Failure	500		{object}	errorResponse
+//	@Router		/api/v1/moderation/ads [get]
+func getModerationQueue(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Query("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		ads, err := a.ModerationQueue(int64(id))
+		if err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, AdsSuccessResponse(&ads))
+	}
+}
+
+// Метод для одобрения объявления модератором
+//
+//	@Summary	Одобрение объявления
+//	@Tags		moderation
+//	@Accept		json
+//	@Produce	json
+//	@Param		ad_id	path		int					true	"ID объявления"
+//	@Param		request	body		approveAdRequest	true	"ID модератора"
+//	@Success	200		{object}	response{data=adResponse}
+//	@Failure	400		{object}	errorResponse
+//	@Failure	403		{object}	errorResponse	"Пользователь не модератор"
+//	@Failure	500		{object}	errorResponse
+//	@Router		/api/v1/moderation/ads/{ad_id}/approve [post]
+func approveAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody approveAdRequest
+		if err := c.BindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		adID, err := strconv.Atoi(c.Param("ad_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		ad, err := withRequest(c, a).ApproveAd(int64(adID), reqBody.UserID)
+		if err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
+
+// Метод для отклонения объявления модератором, причина видна автору
+//
+//	@Summary	Отклонение объявления
+//	@Tags		moderation
+//	@Accept		json
+//	@Produce	json
+//	@Param		ad_id	path		int				true	"ID объявления"
+//	@Param		request	body		rejectAdRequest	true	"ID модератора и причина отклонения"
+//	@Success	200		{object}	response{data=adResponse}
+//	@Failure	400		{object}	errorResponse
+//	@Failure	403		{object}	errorResponse	"Пользователь не модератор"
+//	@Failure	500		{object}	errorResponse
+//	@Router		/api/v1/moderation/ads/{ad_id}/reject [post]
+func rejectAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody rejectAdRequest
+		if err := c.BindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		adID, err := strconv.Atoi(c.Param("ad_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		ad, err := withRequest(c, a).RejectAd(int64(adID), reqBody.UserID, reqBody.Reason)
 		if err != nil {
 			c.JSON(handleErr(err), AdErrorResponse(err))
 			return
@@ -295,3 +842,689 @@ func deleteAd(a app.App) gin.HandlerFunc {
 		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
 	}
 }
+
+// auditQuery читает из query-параметров ID администратора и фильтр журнала аудита
+func auditQuery(c *gin.Context) (int64, audit.Filter, error) {
+	var f audit.Filter
+
+	adminID, err := strconv.ParseInt(c.Query("user_id"), 10, 64)
+	if err != nil {
+		return 0, f, err
+	}
+
+	if c.Query("actor_id") != "" {
+		actorID, err := strconv.ParseInt(c.Query("actor_id"), 10, 64)
+		if err != nil {
+			return 0, f, err
+		}
+		f.ActorID = &actorID
+	}
+
+	f.Target = c.Query("target")
+
+	if c.Query("from") != "" {
+		if f.From, err = time.Parse(time.RFC3339, c.Query("from")); err != nil {
+			return 0, f, err
+		}
+	}
+	if c.Query("to") != "" {
+		if f.To, err = time.Parse(time.RFC3339, c.Query("to")); err != nil {
+			return 0, f, err
+		}
+	}
+
+	return adminID, f, nil
+}
+
+// Метод получения записей журнала аудита
+//
+//	@Summary	Журнал аудита
+//	@Tags		audit
+//	@Produce	json
+//	@Param		user_id		query		int		true	"ID администратора"
+//	@Param		actor_id	query		int		false	"ID пользователя, выполнившего операцию"
+//	@Param		target		query		string	false	"Объект операции, например ad:42 или user:7"
+//	@Param		from		query		string	false	"Начало периода, RFC3339"
+//	@Param		to			query		string	false	"Конец периода (не включительно), RFC3339"
+//	@Success	200			{object}	response{data=[]auditEntryResponse}
+//	@Failure	400			{object}	errorResponse
+//	@Failure	403			{object}	errorResponse	"Пользователь не администратор"
+//	@Failure	500			{object}	errorResponse
+//	@Router		/api/v1/audit [get]
+func getAuditLog(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, f, err := auditQuery(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		entries, err := a.AuditLog(adminID, f)
+		if err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, AuditSuccessResponse(entries))
+	}
+}
+
+// Метод для выгрузки журнала аудита в jsonl
+//
+//	@Summary		Экспорт журнала аудита
+//	@Description	Записи отдаются по одной на строку вместе с хешами, по которым можно проверить целостность цепочки
+//	@Tags			audit
+//	@Produce		application/x-ndjson
+//	@Param			user_id		query		int		true	"ID администратора"
+//	@Param			actor_id	query		int		false	"ID пользователя, выполнившего операцию"
+//	@Param			target		query		string	false	"Объект операции, например ad:42 или user:7"
+//	@Param			from		query		string	false	"Начало периода, RFC3339"
+//	@Param			to			query		string	false	"Конец периода (не включительно), RFC3339"
+//	@Success		200			{array}		auditEntryResponse
+//	@Failure		400			{object}	errorResponse
+//	@Failure		403			{object}	errorResponse	"Пользователь не администратор"
+//	@Router			/api/v1/audit:export [get]
+func exportAuditLog(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, f, err := auditQuery(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		entries, err := a.AuditLog(adminID, f)
+		if err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+
+		enc := json.NewEncoder(c.Writer)
+		for _, e := range entries {
+			if err := enc.Encode(auditEntryResponse(e)); err != nil {
+				log.Printf("export audit log: %s", err.Error())
+				return
+			}
+		}
+	}
+}
+
+// userAndID читает из пути ID пользователя и ID объекта name
+func userAndID(c *gin.Context, name string) (int64, int64, error) {
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	id, err := strconv.ParseInt(c.Param(name), 10, 64)
+	return userID, id, err
+}
+
+// Метод для добавления объявления в избранное
+//
+//	@Summary	Добавление в избранное
+//	@Tags		favorites
+//	@Produce	json
+//	@Param		user_id	path		int							true	"ID пользователя"
+//	@Param		ad_id	path		int							true	"ID объявления"
+//	@Success	200		{object}	response{data=[]adResponse}	"Избранное после добавления"
+//	@Failure	400		{object}	errorResponse
+//	@Failure	403		{object}	errorResponse	"Объявление не опубликовано"
+//	@Failure	500		{object}	errorResponse
+//	@Router		/api/v1/users/{user_id}/favorites/{ad_id} [put]
+func addFavorite(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, adID, err := userAndID(c, "ad_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		if err := withRequest(c, a).AddFavorite(userID, adID); err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		respondFavorites(c, a, userID)
+	}
+}
+
+// Метод для удаления объявления из избранного
+//
+//	@Summary	Удаление из избранного
+//	@Tags		favorites
+//	@Produce	json
+//	@Param		user_id	path		int							true	"ID пользователя"
+//	@Param		ad_id	path		int							true	"ID объявления"
+//	@Success	200		{object}	response{data=[]adResponse}	"Избранное после удаления"
+//	@Failure	400		{object}	errorResponse
+//	@Failure	500		{object}	errorResponse
+//	@Router		/api/v1/users/{user_id}/favorites/{ad_id} [delete]
+func removeFavorite(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, adID, err := userAndID(c, "ad_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		if err := withRequest(c, a).RemoveFavorite(userID, adID); err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		respondFavorites(c, a, userID)
+	}
+}
+
+// Метод получения избранных объявлений пользователя
+//
+//	@Summary	Избранное
+//	@Tags		favorites
+//	@Produce	json
+//	@Param		user_id	path		int	true	"ID пользователя"
+//	@Success	200		{object}	response{data=[]adResponse}
+//	@Failure	400		{object}	errorResponse
+//	@Failure	500		{object}	errorResponse
+//	@Router		/api/v1/users/{user_id}/favorites [get]
+func getFavorites(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		respondFavorites(c, a, userID)
+	}
+}
+
+func respondFavorites(c *gin.Context, a app.App, userID int64) {
+	ads, err := a.Favorites(userID)
+	if err != nil {
+		c.JSON(handleErr(err), AdErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, AdsSuccessResponse(&ads))
+}
+
+// Метод для сохранения поиска
+//
+//	@Summary		Сохранение поиска
+//	@Description	Критерии те же, что у /api/v1/ads/filter. О новых подходящих объявлениях пользователь получает уведомления
+//	@Tags			favorites
+//	@Accept			json
+//	@Produce		json
+//	@Param			user_id	path		int					true	"ID пользователя"
+//	@Param			request	body		saveSearchRequest	true	"Название и критерии поиска"
+//	@Success		200		{object}	response{data=searchResponse}
+//	@Failure		400		{object}	errorResponse
+//	@Failure		500		{object}	errorResponse
+//	@Router			/api/v1/users/{user_id}/searches [post]
+func saveSearch(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody saveSearchRequest
+		if err := c.BindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		f := ads.Filter{AuthorID: -1, Date: reqBody.Date, Title: reqBody.Title}
+		if reqBody.AuthorID != nil {
+			f.AuthorID = *reqBody.AuthorID
+		}
+
+		s, err := withRequest(c, a).SaveSearch(userID, reqBody.Name, f)
+		if err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, SearchSuccessResponse(&s))
+	}
+}
+
+// Метод получения сохраненных поисков пользователя
+//
+//	@Summary	Сохраненные поиски
+//	@Tags		favorites
+//	@Produce	json
+//	@Param		user_id	path		int	true	"ID пользователя"
+//	@Success	200		{object}	response{data=[]searchResponse}
+//	@Failure	400		{object}	errorResponse
+//	@Failure	500		{object}	errorResponse
+//	@Router		/api/v1/users/{user_id}/searches [get]
+func getSearches(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		list, err := a.SavedSearches(userID)
+		if err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, SearchesSuccessResponse(list))
+	}
+}
+
+// Метод для удаления сохраненного поиска
+//
+//	@Summary	Удаление сохраненного поиска
+//	@Tags		favorites
+//	@Produce	json
+//	@Param		user_id		path		int	true	"ID пользователя"
+//	@Param		search_id	path		int	true	"ID поиска"
+//	@Success	200			{object}	response
+//	@Failure	400			{object}	errorResponse
+//	@Failure	404			{object}	errorResponse	"Поиск не найден"
+//	@Failure	500			{object}	errorResponse
+//	@Router		/api/v1/users/{user_id}/searches/{search_id} [delete]
+func deleteSearch(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, searchID, err := userAndID(c, "search_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		if err := withRequest(c, a).DeleteSearch(userID, searchID); err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, &response{})
+	}
+}
+
+// Метод для выполнения сохраненного поиска
+//
+//	@Summary	Объявления по сохраненному поиску
+//	@Tags		favorites
+//	@Produce	json
+//	@Param		user_id		path		int	true	"ID пользователя"
+//	@Param		search_id	path		int	true	"ID поиска"
+//	@Success	200			{object}	response{data=[]adResponse}
+//	@Failure	400			{object}	errorResponse
+//	@Failure	404			{object}	errorResponse	"Поиск не найден"
+//	@Failure	500			{object}	errorResponse
+//	@Router		/api/v1/users/{user_id}/searches/{search_id}/ads [get]
+func runSearch(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, searchID, err := userAndID(c, "search_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		ads, err := a.RunSearch(userID, searchID)
+		if err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, AdsSuccessResponse(&ads))
+	}
+}
+
+// Метод для отправки сообщения автору объявления
+//
+//	@Summary		Сообщение автору объявления
+//	@Description	Первое сообщение создает переписку покупателя по объявлению, последующие попадают в нее же
+//	@Tags			messages
+//	@Accept			json
+//	@Produce		json
+//	@Param			ad_id	path		int						true	"ID объявления"
+//	@Param			request	body		contactAuthorRequest	true	"ID покупателя и текст сообщения"
+//	@Success		200		{object}	response{data=messageResponse}
+//	@Failure		400		{object}	errorResponse
+//	@Failure		403		{object}	errorResponse	"Объявление не опубликовано"
+//	@Failure		500		{object}	errorResponse
+//	@Router			/api/v1/ads/{ad_id}/messages [post]
+func contactAuthor(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody contactAuthorRequest
+		if err := c.BindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		adID, err := strconv.ParseInt(c.Param("ad_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		m, err := withRequest(c, a).ContactAuthor(adID, reqBody.UserID, reqBody.Text)
+		if err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, MessageSuccessResponse(&m))
+	}
+}
+
+// Метод получения переписок пользователя
+//
+//	@Summary	Переписки пользователя
+//	@Tags		messages
+//	@Produce	json
+//	@Param		user_id	path		int								true	"ID пользователя"
+//	@Success	200		{object}	response{data=[]threadResponse}	"Начиная с последней активной"
+//	@Failure	400		{object}	errorResponse
+//	@Failure	500		{object}	errorResponse
+//	@Router		/api/v1/users/{user_id}/threads [get]
+func getThreads(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		list, err := a.Threads(userID)
+		if err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, ThreadsSuccessResponse(list, userID))
+	}
+}
+
+// Метод получения сообщений переписки
+//
+//	@Summary	Сообщения переписки
+//	@Tags		messages
+//	@Produce	json
+//	@Param		user_id		path		int									true	"ID участника переписки"
+//	@Param		thread_id	path		int									true	"ID переписки"
+//	@Success	200			{object}	response{data=[]messageResponse}	"В порядке отправки"
+//	@Failure	400			{object}	errorResponse
+//	@Failure	403			{object}	errorResponse	"Пользователь не участвует в переписке"
+//	@Failure	404			{object}	errorResponse	"Переписка не найдена"
+//	@Failure	500			{object}	errorResponse
+//	@Router		/api/v1/users/{user_id}/threads/{thread_id}/messages [get]
+func getMessages(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, threadID, err := userAndID(c, "thread_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		list, err := a.Messages(threadID, userID)
+		if err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, MessagesSuccessResponse(list))
+	}
+}
+
+// Метод для отправки сообщения в переписку
+//
+//	@Summary	Отправка сообщения
+//	@Tags		messages
+//	@Accept		json
+//	@Produce	json
+//	@Param		user_id		path		int					true	"ID участника переписки"
+//	@Param		thread_id	path		int					true	"ID переписки"
+//	@Param		request		body		sendMessageRequest	true	"Текст сообщения"
+//	@Success	200			{object}	response{data=messageResponse}
+//	@Failure	400			{object}	errorResponse
+//	@Failure	403			{object}	errorResponse	"Пользователь не участвует в переписке"
+//	@Failure	404			{object}	errorResponse	"Переписка не найдена"
+//	@Failure	500			{object}	errorResponse
+//	@Router		/api/v1/users/{user_id}/threads/{thread_id}/messages [post]
+func sendMessage(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody sendMessageRequest
+		if err := c.BindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		userID, threadID, err := userAndID(c, "thread_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		m, err := withRequest(c, a).SendMessage(threadID, userID, reqBody.Text)
+		if err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, MessageSuccessResponse(&m))
+	}
+}
+
+// Метод для отметки сообщений переписки прочитанными
+//
+//	@Summary	Отметка переписки прочитанной
+//	@Tags		messages
+//	@Produce	json
+//	@Param		user_id		path		int	true	"ID участника переписки"
+//	@Param		thread_id	path		int	true	"ID переписки"
+//	@Success	200			{object}	response{data=threadResponse}
+//	@Failure	400			{object}	errorResponse
+//	@Failure	403			{object}	errorResponse	"Пользователь не участвует в переписке"
+//	@Failure	404			{object}	errorResponse	"Переписка не найдена"
+//	@Failure	500			{object}	errorResponse
+//	@Router		/api/v1/users/{user_id}/threads/{thread_id}/read [post]
+func markThreadRead(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, threadID, err := userAndID(c, "thread_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		t, err := a.MarkThreadRead(threadID, userID)
+		if err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, ThreadSuccessResponse(&t, userID))
+	}
+}
+
+// Метод для подписки партнера на события объявлений
+//
+//	@Summary		Создание webhook
+//	@Description	События доставляются POST-запросом с JSON-телом. Заголовок X-Webhook-Signature содержит
+//	@Description	sha256=hex(HMAC-SHA256(secret, X-Webhook-Timestamp + "." + тело))
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			user_id	query		int						true	"ID администратора"
+//	@Param			request	body		createWebhookRequest	true	"Адрес, ключ подписи и события"
+//	@Success		200		{object}	response{data=webhookResponse}
+//	@Failure		400		{object}	errorResponse
+//	@Failure		403		{object}	errorResponse	"Пользователь не администратор"
+//	@Failure		500		{object}	errorResponse
+//	@Router			/api/v1/webhooks [post]
+func createWebhook(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody createWebhookRequest
+		if err := c.BindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		adminID, err := strconv.ParseInt(c.Query("user_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		s, err := withRequest(c, a).CreateWebhook(adminID, reqBody.URL, reqBody.Secret, reqBody.Events)
+		if err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, WebhookSuccessResponse(&s))
+	}
+}
+
+// Метод получения подписок на события
+//
+//	@Summary	Список webhook
+//	@Tags		webhooks
+//	@Produce	json
+//	@Param		user_id	query		int	true	"ID администратора"
+//	@Success	200		{object}	response{data=[]webhookResponse}
+//	@Failure	400		{object}	errorResponse
+//	@Failure	403		{object}	errorResponse	"Пользователь не администратор"
+//	@Failure	500		{object}	errorResponse
+//	@Router		/api/v1/webhooks [get]
+func getWebhooks(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, err := strconv.ParseInt(c.Query("user_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		list, err := a.Webhooks(adminID)
+		if err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, WebhooksSuccessResponse(list))
+	}
+}
+
+// Метод для удаления подписки на события
+//
+//	@Summary	Удаление webhook
+//	@Tags		webhooks
+//	@Produce	json
+//	@Param		webhook_id	path		int	true	"ID подписки"
+//	@Param		user_id		query		int	true	"ID администратора"
+//	@Success	200			{object}	response
+//	@Failure	400			{object}	errorResponse
+//	@Failure	403			{object}	errorResponse	"Пользователь не администратор"
+//	@Failure	404			{object}	errorResponse	"Подписка не найдена"
+//	@Failure	500			{object}	errorResponse
+//	@Router		/api/v1/webhooks/{webhook_id} [delete]
+func deleteWebhook(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("webhook_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		adminID, err := strconv.ParseInt(c.Query("user_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		if err := withRequest(c, a).DeleteWebhook(adminID, id); err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, &response{})
+	}
+}
+
+// Метод получения доставок событий и их попыток
+//
+//	@Summary		Доставки webhook
+//	@Description	С фильтром status=dead - список недоставленных событий
+//	@Tags			webhooks
+//	@Produce		json
+//	@Param			user_id		query		int		true	"ID администратора"
+//	@Param			webhook_id	query		int		false	"ID подписки"
+//	@Param			status		query		string	false	"pending, delivered или dead"
+//	@Success		200			{object}	response{data=[]deliveryResponse}
+//	@Failure		400			{object}	errorResponse
+//	@Failure		403			{object}	errorResponse	"Пользователь не администратор"
+//	@Failure		500			{object}	errorResponse
+//	@Router			/api/v1/webhooks/deliveries [get]
+func getWebhookDeliveries(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, err := strconv.ParseInt(c.Query("user_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		f := webhooks.Filter{Status: webhooks.Status(c.Query("status"))}
+		if c.Query("webhook_id") != "" {
+			if f.SubscriptionID, err = strconv.ParseInt(c.Query("webhook_id"), 10, 64); err != nil {
+				c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+				return
+			}
+		}
+
+		list, err := a.WebhookDeliveries(adminID, f)
+		if err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, DeliveriesSuccessResponse(list))
+	}
+}
+
+// Метод для повторной отправки события
+//
+//	@Summary		Повторная доставка webhook
+//	@Description	Создает новую доставку того же события, попытки считаются заново
+//	@Tags			webhooks
+//	@Produce		json
+//	@Param			delivery_id	path		int	true	"ID доставки"
+//	@Param			user_id		query		int	true	"ID администратора"
+//	@Success		200			{object}	response{data=deliveryResponse}
+//	@Failure		400			{object}	errorResponse
+//	@Failure		403			{object}	errorResponse	"Пользователь не администратор"
+//	@Failure		404			{object}	errorResponse	"Доставка или подписка не найдена"
+//	@Failure		500			{object}	errorResponse
+//	@Router			/api/v1/webhooks/deliveries/{delivery_id}/redeliver [post]
+func redeliverWebhook(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("delivery_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		adminID, err := strconv.ParseInt(c.Query("user_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		d, err := withRequest(c, a).RedeliverWebhook(adminID, id)
+		if err != nil {
+			c.JSON(handleErr(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, DeliverySuccessResponse(&d))
+	}
+}
@@ -0,0 +1,395 @@
+package httpgin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/ads"
+	"homework10/internal/app"
+)
+
+var errActorRequired = errors.New("query parameter actor_id is required")
+
+// pathID читает из пути числовой ID с именем name
+func pathID(c *gin.Context, name string) (int64, error) {
+	id, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return id, nil
+}
+
+// requiredActor читает ID пользователя, выполняющего действие, из обязательного query-параметра actor_id
+func requiredActor(c *gin.Context) (int64, error) {
+	if c.Query("actor_id") == "" {
+		return 0, errActorRequired
+	}
+	return strconv.ParseInt(c.Query("actor_id"), 10, 64)
+}
+
+// Метод для создания объявления (ad)
+//
+//	@Summary	Создание объявления
+//	@Tags		ads v2
+//	@Accept		json
+//	@Produce	json
+//	@Param		request			body		createAdV2Request	true	"Данные объявления"
+//	@Param		Idempotency-Key	header		string				false	"Ключ идемпотентности, повтор с тем же ключом вернет сохраненный ответ"
+//	@Success	201				{object}	dataResponse{data=adV2Response}
+//	@Header		201				{string}	Location	"Адрес созданного объявления"
+//	@Failure	400				{object}	problem		"Некорректный JSON"
+//	@Failure	409				{object}	problem		"Запрос с этим ключом еще выполняется"
+//	@Failure	422				{object}	problem		"Объявление не прошло проверку или ключ использован с другим телом"
+//	@Router		/api/v2/ads [post]
+func createAdV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody createAdV2Request
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			abortWithProblem(c, http.StatusBadRequest, err)
+			return
+		}
+
+		ad, err := withRequest(c, a).CreateAd(reqBody.Title, reqBody.Text, reqBody.AuthorID)
+		if err != nil {
+			abortWithProblem(c, problemStatus(err), err)
+			return
+		}
+
+		c.Header("Location", fmt.Sprintf("/api/v2/ads/%d", ad.ID))
+		c.JSON(http.StatusCreated, AdV2SuccessResponse(&ad))
+	}
+}
+
+// Метод для получения списка объявлений с фильтрами
+//
+//	@Summary	Список объявлений
+//	@Tags		ads v2
+//	@Produce	json
+//	@Param		author_id	query		int		false	"ID автора"
+//	@Param		date		query		string	false	"Дата создания в формате YYYY-MM-DD"
+//	@Param		title		query		string	false	"Подстрока заголовка"
+//	@Param		all			query		bool	false	"Включить неопубликованные и не одобренные модерацией объявления"
+//	@Success	200			{object}	dataResponse{data=[]adV2Response}
+//	@Failure	400			{object}	problem
+//	@Router		/api/v2/ads [get]
+func listAdsV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		published, authorID, date := 1, int64(-1), ""
+
+		if v := c.Query("all"); v != "" {
+			all, err := strconv.ParseBool(v)
+			if err != nil {
+				abortWithProblem(c, http.StatusBadRequest, fmt.Errorf("invalid all: %w", err))
+				return
+			}
+			if all {
+				published = 0
+			}
+		}
+
+		if v := c.Query("author_id"); v != "" {
+			var err error
+			authorID, err = strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				abortWithProblem(c, http.StatusBadRequest, fmt.Errorf("invalid author_id: %w", err))
+				return
+			}
+		}
+
+		if v := c.Query("date"); v != "" {
+			d, err := time.Parse(time.DateOnly, v)
+			if err != nil {
+				abortWithProblem(c, http.StatusBadRequest, fmt.Errorf("invalid date: %w", err))
+				return
+			}
+			date = d.Format(time.DateOnly)
+		}
+
+		list, err := a.GetFilteredAds(published, authorID, date)
+		if err != nil {
+			abortWithProblem(c, problemStatus(err), err)
+			return
+		}
+
+		res := make([]ads.Ad, 0, len(list))
+		for _, ad := range list {
+			if strings.Contains(ad.Title, c.Query("title")) {
+				res = append(res, ad)
+			}
+		}
+
+		c.JSON(http.StatusOK, AdsV2SuccessResponse(res))
+	}
+}
+
+// Метод для получения объявления по его ID
+//
+//	@Summary	Получение объявления
+//	@Tags		ads v2
+//	@Produce	json
+//	@Param		ad_id	path		int	true	"ID объявления"
+//	@Success	200		{object}	dataResponse{data=adV2Response}
+//	@Failure	400		{object}	problem
+//	@Failure	403		{object}	problem	"Объявление не опубликовано"
+//	@Failure	404		{object}	problem
+//	@Router		/api/v2/ads/{ad_id} [get]
+func getAdV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, err := pathID(c, "ad_id")
+		if err != nil {
+			abortWithProblem(c, http.StatusBadRequest, err)
+			return
+		}
+
+		ad, err := a.GetAd(adID)
+		if err != nil {
+			abortWithProblem(c, problemStatus(err), err)
+			return
+		}
+
+		c.JSON(http.StatusOK, AdV2SuccessResponse(&ad))
+	}
+}
+
+// Метод для частичного изменения объявления: заголовка, текста и статуса публикации
+//
+//	@Summary	Изменение объявления
+//	@Tags		ads v2
+//	@Accept		json
+//	@Produce	json
+//	@Param		ad_id		path		int				true	"ID объявления"
+//	@Param		actor_id	query		int				true	"ID пользователя, выполняющего изменение"
+//	@Param		request		body		patchAdRequest	true	"Изменяемые поля"
+//	@Success	200			{object}	dataResponse{data=adV2Response}
+//	@Failure	400			{object}	problem
+//	@Failure	403			{object}	problem	"Недостаточно прав"
+//	@Failure	404			{object}	problem
+//	@Failure	422			{object}	problem	"Объявление не прошло проверку"
+//	@Router		/api/v2/ads/{ad_id} [patch]
+func patchAdV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, err := pathID(c, "ad_id")
+		if err != nil {
+			abortWithProblem(c, http.StatusBadRequest, err)
+			return
+		}
+
+		actorID, err := requiredActor(c)
+		if err != nil {
+			abortWithProblem(c, http.StatusBadRequest, err)
+			return
+		}
+
+		var reqBody patchAdRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			abortWithProblem(c, http.StatusBadRequest, err)
+			return
+		}
+
+		ad, err := withRequest(c, a).PatchAd(adID, actorID, ads.Patch(reqBody))
+		if err != nil {
+			abortWithProblem(c, problemStatus(err), err)
+			return
+		}
+
+		c.JSON(http.StatusOK, AdV2SuccessResponse(&ad))
+	}
+}
+
+// Метод для удаления объявления
+//
+//	@Summary	Удаление объявления
+//	@Tags		ads v2
+//	@Produce	json
+//	@Param		ad_id		path	int	true	"ID объявления"
+//	@Param		actor_id	query	int	true	"ID пользователя, выполняющего удаление"
+//	@Success	204
+//	@Failure	400	{object}	problem
+//	@Failure	403	{object}	problem	"Недостаточно прав"
+//	@Failure	404	{object}	problem
+//	@Router		/api/v2/ads/{ad_id} [delete]
+func deleteAdV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, err := pathID(c, "ad_id")
+		if err != nil {
+			abortWithProblem(c, http.StatusBadRequest, err)
+			return
+		}
+
+		actorID, err := requiredActor(c)
+		if err != nil {
+			abortWithProblem(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if _, err := withRequest(c, a).DeleteAd(adID, actorID); err != nil {
+			abortWithProblem(c, problemStatus(err), err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// Метод для создания пользователя (user)
+//
+//	@Summary		Создание пользователя
+//	@Tags			users v2
+//	@Accept			json
+//	@Produce		json
+//	@Description	ID назначается сервером, на email отправляется код подтверждения
+//	@Param			request			body		createUserRequest	true	"Данные пользователя"
+//	@Param			Idempotency-Key	header		string				false	"Ключ идемпотентности, повтор с тем же ключом вернет сохраненный ответ"
+//	@Success		201				{object}	dataResponse{data=userV2Response}
+//	@Header			201				{string}	Location	"Адрес созданного пользователя"
+//	@Failure		400				{object}	problem		"Некорректный JSON"
+//	@Failure		409				{object}	problem		"Никнейм или email заняты либо запрос с этим ключом еще выполняется"
+//	@Failure		422				{object}	problem		"Данные не прошли проверку или ключ использован с другим телом"
+//	@Router			/api/v2/users [post]
+func createUserV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody createUserRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			abortWithProblem(c, http.StatusBadRequest, err)
+			return
+		}
+
+		u, err := withRequest(c, a).CreateUser(reqBody.Nickname, reqBody.Email, reqBody.Password)
+		if err != nil {
+			abortWithProblem(c, problemStatus(err), err)
+			return
+		}
+
+		c.Header("Location", fmt.Sprintf("/api/v2/users/%d", u.ID))
+		c.JSON(http.StatusCreated, UserV2SuccessResponse(&u))
+	}
+}
+
+// Метод для получения пользователя по id (user)
+//
+//	@Summary	Получение пользователя
+//	@Tags		users v2
+//	@Produce	json
+//	@Param		user_id	path		int	true	"ID пользователя"
+//	@Success	200		{object}	dataResponse{data=userV2Response}
+//	@Failure	400		{object}	problem
+//	@Failure	404		{object}	problem
+//	@Router		/api/v2/users/{user_id} [get]
+func getUserV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := pathID(c, "user_id")
+		if err != nil {
+			abortWithProblem(c, http.StatusBadRequest, err)
+			return
+		}
+
+		u, err := a.GetUser(id)
+		if err != nil {
+			abortWithProblem(c, problemStatus(err), err)
+			return
+		}
+
+		c.JSON(http.StatusOK, UserV2SuccessResponse(&u))
+	}
+}
+
+// Метод для частичного изменения пользователя (user)
+//
+//	@Summary	Изменение пользователя
+//	@Tags		users v2
+//	@Accept		json
+//	@Produce	json
+//	@Param		user_id		path		int					true	"ID пользователя"
+//	@Param		actor_id	query		int					false	"ID пользователя, выполняющего изменение, по умолчанию user_id"
+//	@Param		request		body		patchUserRequest	true	"Изменяемые поля"
+//	@Success	200			{object}	dataResponse{data=userV2Response}
+//	@Failure	400			{object}	problem
+//	@Failure	403			{object}	problem	"Недостаточно прав"
+//	@Failure	404			{object}	problem
+//	@Failure	422			{object}	problem	"Данные не прошли проверку"
+//	@Router		/api/v2/users/{user_id} [patch]
+func patchUserV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := pathID(c, "user_id")
+		if err != nil {
+			abortWithProblem(c, http.StatusBadRequest, err)
+			return
+		}
+
+		actorID, err := actorParam(c, id)
+		if err != nil {
+			abortWithProblem(c, http.StatusBadRequest, err)
+			return
+		}
+
+		var reqBody patchUserRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			abortWithProblem(c, http.StatusBadRequest, err)
+			return
+		}
+
+		// UpdateUser не меняет пустые поля, поэтому явно переданная пустая строка - ошибка, а не пропуск
+		var nickname, email string
+		if reqBody.Nickname != nil {
+			if nickname = *reqBody.Nickname; nickname == "" {
+				abortWithProblem(c, http.StatusUnprocessableEntity, app.ValidationErr)
+				return
+			}
+		}
+		if reqBody.Email != nil {
+			if email = *reqBody.Email; email == "" {
+				abortWithProblem(c, http.StatusUnprocessableEntity, app.ValidationErr)
+				return
+			}
+		}
+
+		u, err := withRequest(c, a).UpdateUser(id, actorID, nickname, email)
+		if err != nil {
+			abortWithProblem(c, problemStatus(err), err)
+			return
+		}
+
+		c.JSON(http.StatusOK, UserV2SuccessResponse(&u))
+	}
+}
+
+// Метод для удаления пользователя (user)
+//
+//	@Summary	Удаление пользователя
+//	@Tags		users v2
+//	@Produce	json
+//	@Param		user_id		path	int	true	"ID пользователя"
+//	@Param		actor_id	query	int	false	"ID пользователя, выполняющего удаление, по умолчанию user_id"
+//	@Success	204
+//	@Failure	400	{object}	problem
+//	@Failure	403	{object}	problem	"Недостаточно прав"
+//	@Failure	404	{object}	problem
+//	@Router		/api/v2/users/{user_id} [delete]
+func deleteUserV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := pathID(c, "user_id")
+		if err != nil {
+			abortWithProblem(c, http.StatusBadRequest, err)
+			return
+		}
+
+		actorID, err := actorParam(c, id)
+		if err != nil {
+			abortWithProblem(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if _, err := withRequest(c, a).DeleteUser(id, actorID); err != nil {
+			abortWithProblem(c, problemStatus(err), err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,188 @@
+package httpgin
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/idempotency"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotency-Replayed"
+)
+
+// idempotentRoutes - маршруты, которые учитывают заголовок Idempotency-Key
+var idempotentRoutes = []string{"/api/v1/ads", "/api/v1/users", "/api/v1/ads:import", "/api/v2/ads", "/api/v2/users"}
+
+// streamingRoutes - маршруты, тело которых может быть очень большим. Оно не читается в память,
+// а хешируется по мере чтения обработчиком
+var streamingRoutes = map[string]bool{"/api/v1/ads:import": true}
+
+// Idempotency выполняет POST-запросы к routes с заголовком Idempotency-Key один раз: повтор с тем же
+// ключом и телом получает сохраненный ответ, повтор с другим телом - 422, а пока первый запрос
+// выполняется - 409. Ответы 5xx не сохраняются, такой запрос можно повторить
+func Idempotency(k *idempotency.Keeper, routes ...string) gin.HandlerFunc {
+	idempotent := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		idempotent[r] = true
+	}
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" || c.Request.Method != http.MethodPost || !idempotent[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		// ключи разных маршрутов не пересекаются
+		route := c.FullPath()
+		key = route + " " + key
+		if streamingRoutes[route] {
+			idempotentStream(c, k, key)
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortIdempotency(c, http.StatusBadRequest, err)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := idempotency.Fingerprint([]byte(c.Request.URL.RawQuery),
+			[]byte(c.ContentType()), body)
+
+		replay, err := k.Begin(key, fingerprint)
+		if err != nil {
+			abortIdempotency(c, idempotencyStatus(err), err)
+			return
+		}
+		if replay != nil {
+			replayResponse(c, replay)
+			return
+		}
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		var res *idempotency.Response
+		if w.Status() < http.StatusInternalServerError {
+			res = &idempotency.Response{Status: w.Status(), ContentType: w.Header().Get("Content-Type"),
+				Body: w.body.Bytes()}
+		}
+		finish(k, key, "", res)
+	}
+}
+
+// idempotentStream выполняет потоковый запрос: тело хешируется, пока его читает обработчик,
+// а сохраняется только итоговый ответ. Повтор читает тело до конца только ради отпечатка
+func idempotentStream(c *gin.Context, k *idempotency.Keeper, key string) {
+	done, err := k.BeginStream(key)
+	if err != nil {
+		abortIdempotency(c, idempotencyStatus(err), err)
+		return
+	}
+
+	fingerprint := idempotency.NewStreamFingerprint([]byte(c.Request.URL.RawQuery), []byte(c.ContentType()))
+	if done != nil {
+		if _, err := io.Copy(fingerprint, c.Request.Body); err != nil {
+			abortIdempotency(c, http.StatusBadRequest, err)
+			return
+		}
+
+		replay, err := idempotency.Replay(done, fingerprint.Sum())
+		if err != nil {
+			abortIdempotency(c, idempotencyStatus(err), err)
+			return
+		}
+		replayResponse(c, replay)
+		return
+	}
+
+	body := c.Request.Body
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.TeeReader(body, fingerprint), body}
+
+	w := &recordingWriter{ResponseWriter: c.Writer}
+	c.Writer = w
+	c.Next()
+
+	// обработчик мог прервать чтение на ошибке, а отпечаток считается по всему телу
+	var res *idempotency.Response
+	if _, err := io.Copy(io.Discard, c.Request.Body); err == nil && w.Status() < http.StatusInternalServerError {
+		res = &idempotency.Response{Status: w.Status(), ContentType: w.Header().Get("Content-Type"),
+			Body: w.body.Bytes()}
+	}
+	finish(k, key, fingerprint.Sum(), res)
+}
+
+// finish сохраняет ответ res или, если его нет, освобождает ключ, чтобы запрос можно было повторить.
+// Непустой fingerprint - отпечаток потокового запроса
+func finish(k *idempotency.Keeper, key string, fingerprint string, res *idempotency.Response) {
+	var err error
+	switch {
+	case res == nil:
+		err = k.Abort(key)
+	case fingerprint != "":
+		err = k.FinishStream(key, fingerprint, *res)
+	default:
+		err = k.Finish(key, *res)
+	}
+	if err != nil {
+		log.Printf("idempotency: %s", err.Error())
+	}
+}
+
+func replayResponse(c *gin.Context, r *idempotency.Response) {
+	c.Header(idempotencyReplayedHeader, "true")
+	c.Data(r.Status, r.ContentType, r.Body)
+	c.Abort()
+}
+
+func idempotencyStatus(err error) int {
+	switch err {
+	case idempotency.ErrKeyReused:
+		return http.StatusUnprocessableEntity
+	case idempotency.ErrInProgress:
+		return http.StatusConflict
+	case idempotency.ErrInvalidKey:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// recordingWriter запоминает тело ответа, чтобы его можно было повторить. У потоковых
+// маршрутов ответ - только итоговый отчет, поэтому он тоже небольшой
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// abortIdempotency отвечает ошибкой в формате версии API, к которой относится маршрут
+func abortIdempotency(c *gin.Context, status int, err error) {
+	if strings.HasPrefix(c.FullPath(), "/api/v2/") {
+		abortWithProblem(c, status, err)
+		return
+	}
+	c.AbortWithStatusJSON(status, AdErrorResponse(err))
+}
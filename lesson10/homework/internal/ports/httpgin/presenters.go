@@ -1,21 +1,52 @@
 package httpgin
 
 import (
-	"github.com/gin-gonic/gin"
+	"encoding/json"
 	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/audit"
+	"homework10/internal/favorites"
+	"homework10/internal/messages"
 	"homework10/internal/users"
+	"homework10/internal/webhooks"
+	"time"
 )
 
+// response - конверт, в который завернуты все успешные ответы API
+type response struct {
+	Data  any     `json:"data"`
+	Error *string `json:"error"`
+}
+
+// errorResponse - конверт ответа с ошибкой
+type errorResponse struct {
+	Data  any    `json:"data"`
+	Error string `json:"error"`
+}
+
 type createUserRequest struct {
 	Nickname string `json:"nickname"`
 	Email    string `json:"email"`
-	UserID   int64  `json:"user_id"`
+	// от 8 до 72 байт
+	Password string `json:"password"`
 }
 
 type userResponse struct {
 	Nickname string `json:"nickname"`
 	Email    string `json:"email"`
 	UserID   int64  `json:"user_id"`
+	// user, moderator или admin
+	Role          string `json:"role"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type setUserRoleRequest struct {
+	ActorID int64  `json:"actor_id"`
+	Role    string `json:"role"`
 }
 
 type updateUserRequest struct {
@@ -23,14 +54,16 @@ type updateUserRequest struct {
 	Email    string `json:"email"`
 }
 
-func UserSuccessResponse(u *users.User) *gin.H {
-	return &gin.H{
-		"data": userResponse{
+func UserSuccessResponse(u *users.User) *response {
+	return &response{
+		Data: userResponse{
 			UserID:   u.ID,
 			Email:    u.Email,
 			Nickname: u.Nickname,
+			Role:     string(u.Role),
+
+			EmailVerified: u.EmailVerified,
 		},
-		"error": nil,
 	}
 }
 
@@ -41,11 +74,40 @@ type createAdRequest struct {
 }
 
 type adResponse struct {
-	ID        int64  `json:"id"`
-	Title     string `json:"title"`
-	Text      string `json:"text"`
-	AuthorID  int64  `json:"author_id"`
-	Published bool   `json:"published"`
+	ID        int64      `json:"id"`
+	Title     string     `json:"title"`
+	Text      string     `json:"text"`
+	AuthorID  int64      `json:"author_id"`
+	Published bool       `json:"published"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// pending_review, approved или rejected
+	Moderation   string   `json:"moderation"`
+	Flags        []string `json:"flags,omitempty"`
+	RejectReason string   `json:"reject_reason,omitempty"`
+}
+
+func newAdResponse(ad *ads.Ad) adResponse {
+	return adResponse{
+		ID:        ad.ID,
+		Title:     ad.Title,
+		Text:      ad.Text,
+		AuthorID:  ad.AuthorID,
+		Published: ad.Published,
+		PublishAt: timeOrNil(ad.PublishAt),
+		ExpiresAt: timeOrNil(ad.ExpiresAt),
+
+		Moderation:   string(ad.Moderation),
+		Flags:        ad.Flags,
+		RejectReason: ad.RejectReason,
+	}
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
 }
 
 type deleteAdResponse struct {
@@ -58,45 +120,322 @@ type changeAdStatusRequest struct {
 	UserID    int64 `json:"user_id"`
 }
 
+type scheduleAdRequest struct {
+	UserID    int64      `json:"user_id"`
+	PublishAt *time.Time `json:"publish_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type approveAdRequest struct {
+	UserID int64 `json:"user_id"`
+}
+
+type rejectAdRequest struct {
+	UserID int64  `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
 type updateAdRequest struct {
 	Title  string `json:"title"`
 	Text   string `json:"text"`
 	UserID int64  `json:"user_id"`
 }
 
-func AdSuccessResponse(ad *ads.Ad) *gin.H {
-	return &gin.H{
-		"data": adResponse{
-			ID:        ad.ID,
-			Title:     ad.Title,
-			Text:      ad.Text,
-			AuthorID:  ad.AuthorID,
-			Published: ad.Published,
-		},
-		"error": nil,
+func AdSuccessResponse(ad *ads.Ad) *response {
+	return &response{
+		Data: newAdResponse(ad),
 	}
 }
 
-func AdsSuccessResponse(ads *[]ads.Ad) *gin.H {
+func AdsSuccessResponse(ads *[]ads.Ad) *response {
 	res := make([]adResponse, 0)
-	for _, ad := range *ads {
-		res = append(res, adResponse{
-			ID:        ad.ID,
-			Title:     ad.Title,
-			Text:      ad.Text,
-			AuthorID:  ad.AuthorID,
-			Published: ad.Published,
-		})
+	for i := range *ads {
+		res = append(res, newAdResponse(&(*ads)[i]))
 	}
-	return &gin.H{
-		"data":  res,
-		"error": nil,
+	return &response{
+		Data: res,
 	}
 }
 
-func AdErrorResponse(err error) *gin.H {
-	return &gin.H{
-		"data":  nil,
-		"error": err.Error(),
+func AdErrorResponse(err error) *errorResponse {
+	return &errorResponse{
+		Error: err.Error(),
+	}
+}
+
+type importErrorResponse struct {
+	Line  int64  `json:"line"`
+	Error string `json:"error"`
+}
+
+type importResponse struct {
+	Total    int64                 `json:"total"`
+	Imported int64                 `json:"imported"`
+	Failed   int64                 `json:"failed"`
+	DryRun   bool                  `json:"dry_run"`
+	Errors   []importErrorResponse `json:"errors"`
+}
+
+func ImportSuccessResponse(r *app.ImportReport) *response {
+	errs := make([]importErrorResponse, 0, len(r.Errors))
+	for _, e := range r.Errors {
+		errs = append(errs, importErrorResponse{Line: e.Line, Error: e.Err.Error()})
+	}
+	return &response{
+		Data: importResponse{
+			Total:    r.Total,
+			Imported: r.Imported,
+			Failed:   r.Failed,
+			DryRun:   r.DryRun,
+			Errors:   errs,
+		},
+	}
+}
+
+// auditEntryResponse повторяет audit.Entry, чтобы по выгрузке можно было проверить цепочку хешей
+type auditEntryResponse struct {
+	Seq       int64           `json:"seq"`
+	At        time.Time       `json:"at"`
+	ActorID   int64           `json:"actor_id"`
+	Role      string          `json:"role"`
+	Action    string          `json:"action"`
+	Target    string          `json:"target"`
+	Before    json.RawMessage `json:"before" swaggertype:"object"`
+	After     json.RawMessage `json:"after" swaggertype:"object"`
+	RequestID string          `json:"request_id,omitempty"`
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `json:"hash"`
+}
+
+func AuditSuccessResponse(entries []audit.Entry) *response {
+	res := make([]auditEntryResponse, 0, len(entries))
+	for _, e := range entries {
+		res = append(res, auditEntryResponse(e))
+	}
+
+	return &response{
+		Data: res,
+	}
+}
+
+type saveSearchRequest struct {
+	Name string `json:"name"`
+	// не задан - любой автор
+	AuthorID *int64 `json:"author_id"`
+	// дата создания в формате YYYY-MM-DD
+	Date string `json:"date"`
+	// подстрока заголовка
+	Title string `json:"title"`
+}
+
+type searchResponse struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Name      string    `json:"name"`
+	AuthorID  *int64    `json:"author_id,omitempty"`
+	Date      string    `json:"date,omitempty"`
+	Title     string    `json:"title,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newSearchResponse(s *favorites.Search) searchResponse {
+	res := searchResponse{
+		ID:        s.ID,
+		UserID:    s.UserID,
+		Name:      s.Name,
+		Date:      s.Filter.Date,
+		Title:     s.Filter.Title,
+		CreatedAt: s.CreatedAt,
+	}
+	if s.Filter.AuthorID != -1 {
+		authorID := s.Filter.AuthorID
+		res.AuthorID = &authorID
+	}
+	return res
+}
+
+func SearchSuccessResponse(s *favorites.Search) *response {
+	return &response{
+		Data: newSearchResponse(s),
+	}
+}
+
+func SearchesSuccessResponse(list []favorites.Search) *response {
+	res := make([]searchResponse, 0, len(list))
+	for i := range list {
+		res = append(res, newSearchResponse(&list[i]))
+	}
+	return &response{
+		Data: res,
+	}
+}
+
+type contactAuthorRequest struct {
+	UserID int64  `json:"user_id"`
+	Text   string `json:"text"`
+}
+
+type sendMessageRequest struct {
+	Text string `json:"text"`
+}
+
+type messageResponse struct {
+	ID       int64     `json:"id"`
+	ThreadID int64     `json:"thread_id"`
+	SenderID int64     `json:"sender_id"`
+	Text     string    `json:"text"`
+	SentAt   time.Time `json:"sent_at"`
+}
+
+func MessageSuccessResponse(m *messages.Message) *response {
+	return &response{
+		Data: messageResponse(*m),
+	}
+}
+
+func MessagesSuccessResponse(list []messages.Message) *response {
+	res := make([]messageResponse, 0, len(list))
+	for _, m := range list {
+		res = append(res, messageResponse(m))
+	}
+	return &response{
+		Data: res,
+	}
+}
+
+type threadResponse struct {
+	ID            int64     `json:"id"`
+	AdID          int64     `json:"ad_id"`
+	AuthorID      int64     `json:"author_id"`
+	BuyerID       int64     `json:"buyer_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	LastMessageAt time.Time `json:"last_message_at"`
+	// непрочитанные сообщения пользователя, запросившего переписку
+	Unread int `json:"unread"`
+}
+
+func newThreadResponse(t *messages.Thread, userID int64) threadResponse {
+	return threadResponse{
+		ID:            t.ID,
+		AdID:          t.AdID,
+		AuthorID:      t.AuthorID,
+		BuyerID:       t.BuyerID,
+		CreatedAt:     t.CreatedAt,
+		LastMessageAt: t.LastMessageAt,
+		Unread:        t.Unread(userID),
+	}
+}
+
+func ThreadSuccessResponse(t *messages.Thread, userID int64) *response {
+	return &response{
+		Data: newThreadResponse(t, userID),
+	}
+}
+
+func ThreadsSuccessResponse(list []messages.Thread, userID int64) *response {
+	res := make([]threadResponse, 0, len(list))
+	for i := range list {
+		res = append(res, newThreadResponse(&list[i], userID))
+	}
+	return &response{
+		Data: res,
+	}
+}
+
+type createWebhookRequest struct {
+	URL string `json:"url"`
+	// ключ подписи HMAC-SHA256, не короче 16 символов
+	Secret string `json:"secret"`
+	// пустой список - все события
+	Events []string `json:"events"`
+}
+
+type webhookResponse struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newWebhookResponse(s *webhooks.Subscription) webhookResponse {
+	return webhookResponse{
+		ID:        s.ID,
+		URL:       s.URL,
+		Events:    append([]string{}, s.Events...),
+		CreatedAt: s.CreatedAt,
+	}
+}
+
+func WebhookSuccessResponse(s *webhooks.Subscription) *response {
+	return &response{
+		Data: newWebhookResponse(s),
+	}
+}
+
+func WebhooksSuccessResponse(list []webhooks.Subscription) *response {
+	res := make([]webhookResponse, 0, len(list))
+	for i := range list {
+		res = append(res, newWebhookResponse(&list[i]))
+	}
+	return &response{
+		Data: res,
+	}
+}
+
+type attemptResponse struct {
+	At time.Time `json:"at"`
+	// 0 - ответ не получен
+	StatusCode int    `json:"status_code"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+type deliveryResponse struct {
+	ID        int64           `json:"id"`
+	WebhookID int64           `json:"webhook_id"`
+	Event     string          `json:"event"`
+	Payload   json.RawMessage `json:"payload" swaggertype:"object"`
+	// pending, delivered или dead
+	Status   string            `json:"status"`
+	Attempts []attemptResponse `json:"attempts"`
+	// только для pending
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func newDeliveryResponse(d *webhooks.Delivery) deliveryResponse {
+	res := deliveryResponse{
+		ID:        d.ID,
+		WebhookID: d.SubscriptionID,
+		Event:     d.Event,
+		Payload:   d.Payload,
+		Status:    string(d.Status),
+		Attempts:  make([]attemptResponse, 0, len(d.Attempts)),
+		CreatedAt: d.CreatedAt,
+	}
+	for _, at := range d.Attempts {
+		res.Attempts = append(res.Attempts, attemptResponse{At: at.At, StatusCode: at.StatusCode, Error: at.Error,
+			DurationMS: at.Duration.Milliseconds()})
+	}
+	if d.Status == webhooks.StatusPending {
+		next := d.NextAttemptAt
+		res.NextAttemptAt = &next
+	}
+	return res
+}
+
+func DeliverySuccessResponse(d *webhooks.Delivery) *response {
+	return &response{
+		Data: newDeliveryResponse(d),
+	}
+}
+
+func DeliveriesSuccessResponse(list []webhooks.Delivery) *response {
+	res := make([]deliveryResponse, 0, len(list))
+	for i := range list {
+		res = append(res, newDeliveryResponse(&list[i]))
+	}
+	return &response{
+		Data: res,
 	}
 }
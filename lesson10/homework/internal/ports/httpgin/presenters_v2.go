@@ -0,0 +1,107 @@
+package httpgin
+
+import (
+	"time"
+
+	"homework10/internal/ads"
+	"homework10/internal/users"
+)
+
+// dataResponse - конверт успешных ответов API v2, ошибки возвращаются как problem
+type dataResponse struct {
+	Data any `json:"data"`
+}
+
+type createAdV2Request struct {
+	Title    string `json:"title"`
+	Text     string `json:"text"`
+	AuthorID int64  `json:"author_id"`
+}
+
+// patchAdRequest - отсутствующие поля не меняются
+type patchAdRequest struct {
+	Title     *string `json:"title"`
+	Text      *string `json:"text"`
+	Published *bool   `json:"published"`
+}
+
+// patchUserRequest - отсутствующие поля не меняются
+type patchUserRequest struct {
+	Nickname *string `json:"nickname"`
+	Email    *string `json:"email"`
+}
+
+type adV2Response struct {
+	ID        int64      `json:"id"`
+	Title     string     `json:"title"`
+	Text      string     `json:"text"`
+	AuthorID  int64      `json:"author_id"`
+	Published bool       `json:"published"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// pending_review, approved или rejected
+	Moderation   string    `json:"moderation"`
+	Flags        []string  `json:"flags"`
+	RejectReason string    `json:"reject_reason,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func newAdV2Response(ad *ads.Ad) adV2Response {
+	return adV2Response{
+		ID:        ad.ID,
+		Title:     ad.Title,
+		Text:      ad.Text,
+		AuthorID:  ad.AuthorID,
+		Published: ad.Published,
+		PublishAt: timeOrNil(ad.PublishAt),
+		ExpiresAt: timeOrNil(ad.ExpiresAt),
+
+		Moderation:   string(ad.Moderation),
+		Flags:        append([]string{}, ad.Flags...),
+		RejectReason: ad.RejectReason,
+		CreatedAt:    ad.CreateDate,
+		UpdatedAt:    ad.LastUpdate,
+	}
+}
+
+func AdV2SuccessResponse(ad *ads.Ad) *dataResponse {
+	return &dataResponse{
+		Data: newAdV2Response(ad),
+	}
+}
+
+func AdsV2SuccessResponse(list []ads.Ad) *dataResponse {
+	res := make([]adV2Response, 0, len(list))
+	for i := range list {
+		res = append(res, newAdV2Response(&list[i]))
+	}
+	return &dataResponse{
+		Data: res,
+	}
+}
+
+type userV2Response struct {
+	ID       int64  `json:"id"`
+	Nickname string `json:"nickname"`
+	Email    string `json:"email"`
+	// user, moderator или admin
+	Role          string    `json:"role"`
+	EmailVerified bool      `json:"email_verified"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func UserV2SuccessResponse(u *users.User) *dataResponse {
+	return &dataResponse{
+		Data: userV2Response{
+			ID:            u.ID,
+			Nickname:      u.Nickname,
+			Email:         u.Email,
+			Role:          string(u.Role),
+			EmailVerified: u.EmailVerified,
+			CreatedAt:     u.CreatedAt,
+			UpdatedAt:     u.UpdatedAt,
+		},
+	}
+}
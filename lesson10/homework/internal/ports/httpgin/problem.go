@@ -0,0 +1,67 @@
+package httpgin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/favorites"
+	"homework10/internal/messages"
+	"homework10/internal/users"
+	"homework10/internal/webhooks"
+)
+
+const problemContentType = "application/problem+json"
+
+// problem - ошибка API v2 в формате RFC 7807
+type problem struct {
+	// всегда about:blank: тип ошибки определяется кодом ответа
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+	// путь запроса, вызвавшего ошибку
+	Instance  string `json:"instance"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// abortWithProblem завершает запрос ответом application/problem+json
+func abortWithProblem(c *gin.Context, status int, err error) {
+	b, _ := json.Marshal(problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    err.Error(),
+		Instance:  c.Request.URL.Path,
+		RequestID: c.GetString(requestIDKey),
+	})
+	c.Data(status, problemContentType, b)
+	c.Abort()
+}
+
+// problemStatus - код ответа API v2 для ошибки app. В отличие от handleErr отсутствующие
+// объявления и пользователи дают 404, а не 500
+func problemStatus(err error) int {
+	switch {
+	case errors.Is(err, ads.ErrAdNotFound), errors.Is(err, users.ErrUserNotFound),
+		errors.Is(err, favorites.ErrSearchNotFound), errors.Is(err, messages.ErrThreadNotFound),
+		errors.Is(err, webhooks.ErrSubscriptionNotFound), errors.Is(err, webhooks.ErrDeliveryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, app.ValidationErr), errors.Is(err, app.VerificationErr):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, app.AccessErr):
+		return http.StatusForbidden
+	case errors.Is(err, users.ErrNicknameTaken), errors.Is(err, users.ErrEmailTaken):
+		return http.StatusConflict
+	case errors.Is(err, app.DisabledErr):
+		return http.StatusNotImplemented
+	case errors.Is(err, app.ThrottledErr):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,41 @@
+package httpgin
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/app"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	requestIDKey    = "request_id"
+)
+
+// RequestID берет идентификатор запроса из заголовка X-Request-ID или создает новый
+// и возвращает его клиенту в том же заголовке
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+
+		c.Set(requestIDKey, id)
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// withRequest возвращает app, который помечает записи аудита идентификатором текущего запроса
+func withRequest(c *gin.Context, a app.App) app.App {
+	return a.WithRequestID(c.GetString(requestIDKey))
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -5,17 +5,75 @@ import (
 	"homework10/internal/app"
 )
 
+//go:generate go run github.com/swaggo/swag/cmd/swag@v1.16.1 init -g router.go -o ../../../docs
+
+// AppRouter регистрирует маршруты API объявлений
+//
+//	@title			Ads API
+//	@version		1.0
+//	@description	Сервис объявлений. Все ответы завернуты в конверт {"data": ..., "error": ...}. Пользователь из user_id, author_id и actor_id не аутентифицируется, поэтому роли и права только рекомендательные: не открывайте API наружу без аутентификации перед ним
+//	@BasePath		/
 func AppRouter(r *gin.Engine, a app.App) {
-	r.POST("/api/v1/ads", createAd(a))                    // Метод для создания объявления (ad)
-	r.GET("/api/v1/ads", getAds(a))                       // Метод для получения списка всех объявлений (ad)
-	r.PUT("/api/v1/ads/:ad_id/status", changeAdStatus(a)) // Метод для изменения статуса объявления (опубликовано - Published = true или снято с публикации Published = false)
-	r.PUT("/api/v1/ads/:ad_id", updateAd(a))              // Метод для обновления текста(Text) или заголовка(Title) объявления
-	r.GET("api/v1/ads/:ad_id", getAdByID(a))              // Метод для получения объявления по его ID
-	r.GET("api/v1/ads/find/:title", getAdsByTitle(a))     // Метод для получения списка объявлений по их заголовку
-	r.GET("api/v1/ads/filter", getFilteredAds(a))         // Метод для получения списка отфильтрованных объявлений
-	r.POST("/api/v1/users", createUser(a))                // Метод для создания пользователя (user)
-	r.GET("/api/v1/users/:user_id", getUser(a))           // Метод для получения пользователя по id (user)
-	r.POST("/api/v1/users/:user_id", updateUser(a))       // Метод для изменения пользователя по id (user)
-	r.DELETE("/api/v1/users/:user_id", deleteUser(a))     // Метод для удаления пользователя по id (user)
-	r.DELETE("/api/v1/ads/:ad_id", deleteAd(a))           // Метод для удаления объявления по id (ad)
+	r.POST("/api/v1/ads", createAd(a))                                   // Метод для создания объявления (ad)
+	r.GET("/api/v1/ads", getAds(a))                                      // Метод для получения списка всех объявлений (ad)
+	r.PUT("/api/v1/ads/:ad_id/status", changeAdStatus(a))                // Метод для изменения статуса объявления (опубликовано - Published = true или снято с публикации Published = false)
+	r.PUT("/api/v1/ads/:ad_id", updateAd(a))                             // Метод для обновления текста(Text) или заголовка(Title) объявления
+	r.GET("api/v1/ads/:ad_id", getAdByID(a))                             // Метод для получения объявления по его ID
+	r.GET("api/v1/ads/find/:title", getAdsByTitle(a))                    // Метод для получения списка объявлений по их заголовку
+	r.GET("api/v1/ads/filter", getFilteredAds(a))                        // Метод для получения списка отфильтрованных объявлений
+	r.POST("/api/v1/users", createUser(a))                               // Метод для создания пользователя (user)
+	r.GET("/api/v1/users/:user_id", getUser(a))                          // Метод для получения пользователя по id (user)
+	r.POST("/api/v1/users/:user_id", updateUser(a))                      // Метод для изменения пользователя по id (user)
+	r.DELETE("/api/v1/users/:user_id", deleteUser(a))                    // Метод для удаления пользователя по id (user)
+	r.PUT("/api/v1/users/:user_id/role", setUserRole(a))                 // Метод для назначения роли пользователю (user)
+	r.POST("/api/v1/users/:user_id/verify", verifyEmail(a))              // Метод для подтверждения email пользователя (user)
+	r.POST("/api/v1/users/:user_id/verification", resendVerification(a)) // Метод для повторной отправки кода подтверждения (user)
+	r.DELETE("/api/v1/ads/:ad_id", deleteAd(a))                          // Метод для удаления объявления по id (ad)
+	r.PUT("/api/v1/ads/:ad_id/schedule", scheduleAd(a))                  // Метод для планирования публикации и снятия с публикации объявления (ad)
+
+	r.GET("/api/v1/moderation/ads", getModerationQueue(a))        // Метод получения очереди модерации
+	r.POST("/api/v1/moderation/ads/:ad_id/approve", approveAd(a)) // Метод для одобрения объявления модератором
+	r.POST("/api/v1/moderation/ads/:ad_id/reject", rejectAd(a))   // Метод для отклонения объявления модератором
+	r.GET("/api/v1/audit", getAuditLog(a))                        // Метод получения записей журнала аудита
+
+	r.GET("/api/v1/users/:user_id/favorites", getFavorites(a))              // Метод получения избранных объявлений
+	r.PUT("/api/v1/users/:user_id/favorites/:ad_id", addFavorite(a))        // Метод для добавления объявления в избранное
+	r.DELETE("/api/v1/users/:user_id/favorites/:ad_id", removeFavorite(a))  // Метод для удаления объявления из избранного
+	r.POST("/api/v1/users/:user_id/searches", saveSearch(a))                // Метод для сохранения поиска
+	r.GET("/api/v1/users/:user_id/searches", getSearches(a))                // Метод получения сохраненных поисков
+	r.DELETE("/api/v1/users/:user_id/searches/:search_id", deleteSearch(a)) // Метод для удаления сохраненного поиска
+	r.GET("/api/v1/users/:user_id/searches/:search_id/ads", runSearch(a))   // Метод для выполнения сохраненного поиска
+
+	r.POST("/api/v1/ads/:ad_id/messages", contactAuthor(a))                      // Метод для отправки сообщения автору объявления
+	r.GET("/api/v1/users/:user_id/threads", getThreads(a))                       // Метод получения переписок пользователя
+	r.GET("/api/v1/users/:user_id/threads/:thread_id/messages", getMessages(a))  // Метод получения сообщений переписки
+	r.POST("/api/v1/users/:user_id/threads/:thread_id/messages", sendMessage(a)) // Метод для отправки сообщения в переписку
+	r.POST("/api/v1/users/:user_id/threads/:thread_id/read", markThreadRead(a))  // Метод для отметки переписки прочитанной
+
+	r.POST("/api/v1/webhooks", createWebhook(a))                                      // Метод для подписки партнера на события объявлений
+	r.GET("/api/v1/webhooks", getWebhooks(a))                                         // Метод получения подписок на события
+	r.DELETE("/api/v1/webhooks/:webhook_id", deleteWebhook(a))                        // Метод для удаления подписки на события
+	r.GET("/api/v1/webhooks/deliveries", getWebhookDeliveries(a))                     // Метод получения доставок событий и их попыток
+	r.POST("/api/v1/webhooks/deliveries/:delivery_id/redeliver", redeliverWebhook(a)) // Метод для повторной отправки события
+
+	// gin не поддерживает двоеточие в статической части пути, поэтому ":import" и ":export" - параметры
+	r.POST("/api/v1/ads:import", customMethod("import", importAdsHandler(a))) // Метод для массового импорта объявлений (ad)
+	r.GET("/api/v1/ads:export", customMethod("export", exportAdsHandler(a)))  // Метод для массового экспорта объявлений (ad)
+	r.GET("/api/v1/audit:export", customMethod("export", exportAuditLog(a)))  // Метод для выгрузки журнала аудита
+}
+
+// AppRouterV2 регистрирует маршруты API v2: PATCH для частичных изменений, ID только в пути,
+// DELETE без тела и ошибки в формате application/problem+json
+func AppRouterV2(r *gin.Engine, a app.App) {
+	v2 := r.Group("/api/v2")
+
+	v2.POST("/ads", createAdV2(a))                // Метод для создания объявления (ad)
+	v2.GET("/ads", listAdsV2(a))                  // Метод для получения списка объявлений с фильтрами
+	v2.GET("/ads/:ad_id", getAdV2(a))             // Метод для получения объявления по его ID
+	v2.PATCH("/ads/:ad_id", patchAdV2(a))         // Метод для частичного изменения объявления
+	v2.DELETE("/ads/:ad_id", deleteAdV2(a))       // Метод для удаления объявления
+	v2.POST("/users", createUserV2(a))            // Метод для создания пользователя (user)
+	v2.GET("/users/:user_id", getUserV2(a))       // Метод для получения пользователя по id (user)
+	v2.PATCH("/users/:user_id", patchUserV2(a))   // Метод для частичного изменения пользователя (user)
+	v2.DELETE("/users/:user_id", deleteUserV2(a)) // Метод для удаления пользователя (user)
 }
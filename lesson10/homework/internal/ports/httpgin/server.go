@@ -4,6 +4,8 @@ import (
 	"context"
 	"github.com/gin-gonic/gin"
 	"homework10/internal/app"
+	"homework10/internal/idempotency"
+	"log"
 	"net/http"
 )
 
@@ -12,12 +14,39 @@ type Server struct {
 	app  *http.Server
 }
 
-func NewHTTPServer(port string, a app.App) Server {
+type Option func(o *options)
+
+type options struct {
+	keeper *idempotency.Keeper
+}
+
+// WithIdempotency включает заголовок Idempotency-Key для создания объявлений, пользователей и импорта
+func WithIdempotency(k *idempotency.Keeper) Option {
+	return func(o *options) {
+		o.keeper = k
+	}
+}
+
+func NewHTTPServer(port string, a app.App, opts ...Option) Server {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	gin.SetMode(gin.ReleaseMode)
 	handler := gin.New()
 	s := Server{port: port, app: &http.Server{Addr: port, Handler: handler}}
 
+	handler.Use(RequestID())
+	handler.Use(Deprecated("/api/v1/", "/api/v2"))
+	if o.keeper != nil {
+		handler.Use(Idempotency(o.keeper, idempotentRoutes...))
+	}
 	AppRouter(handler, a)
+	AppRouterV2(handler, a)
+	if err := SwaggerRouter(handler); err != nil {
+		log.Printf("can't register swagger: %s", err.Error())
+	}
 
 	return s
 }
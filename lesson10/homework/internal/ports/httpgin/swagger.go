@@ -0,0 +1,49 @@
+package httpgin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+
+	"homework10/docs"
+)
+
+const openAPIPath = "/openapi.json"
+
+// OpenAPI возвращает спецификацию API в формате OpenAPI 3,
+// сконвертированную из сгенерированной swag документации
+func OpenAPI() (*openapi3.T, error) {
+	var doc openapi2.T
+	if err := json.Unmarshal([]byte(docs.SwaggerInfo.ReadDoc()), &doc); err != nil {
+		return nil, err
+	}
+
+	return openapi2conv.ToV3(&doc)
+}
+
+// SwaggerRouter регистрирует Swagger UI и спецификацию OpenAPI 3 по пути /swagger/*
+func SwaggerRouter(r *gin.Engine) error {
+	spec, err := OpenAPI()
+	if err != nil {
+		return err
+	}
+
+	ui := ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("openapi.json"))
+
+	r.GET("/swagger/*any", func(c *gin.Context) {
+		if c.Param("any") == openAPIPath {
+			c.JSON(http.StatusOK, spec)
+			return
+		}
+
+		ui(c)
+	})
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/adapters/adcache"
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/usersrepo"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+)
+
+// countingRepo считает чтения и может задерживать их, чтобы промахи совпали по времени
+type countingRepo struct {
+	ads.Repository
+	reads int32
+	delay time.Duration
+}
+
+func (r *countingRepo) GetById(id int64) (ads.Ad, error) {
+	atomic.AddInt32(&r.reads, 1)
+	time.Sleep(r.delay)
+	return r.Repository.GetById(id)
+}
+
+func TestAdCacheReadThrough(t *testing.T) {
+	repo := &countingRepo{Repository: adrepo.New()}
+	cache := adcache.New(repo)
+	id := cache.AddAd(ads.Ad{Title: "hello", Text: "world"})
+
+	for i := 0; i < 3; i++ {
+		ad, err := cache.GetById(id)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", ad.Title)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&repo.reads))
+	assert.Equal(t, adcache.Stats{Hits: 2, Misses: 1, Size: 1}, cache.Stats())
+
+	// запись сбрасывает объявление
+	assert.NoError(t, cache.ReplaceByID(id, ads.Ad{Title: "updated", Text: "world"}))
+	ad, err := cache.GetById(id)
+	assert.NoError(t, err)
+	assert.Equal(t, "updated", ad.Title)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&repo.reads))
+
+	// ошибки не кешируются
+	_, err = cache.GetById(100)
+	assert.Error(t, err)
+	_, err = cache.GetById(100)
+	assert.Error(t, err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&repo.reads))
+}
+
+func TestAdCacheDeleteShiftsIDs(t *testing.T) {
+	cache := adcache.New(adrepo.New())
+	first := cache.AddAd(ads.Ad{Title: "first"})
+	second := cache.AddAd(ads.Ad{Title: "second"})
+
+	_, err := cache.GetById(second)
+	assert.NoError(t, err)
+
+	_, err = cache.DeleteByID(first)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, cache.Stats().Size)
+
+	ad, err := cache.GetById(first)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", ad.Title)
+	_, err = cache.GetById(second)
+	assert.Error(t, err)
+}
+
+func TestAdCacheEviction(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	repo := &countingRepo{Repository: adrepo.New()}
+	cache := adcache.New(repo, adcache.WithSize(2), adcache.WithTTL(time.Minute), adcache.WithNow(clock.Now))
+	for i := 0; i < 3; i++ {
+		cache.AddAd(ads.Ad{Title: "hello"})
+	}
+
+	// 0 и 1 в кеше, чтение 0 делает 1 самым старым, и его вытесняет 2
+	for _, id := range []int64{0, 1, 0, 2, 0} {
+		_, err := cache.GetById(id)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, adcache.Stats{Hits: 2, Misses: 3, Evictions: 1, Size: 2}, cache.Stats())
+
+	_, err := cache.GetById(1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), cache.Stats().Misses)
+
+	// истекшее объявление читается заново
+	clock.Add(time.Minute)
+	_, err = cache.GetById(1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), cache.Stats().Misses)
+	assert.Equal(t, int32(5), atomic.LoadInt32(&repo.reads))
+}
+
+func TestAdCacheSingleflight(t *testing.T) {
+	repo := &countingRepo{Repository: adrepo.New(), delay: 50 * time.Millisecond}
+	cache := adcache.New(repo)
+	id := cache.AddAd(ads.Ad{Title: "hello"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ad, err := cache.GetById(id)
+			assert.NoError(t, err)
+			assert.Equal(t, "hello", ad.Title)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&repo.reads))
+}
+
+func TestAppWithAdCache(t *testing.T) {
+	a := app.NewApp(adcache.New(adrepo.New()), usersrepo.New())
+	client := getTestClientWithApp(a)
+	ctx := context.Background()
+
+	ad, err := client.CreateAd(ctx, 123, "hello", "world")
+	assert.NoError(t, err)
+	_, err = client.ChangeAdStatus(ctx, 123, ad.ID, true)
+	assert.NoError(t, err)
+	_, err = client.GetAd(ctx, ad.ID)
+	assert.NoError(t, err)
+
+	_, err = client.UpdateAd(ctx, 123, ad.ID, "bye", "world")
+	assert.NoError(t, err)
+
+	got, err := client.GetAd(ctx, ad.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "bye", got.Title)
+}
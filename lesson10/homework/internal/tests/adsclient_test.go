@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/pkg/adsclient"
+)
+
+func TestClientUpdateUser(t *testing.T) {
+	client := getTestClient()
+	ctx := context.Background()
+
+	created, err := client.CreateUser(ctx, "danil", "mail@example.com", "password")
+	assert.NoError(t, err)
+
+	u, err := client.UpdateUser(ctx, created.ID, "oleg", "")
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, u.ID)
+	assert.Equal(t, "oleg", u.Nickname)
+	assert.Equal(t, "mail@example.com", u.Email)
+
+	_, err = client.UpdateUser(ctx, created.ID, "", "not an email")
+	assert.ErrorIs(t, err, adsclient.ErrBadRequest)
+}
+
+func TestClientAPIError(t *testing.T) {
+	client := getTestClient()
+	ctx := context.Background()
+
+	_, err := client.CreateAd(ctx, 123, "", "world")
+
+	var apiErr *adsclient.APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.NotEmpty(t, apiErr.Message)
+}
+
+func TestClientRetries(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[],"error":null}`))
+	}))
+	defer srv.Close()
+
+	client := adsclient.New(srv.URL,
+		adsclient.WithRetries(2),
+		adsclient.WithBackoff(time.Millisecond, 5*time.Millisecond))
+
+	ads, err := client.ListAds(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, ads, 0)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// POST не повторяется, чтобы не создать дубликат
+	atomic.StoreInt32(&calls, 0)
+	_, err = client.CreateAd(context.Background(), 123, "hello", "world")
+	assert.ErrorIs(t, err, adsclient.ErrInternal)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
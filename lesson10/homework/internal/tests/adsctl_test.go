@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gopkg.in/yaml.v3"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/usersrepo"
+	"homework10/internal/adsctl"
+	"homework10/internal/app"
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func getTestGRPCClient(t *testing.T) grpcPort.AdServiceClient {
+	return getTestGRPCClientWithApp(t, app.NewApp(adrepo.New(), usersrepo.New()))
+}
+
+func getTestGRPCClientWithApp(t *testing.T, a app.App, opts ...grpc.ServerOption) grpcPort.AdServiceClient {
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+
+	srv := grpc.NewServer(opts...)
+	t.Cleanup(func() {
+		srv.Stop()
+	})
+
+	grpcPort.RegisterAdServiceServer(srv, grpcPort.NewService(a))
+
+	go func() {
+		assert.NoError(t, srv.Serve(lis), "srv.Serve")
+	}()
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(cancel)
+
+	conn, err := grpc.DialContext(ctx, "", grpc.WithContextDialer(dialer),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err, "grpc.DialContext")
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	return grpcPort.NewAdServiceClient(conn)
+}
+
+func runAdsctl(client grpcPort.AdServiceClient, args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := adsctl.NewRootCmd(client)
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(args)
+
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestAdsctlAds(t *testing.T) {
+	client := getTestGRPCClient(t)
+
+	out, err := runAdsctl(client, "ads", "create", "--user", "1", "--title", "hello", "--text", "world", "-o", "json")
+	assert.NoError(t, err)
+
+	var ad map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(out), &ad))
+	assert.Equal(t, "hello", ad["title"])
+	assert.Equal(t, false, ad["published"])
+
+	_, err = runAdsctl(client, "ads", "publish", "0", "--user", "2")
+	assert.Error(t, err)
+
+	_, err = runAdsctl(client, "ads", "publish", "0", "--user", "1")
+	assert.NoError(t, err)
+
+	_, err = runAdsctl(client, "ads", "update", "0", "--user", "1", "--title", "best cat", "--text", "not for sale")
+	assert.NoError(t, err)
+
+	out, err = runAdsctl(client, "ads", "get", "0", "-o", "yaml")
+	assert.NoError(t, err)
+
+	var got struct {
+		Title     string `yaml:"title"`
+		Published bool   `yaml:"published"`
+	}
+	assert.NoError(t, yaml.Unmarshal([]byte(out), &got))
+	assert.Equal(t, "best cat", got.Title)
+	assert.True(t, got.Published)
+
+	out, err = runAdsctl(client, "ads", "search", "cat")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "best cat")
+
+	out, err = runAdsctl(client, "ads", "filter", "--author", "5", "-o", "json")
+	assert.NoError(t, err)
+	assert.JSONEq(t, "[]", out)
+
+	_, err = runAdsctl(client, "ads", "filter", "--date", "yesterday")
+	assert.Error(t, err)
+
+	out, err = runAdsctl(client, "ads", "list")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "PUBLISHED")
+	assert.Contains(t, out, "best cat")
+
+	_, err = runAdsctl(client, "ads", "delete", "0", "--user", "1")
+	assert.NoError(t, err)
+}
+
+func TestAdsctlUsers(t *testing.T) {
+	client := getTestGRPCClient(t)
+
+	out, err := runAdsctl(client, "users", "create", "--nickname", "danil", "--email", "mail@example.com",
+		"--password", "password")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "VERIFIED")
+
+	_, err = runAdsctl(client, "users", "create", "--nickname", "danil", "--email", "other@example.com",
+		"--password", "password")
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	out, err = runAdsctl(client, "users", "update", "1", "--nickname", "oleg", "-o", "json")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":1,"nickname":"oleg","email":"mail@example.com","role":"user","email_verified":false}`, out)
+
+	_, err = runAdsctl(client, "users", "verify", "1", "--token", "wrong")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = runAdsctl(client, "users", "delete", "1")
+	assert.NoError(t, err)
+
+	_, err = runAdsctl(client, "users", "get", "1")
+	assert.Error(t, err)
+
+	_, err = runAdsctl(client, "users", "get", "1", "-o", "xml")
+	assert.Error(t, err)
+}
+
+func TestAdsctlBulkCreate(t *testing.T) {
+	client := getTestGRPCClient(t)
+	dir := t.TempDir()
+
+	csvFile := filepath.Join(dir, "ads.csv")
+	assert.NoError(t, os.WriteFile(csvFile, []byte("user_id,title,text\n1,hello,world\n2,,empty title\n"), 0o600))
+
+	out, err := runAdsctl(client, "ads", "create", "-f", csvFile)
+	assert.Error(t, err)
+	assert.Contains(t, out, "line 3:")
+	assert.Contains(t, out, "hello")
+
+	jsonlFile := filepath.Join(dir, "users.jsonl")
+	assert.NoError(t, os.WriteFile(jsonlFile, []byte(
+		`{"nickname": "danil", "email": "a@example.com", "password": "password"}`+"\n"+
+			`{"nickname": "oleg", "email": "b@example.com", "password": "password"}`+"\n"), 0o600))
+
+	out, err = runAdsctl(client, "users", "create", "-f", jsonlFile, "-o", "json")
+	assert.NoError(t, err)
+
+	var users []map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(out), &users))
+	assert.Len(t, users, 2)
+}
+
+func TestAdsctlCompletion(t *testing.T) {
+	out, err := runAdsctl(nil, "completion", "bash")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "adsctl")
+}
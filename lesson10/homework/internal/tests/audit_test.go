@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/auditrepo"
+	"homework10/internal/adapters/usersrepo"
+	"homework10/internal/app"
+	"homework10/internal/audit"
+	"homework10/internal/ports/httpgin"
+	"homework10/pkg/adsclient"
+)
+
+func TestAuditLog(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)}
+	a := app.NewApp(adrepo.New(), usersrepo.New(), app.WithClock(clock),
+		app.WithAdmins(adminID), app.WithAuditLog(auditrepo.New()))
+
+	ad, err := a.CreateAd("hello", "world", 1)
+	assert.NoError(t, err)
+	_, err = a.WithRequestID("req-1").ChangeAdStatus(ad.ID, 1, true)
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(ad.ID, 2, false)
+	assert.ErrorIs(t, err, app.AccessErr)
+
+	clock.Add(time.Hour)
+	_, err = a.ScheduleAd(ad.ID, 1, time.Time{}, clock.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	clock.Add(time.Hour)
+	runSchedulerOnce(t, a)
+
+	_, err = a.AuditLog(1, audit.Filter{})
+	assert.ErrorIs(t, err, app.AccessErr)
+
+	entries, err := a.AuditLog(adminID, audit.Filter{Target: audit.Target("ad", ad.ID)})
+	assert.NoError(t, err)
+	assert.NoError(t, audit.Verify(entries))
+
+	// неудачные операции в журнал не попадают
+	assert.Equal(t, []string{"ad.create", "ad.publish", "ad.schedule", "ad.unpublish"},
+		[]string{entries[0].Action, entries[1].Action, entries[2].Action, entries[3].Action})
+	assert.Len(t, entries, 4)
+
+	assert.JSONEq(t, "null", string(entries[0].Before))
+	assert.Equal(t, "req-1", entries[1].RequestID)
+	assert.Contains(t, string(entries[1].Before), `"Published":false`)
+	assert.Contains(t, string(entries[1].After), `"Published":true`)
+	assert.Equal(t, audit.SystemActor, entries[3].ActorID)
+	assert.Equal(t, "system", entries[3].Role)
+
+	entries, err = a.AuditLog(adminID, audit.Filter{From: clock.Now().Add(-90 * time.Minute), To: clock.Now()})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "ad.schedule", entries[0].Action)
+}
+
+func TestAuditLogAfterDelete(t *testing.T) {
+	a := app.NewApp(adrepo.New(), usersrepo.New(), app.WithAdmins(adminID), app.WithAuditLog(auditrepo.New()))
+
+	first, err := a.CreateAd("first", "by author 1", 1)
+	assert.NoError(t, err)
+	second, err := a.CreateAd("second", "by author 2", 2)
+	assert.NoError(t, err)
+
+	_, err = a.DeleteAd(0, 1)
+	assert.NoError(t, err)
+
+	// после удаления объявление второго автора занимает место 0, но хранит прежний ID
+	ad, err := a.ChangeAdStatus(0, 2, true)
+	assert.NoError(t, err)
+	assert.Equal(t, second.ID, ad.ID)
+
+	// новое объявление не получает ID удаленного или существующего объявления
+	third, err := a.CreateAd("third", "by author 3", 3)
+	assert.NoError(t, err)
+	assert.NotContains(t, []int64{first.ID, second.ID}, third.ID)
+
+	list, err := a.GetAds()
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "second", list[0].Title)
+
+	history := func(id int64) []string {
+		entries, err := a.AuditLog(adminID, audit.Filter{Target: audit.Target("ad", id)})
+		assert.NoError(t, err)
+		res := make([]string, 0, len(entries))
+		for _, e := range entries {
+			res = append(res, e.Action)
+		}
+		return res
+	}
+
+	// история каждого объявления хранится отдельно
+	assert.Equal(t, []string{"ad.create", "ad.delete"}, history(first.ID))
+	assert.Equal(t, []string{"ad.create", "ad.publish"}, history(second.ID))
+	assert.Equal(t, []string{"ad.create"}, history(third.ID))
+}
+
+func TestAuditLogHashChain(t *testing.T) {
+	repo := auditrepo.New()
+	for _, action := range []string{"ad.create", "ad.update", "ad.delete"} {
+		_, err := repo.Append(audit.Entry{At: time.Now().UTC(), ActorID: 1, Action: action, Target: "ad:0",
+			Before: audit.Snapshot(nil), After: audit.Snapshot(nil)})
+		assert.NoError(t, err)
+	}
+
+	entries, err := repo.List(audit.Filter{})
+	assert.NoError(t, err)
+	assert.NoError(t, audit.Verify(entries))
+	assert.Equal(t, entries[0].Hash, entries[1].PrevHash)
+
+	tampered := append([]audit.Entry{}, entries...)
+	tampered[1].ActorID = 2
+	assert.ErrorIs(t, audit.Verify(tampered), audit.ErrBrokenChain)
+
+	assert.ErrorIs(t, audit.Verify(entries[1:]), audit.ErrBrokenChain)
+}
+
+func TestAuditLogHTTP(t *testing.T) {
+	a := app.NewApp(adrepo.New(), usersrepo.New(), app.WithAdmins(adminID), app.WithAuditLog(auditrepo.New()))
+	srv := httpgin.NewHTTPServer(":18080", a)
+	server := httptest.NewServer(srv.Handler())
+	defer server.Close()
+	client := adsclient.New(server.URL, adsclient.WithHTTPClient(server.Client()))
+	ctx := context.Background()
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/api/v1/users",
+		strings.NewReader(`{"nickname": "oleg", "email": "oleg@mail.ru", "password": "password"}`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-ID", "create-user-1")
+	resp, err := server.Client().Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "create-user-1", resp.Header.Get("X-Request-ID"))
+
+	_, err = client.CreateAd(ctx, 1, "hello", "world")
+	assert.NoError(t, err)
+
+	_, err = client.AuditLog(ctx, 1, adsclient.AuditFilter{})
+	assert.ErrorIs(t, err, adsclient.ErrForbidden)
+
+	entries, err := client.AuditLog(ctx, adminID, adsclient.AuditFilter{Target: "user:1"})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "user.create", entries[0].Action)
+	assert.Equal(t, "create-user-1", entries[0].RequestID)
+
+	body, err := client.ExportAuditLog(ctx, adminID, adsclient.AuditFilter{})
+	assert.NoError(t, err)
+	defer body.Close()
+
+	exported := make([]audit.Entry, 0)
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		var e audit.Entry
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		exported = append(exported, e)
+	}
+	assert.NoError(t, scanner.Err())
+	assert.Len(t, exported, 2)
+	assert.NotEmpty(t, exported[1].RequestID)
+	assert.NoError(t, audit.Verify(exported))
+}
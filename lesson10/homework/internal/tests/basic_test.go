@@ -1,223 +1,246 @@
 package tests
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"homework10/pkg/adsclient"
 )
 
 func TestCreateAd(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	response, err := client.createAd(123, "hello", "world")
+	response, err := client.CreateAd(ctx, 123, "hello", "world")
 	assert.NoError(t, err)
-	assert.Zero(t, response.Data.ID)
-	assert.Equal(t, response.Data.Title, "hello")
-	assert.Equal(t, response.Data.Text, "world")
-	assert.Equal(t, response.Data.AuthorID, int64(123))
-	assert.False(t, response.Data.Published)
+	assert.Zero(t, response.ID)
+	assert.Equal(t, response.Title, "hello")
+	assert.Equal(t, response.Text, "world")
+	assert.Equal(t, response.AuthorID, int64(123))
+	assert.False(t, response.Published)
 }
 
 func TestChangeAdStatus(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	response, err := client.createAd(123, "hello", "world")
+	response, err := client.CreateAd(ctx, 123, "hello", "world")
 	assert.NoError(t, err)
 
-	response, err = client.changeAdStatus(123, response.Data.ID, true)
+	response, err = client.ChangeAdStatus(ctx, 123, response.ID, true)
 	assert.NoError(t, err)
-	assert.True(t, response.Data.Published)
+	assert.True(t, response.Published)
 
-	response, err = client.changeAdStatus(123, response.Data.ID, false)
+	response, err = client.ChangeAdStatus(ctx, 123, response.ID, false)
 	assert.NoError(t, err)
-	assert.False(t, response.Data.Published)
+	assert.False(t, response.Published)
 
-	response, err = client.changeAdStatus(123, response.Data.ID, false)
+	response, err = client.ChangeAdStatus(ctx, 123, response.ID, false)
 	assert.NoError(t, err)
-	assert.False(t, response.Data.Published)
+	assert.False(t, response.Published)
 }
 
 func TestUpdateAd(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	response, err := client.createAd(123, "hello", "world")
+	response, err := client.CreateAd(ctx, 123, "hello", "world")
 	assert.NoError(t, err)
 
-	response, err = client.updateAd(123, response.Data.ID, "привет", "мир")
+	response, err = client.UpdateAd(ctx, 123, response.ID, "привет", "мир")
 	assert.NoError(t, err)
-	assert.Equal(t, response.Data.Title, "привет")
-	assert.Equal(t, response.Data.Text, "мир")
+	assert.Equal(t, response.Title, "привет")
+	assert.Equal(t, response.Text, "мир")
 }
 
 func TestListAds(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	response, err := client.createAd(123, "hello", "world")
+	response, err := client.CreateAd(ctx, 123, "hello", "world")
 	assert.NoError(t, err)
 
-	publishedAd, err := client.changeAdStatus(123, response.Data.ID, true)
+	publishedAd, err := client.ChangeAdStatus(ctx, 123, response.ID, true)
 	assert.NoError(t, err)
 
-	_, err = client.createAd(123, "best cat", "not for sale")
+	_, err = client.CreateAd(ctx, 123, "best cat", "not for sale")
 	assert.NoError(t, err)
 
-	ads, err := client.listAds()
+	ads, err := client.ListAds(ctx)
 	assert.NoError(t, err)
-	assert.Len(t, ads.Data, 1)
-	assert.Equal(t, ads.Data[0].ID, publishedAd.Data.ID)
-	assert.Equal(t, ads.Data[0].Title, publishedAd.Data.Title)
-	assert.Equal(t, ads.Data[0].Text, publishedAd.Data.Text)
-	assert.Equal(t, ads.Data[0].AuthorID, publishedAd.Data.AuthorID)
-	assert.True(t, ads.Data[0].Published)
+	assert.Len(t, ads, 1)
+	assert.Equal(t, ads[0].ID, publishedAd.ID)
+	assert.Equal(t, ads[0].Title, publishedAd.Title)
+	assert.Equal(t, ads[0].Text, publishedAd.Text)
+	assert.Equal(t, ads[0].AuthorID, publishedAd.AuthorID)
+	assert.True(t, ads[0].Published)
 }
 
 func TestGetAdByID(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	response, err := client.createAd(123, "hello", "world")
+	response, err := client.CreateAd(ctx, 123, "hello", "world")
 	assert.NoError(t, err)
 
-	publishedAd, err := client.changeAdStatus(123, response.Data.ID, true)
+	publishedAd, err := client.ChangeAdStatus(ctx, 123, response.ID, true)
 	assert.NoError(t, err)
 
-	ad, err := client.getAdByID(0)
+	ad, err := client.GetAd(ctx, 0)
 	assert.NoError(t, err)
-	assert.Equal(t, ad.Data.ID, publishedAd.Data.ID)
-	assert.Equal(t, ad.Data.Title, publishedAd.Data.Title)
-	assert.Equal(t, ad.Data.Text, publishedAd.Data.Text)
-	assert.Equal(t, ad.Data.AuthorID, publishedAd.Data.AuthorID)
-	assert.True(t, ad.Data.Published)
+	assert.Equal(t, ad.ID, publishedAd.ID)
+	assert.Equal(t, ad.Title, publishedAd.Title)
+	assert.Equal(t, ad.Text, publishedAd.Text)
+	assert.Equal(t, ad.AuthorID, publishedAd.AuthorID)
+	assert.True(t, ad.Published)
 
-	_, err = client.createAd(123, "best cat", "not for sale")
+	_, err = client.CreateAd(ctx, 123, "best cat", "not for sale")
 	assert.NoError(t, err)
 
-	_, err = client.getAdByID(1)
-	assert.Equal(t, err, ErrForbidden)
+	_, err = client.GetAd(ctx, 1)
+	assert.ErrorIs(t, err, adsclient.ErrForbidden)
 }
 
 func TestGetAdsByTitle(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	response, err := client.createAd(123, "best", "world")
+	response, err := client.CreateAd(ctx, 123, "best", "world")
 	assert.NoError(t, err)
 
-	_, err = client.createAd(123, "qq", "danil")
+	_, err = client.CreateAd(ctx, 123, "qq", "danil")
 	assert.NoError(t, err)
 
-	publishedAd, err := client.changeAdStatus(123, response.Data.ID, true)
+	publishedAd, err := client.ChangeAdStatus(ctx, 123, response.ID, true)
 	assert.NoError(t, err)
 
-	_, err = client.createAd(123, "best cat", "not for sale")
+	_, err = client.CreateAd(ctx, 123, "best cat", "not for sale")
 	assert.NoError(t, err)
 
-	ads, err := client.getAdsByTitle("best")
+	ads, err := client.FindAds(ctx, "best")
 	assert.NoError(t, err)
-	assert.Len(t, ads.Data, 1)
-	assert.Equal(t, ads.Data[0].ID, publishedAd.Data.ID)
-	assert.Equal(t, ads.Data[0].Title, publishedAd.Data.Title)
-	assert.Equal(t, ads.Data[0].Text, publishedAd.Data.Text)
-	assert.Equal(t, ads.Data[0].AuthorID, publishedAd.Data.AuthorID)
-	assert.True(t, ads.Data[0].Published)
+	assert.Len(t, ads, 1)
+	assert.Equal(t, ads[0].ID, publishedAd.ID)
+	assert.Equal(t, ads[0].Title, publishedAd.Title)
+	assert.Equal(t, ads[0].Text, publishedAd.Text)
+	assert.Equal(t, ads[0].AuthorID, publishedAd.AuthorID)
+	assert.True(t, ads[0].Published)
 
-	ads, err = client.getAdsByTitle("some title")
+	ads, err = client.FindAds(ctx, "some title")
 	assert.NoError(t, err)
-	assert.Len(t, ads.Data, 0)
+	assert.Len(t, ads, 0)
 
-	ads, err = client.getAdsByTitle("qq")
+	ads, err = client.FindAds(ctx, "qq")
 	assert.NoError(t, err)
-	assert.Len(t, ads.Data, 0)
+	assert.Len(t, ads, 0)
 }
 
 func TestFilteredAds(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	response, err := client.createAd(123, "best", "world")
+	response, err := client.CreateAd(ctx, 123, "best", "world")
 	assert.NoError(t, err)
 
-	_, err = client.createAd(123, "qq", "danil")
+	_, err = client.CreateAd(ctx, 123, "qq", "danil")
 	assert.NoError(t, err)
 
-	_, err = client.changeAdStatus(123, response.Data.ID, true)
+	_, err = client.ChangeAdStatus(ctx, 123, response.ID, true)
 	assert.NoError(t, err)
 
-	_, err = client.createAd(123, "best cat", "not for sale")
+	_, err = client.CreateAd(ctx, 123, "best cat", "not for sale")
 	assert.NoError(t, err)
 
-	_, err = client.createAd(5, "test ad", "some text")
+	_, err = client.CreateAd(ctx, 5, "test ad", "some text")
 	assert.NoError(t, err)
 
-	r, err := client.getFilteredAds(1, -1, "")
+	r, err := client.FilterAds(ctx, adsclient.AdFilter{OnlyPublished: true})
 	assert.NoError(t, err)
-	assert.Len(t, r.Data, 1)
+	assert.Len(t, r, 1)
 
-	r, err = client.getFilteredAds(-1, -1, "")
+	r, err = client.FilterAds(ctx, adsclient.AdFilter{})
 	assert.NoError(t, err)
-	assert.Len(t, r.Data, 4)
+	assert.Len(t, r, 4)
 
-	r, err = client.getFilteredAds(-1, -1, time.Now().UTC().Format(time.DateOnly))
+	r, err = client.FilterAds(ctx, adsclient.AdFilter{CreatedOn: time.Now().UTC()})
 	assert.NoError(t, err)
-	assert.Len(t, r.Data, 4)
+	assert.Len(t, r, 4)
 
-	r, err = client.getFilteredAds(-1, -1, "2004-04-23")
+	r, err = client.FilterAds(ctx, adsclient.AdFilter{CreatedOn: time.Date(2004, time.April, 23, 0, 0, 0, 0, time.UTC)})
 	assert.NoError(t, err)
-	assert.Len(t, r.Data, 0)
+	assert.Len(t, r, 0)
 
-	r, err = client.getFilteredAds(-1, 5, "")
+	authorID := int64(5)
+	r, err = client.FilterAds(ctx, adsclient.AdFilter{AuthorID: &authorID})
 	assert.NoError(t, err)
-	assert.Len(t, r.Data, 1)
+	assert.Len(t, r, 1)
 }
 
 func TestCreateUser(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	response, err := client.createUser(123, "danil", "mail@example.com")
+	response, err := client.CreateUser(ctx, "danil", "mail@example.com", "password")
 	assert.NoError(t, err)
-	assert.Equal(t, response.Data.Nickname, "danil")
-	assert.Equal(t, response.Data.Email, "mail@example.com")
+	assert.Equal(t, response.Nickname, "danil")
+	assert.Equal(t, response.Email, "mail@example.com")
+	assert.False(t, response.EmailVerified)
+
+	_, err = client.CreateUser(ctx, "danil", "other@example.com", "password")
+	assert.ErrorIs(t, err, adsclient.ErrConflict)
+	_, err = client.CreateUser(ctx, "oleg", "MAIL@example.com", "password")
+	assert.ErrorIs(t, err, adsclient.ErrConflict)
+	_, err = client.CreateUser(ctx, "oleg", "oleg@example.com", "short")
+	assert.ErrorIs(t, err, adsclient.ErrBadRequest)
 }
 
 func TestGetUser(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	_, err := client.createUser(123, "danil", "mail@example.com")
+	u, err := client.CreateUser(ctx, "danil", "mail@example.com", "password")
 	assert.NoError(t, err)
 
-	response, err := client.getUser(123)
+	response, err := client.GetUser(ctx, u.ID)
 	assert.NoError(t, err)
-	assert.Equal(t, response.Data.Email, "mail@example.com")
-	assert.Equal(t, response.Data.Nickname, "danil")
+	assert.Equal(t, response.Email, "mail@example.com")
+	assert.Equal(t, response.Nickname, "danil")
 }
 
 func TestDeleteUser(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	_, err := client.createUser(123, "danil", "mail@example.com")
+	u, err := client.CreateUser(ctx, "danil", "mail@example.com", "password")
 	assert.NoError(t, err)
 
-	response, err := client.deleteUser(123)
+	response, err := client.DeleteUser(ctx, u.ID)
 	assert.NoError(t, err)
-	assert.Equal(t, response.Data.Email, "mail@example.com")
-	assert.Equal(t, response.Data.Nickname, "danil")
+	assert.Equal(t, response.Email, "mail@example.com")
+	assert.Equal(t, response.Nickname, "danil")
 
-	_, err = client.getUser(123)
+	_, err = client.GetUser(ctx, u.ID)
 	assert.Error(t, err)
 }
 
 func TestDeleteAd(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	_, err := client.createUser(123, "danil", "mail@example.com")
+	u, err := client.CreateUser(ctx, "danil", "mail@example.com", "password")
 	assert.NoError(t, err)
 
-	response, err := client.createAd(123, "best", "world")
+	response, err := client.CreateAd(ctx, u.ID, "best", "world")
 	assert.NoError(t, err)
 
-	response, err = client.deleteAd(response.Data.ID, 123)
+	response, err = client.DeleteAd(ctx, response.ID, u.ID)
 	assert.NoError(t, err)
-	assert.Equal(t, response.Data.Title, "best")
-	assert.Equal(t, response.Data.Text, "world")
+	assert.Equal(t, response.Title, "best")
+	assert.Equal(t, response.Text, "world")
 
-	_, err = client.getAdByID(1)
+	_, err = client.GetAd(ctx, 1)
 	assert.Error(t, err)
 }
@@ -2,6 +2,7 @@ package tests
 
 import (
 	"context"
+	"fmt"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"homework10/internal/adapters/usersrepo"
@@ -56,10 +57,11 @@ func BenchmarkGRPCCreateUser(b *testing.B) {
 	client := grpcPort.NewAdServiceClient(conn)
 
 	for i := 0; i < b.N; i++ {
-		res, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg", Id: int64(i), Email: "email@exmple.com"})
+		res, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: fmt.Sprintf("Oleg%d", i),
+			Email: fmt.Sprintf("email%d@exmple.com", i), Password: "password"})
 		assert.NoError(b, err, "client.GetUser")
 
-		assert.Equal(b, "Oleg", res.Name)
+		assert.Equal(b, fmt.Sprintf("Oleg%d", i), res.Name)
 	}
 
 }
@@ -326,17 +328,18 @@ func BenchmarkGRPCGetUser(b *testing.B) {
 	})
 
 	client := grpcPort.NewAdServiceClient(conn)
-	res, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg", Id: 15, Email: "email@exmple.com"})
+	res, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg", Email: "email@exmple.com", Password: "password"})
 	assert.NoError(b, err, "client.CreateUser")
 
 	assert.Equal(b, "Oleg", res.Name)
 
+	id := res.Id
 	for i := 0; i < b.N; i++ {
-		res, err = client.GetUser(ctx, &grpcPort.GetUserRequest{Id: 15})
+		res, err = client.GetUser(ctx, &grpcPort.GetUserRequest{Id: id})
 		assert.NoError(b, err, "client.GetUser")
 
 		assert.Equal(b, "Oleg", res.Name)
-		assert.Equal(b, int64(15), res.Id)
+		assert.Equal(b, id, res.Id)
 	}
 
 }
@@ -378,16 +381,18 @@ func BenchmarkGRPCDeleteUser(b *testing.B) {
 
 	client := grpcPort.NewAdServiceClient(conn)
 
+	ids := make([]int64, 0, b.N)
 	for i := 0; i < b.N; i++ {
-		_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{
-			Name:  "Oleg",
-			Id:    int64(i),
-			Email: "email@exmple.com"})
+		res, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{
+			Name:     fmt.Sprintf("Oleg%d", i),
+			Email:    fmt.Sprintf("email%d@exmple.com", i),
+			Password: "password"})
 		assert.NoError(b, err, "client.CreateUser")
+		ids = append(ids, res.GetId())
 	}
 
-	for i := 0; i < b.N; i++ {
-		_, err = client.DeleteUser(ctx, &grpcPort.DeleteUserRequest{Id: int64(i)})
+	for _, id := range ids {
+		_, err = client.DeleteUser(ctx, &grpcPort.DeleteUserRequest{Id: id})
 		assert.NoError(b, err, "client.DeleteUser")
 	}
 
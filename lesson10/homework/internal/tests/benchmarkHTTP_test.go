@@ -1,7 +1,10 @@
 package tests
 
 import (
+	"context"
+	"fmt"
 	"github.com/stretchr/testify/assert"
+	"homework10/pkg/adsclient"
 	"strconv"
 	"testing"
 	"time"
@@ -9,168 +12,179 @@ import (
 
 func BenchmarkCreateAd(b *testing.B) {
 	client := getTestClient()
+	ctx := context.Background()
 
 	for i := 0; i < b.N; i++ {
-		response, err := client.createAd(int64(i), "hello", "world")
+		response, err := client.CreateAd(ctx, int64(i), "hello", "world")
 		assert.NoError(b, err)
-		assert.Equal(b, int64(i), response.Data.ID)
-		assert.Equal(b, response.Data.Title, "hello")
-		assert.Equal(b, response.Data.Text, "world")
-		assert.Equal(b, response.Data.AuthorID, int64(i))
-		assert.False(b, response.Data.Published)
+		assert.Equal(b, int64(i), response.ID)
+		assert.Equal(b, response.Title, "hello")
+		assert.Equal(b, response.Text, "world")
+		assert.Equal(b, response.AuthorID, int64(i))
+		assert.False(b, response.Published)
 	}
 }
 
 func BenchmarkChangeAdStatus(b *testing.B) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	response, err := client.createAd(123, "hello", "world")
+	response, err := client.CreateAd(ctx, 123, "hello", "world")
 	assert.NoError(b, err)
 
 	for i := 0; i < b.N; i++ {
-		response, err = client.changeAdStatus(123, response.Data.ID, true)
+		response, err = client.ChangeAdStatus(ctx, 123, response.ID, true)
 		assert.NoError(b, err)
-		assert.True(b, response.Data.Published)
+		assert.True(b, response.Published)
 	}
 }
 
 func BenchmarkUpdateAd(b *testing.B) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	response, err := client.createAd(123, "hello", "world")
+	response, err := client.CreateAd(ctx, 123, "hello", "world")
 	assert.NoError(b, err)
 
 	for i := 0; i < b.N; i++ {
 		s := strconv.Itoa(i)
-		response, err = client.updateAd(123, response.Data.ID, "привет"+s, "мир")
+		response, err = client.UpdateAd(ctx, 123, response.ID, "привет"+s, "мир")
 		assert.NoError(b, err)
-		assert.Equal(b, response.Data.Title, "привет"+s)
-		assert.Equal(b, response.Data.Text, "мир")
+		assert.Equal(b, response.Title, "привет"+s)
+		assert.Equal(b, response.Text, "мир")
 	}
 }
 
 func BenchmarkListAds(b *testing.B) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	response, err := client.createAd(int64(11), "hello", "world")
+	response, err := client.CreateAd(ctx, int64(11), "hello", "world")
 	assert.NoError(b, err)
 
-	_, err = client.changeAdStatus(int64(11), response.Data.ID, true)
+	_, err = client.ChangeAdStatus(ctx, int64(11), response.ID, true)
 	assert.NoError(b, err)
 
-	_, err = client.createAd(int64(11), "best cat", "not for sale")
+	_, err = client.CreateAd(ctx, int64(11), "best cat", "not for sale")
 	assert.NoError(b, err)
 
 	for i := 0; i < b.N; i++ {
-		ads, err := client.listAds()
+		ads, err := client.ListAds(ctx)
 		assert.NoError(b, err)
-		assert.True(b, ads.Data[0].Published)
+		assert.True(b, ads[0].Published)
 	}
 }
 
 func BenchmarkGetAdByID(b *testing.B) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	response, err := client.createAd(123, "hello", "world")
+	response, err := client.CreateAd(ctx, 123, "hello", "world")
 	assert.NoError(b, err)
 
-	publishedAd, err := client.changeAdStatus(123, response.Data.ID, true)
+	publishedAd, err := client.ChangeAdStatus(ctx, 123, response.ID, true)
 	assert.NoError(b, err)
 
 	for i := 0; i < b.N; i++ {
-		ad, err := client.getAdByID(0)
+		ad, err := client.GetAd(ctx, 0)
 		assert.NoError(b, err)
-		assert.Equal(b, ad.Data.ID, publishedAd.Data.ID)
-		assert.Equal(b, ad.Data.Title, publishedAd.Data.Title)
-		assert.Equal(b, ad.Data.Text, publishedAd.Data.Text)
-		assert.Equal(b, ad.Data.AuthorID, publishedAd.Data.AuthorID)
-		assert.True(b, ad.Data.Published)
+		assert.Equal(b, ad.ID, publishedAd.ID)
+		assert.Equal(b, ad.Title, publishedAd.Title)
+		assert.Equal(b, ad.Text, publishedAd.Text)
+		assert.Equal(b, ad.AuthorID, publishedAd.AuthorID)
+		assert.True(b, ad.Published)
 	}
 
 }
 
 func BenchmarkGetAdsByTitle(b *testing.B) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	response, err := client.createAd(123, "best", "world")
+	response, err := client.CreateAd(ctx, 123, "best", "world")
 	assert.NoError(b, err)
 
-	_, err = client.createAd(123, "qq", "danil")
+	_, err = client.CreateAd(ctx, 123, "qq", "danil")
 	assert.NoError(b, err)
 
-	publishedAd, err := client.changeAdStatus(123, response.Data.ID, true)
+	publishedAd, err := client.ChangeAdStatus(ctx, 123, response.ID, true)
 	assert.NoError(b, err)
 
-	_, err = client.createAd(123, "best cat", "not for sale")
+	_, err = client.CreateAd(ctx, 123, "best cat", "not for sale")
 	assert.NoError(b, err)
 
 	for i := 0; i < b.N; i++ {
-		ads, err := client.getAdsByTitle("best")
+		ads, err := client.FindAds(ctx, "best")
 		assert.NoError(b, err)
-		assert.Len(b, ads.Data, 1)
-		assert.Equal(b, ads.Data[0].ID, publishedAd.Data.ID)
-		assert.Equal(b, ads.Data[0].Title, publishedAd.Data.Title)
-		assert.Equal(b, ads.Data[0].Text, publishedAd.Data.Text)
-		assert.Equal(b, ads.Data[0].AuthorID, publishedAd.Data.AuthorID)
-		assert.True(b, ads.Data[0].Published)
+		assert.Len(b, ads, 1)
+		assert.Equal(b, ads[0].ID, publishedAd.ID)
+		assert.Equal(b, ads[0].Title, publishedAd.Title)
+		assert.Equal(b, ads[0].Text, publishedAd.Text)
+		assert.Equal(b, ads[0].AuthorID, publishedAd.AuthorID)
+		assert.True(b, ads[0].Published)
 	}
 }
 
 func BenchmarkFilteredAds(b *testing.B) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	response, err := client.createAd(123, "best", "world")
+	response, err := client.CreateAd(ctx, 123, "best", "world")
 	assert.NoError(b, err)
 
-	_, err = client.createAd(123, "qq", "danil")
+	_, err = client.CreateAd(ctx, 123, "qq", "danil")
 	assert.NoError(b, err)
 
-	_, err = client.changeAdStatus(123, response.Data.ID, true)
+	_, err = client.ChangeAdStatus(ctx, 123, response.ID, true)
 	assert.NoError(b, err)
 
-	_, err = client.createAd(123, "best cat", "not for sale")
+	_, err = client.CreateAd(ctx, 123, "best cat", "not for sale")
 	assert.NoError(b, err)
 
-	_, err = client.createAd(5, "test ad", "some text")
+	_, err = client.CreateAd(ctx, 5, "test ad", "some text")
 	assert.NoError(b, err)
 
 	for i := 0; i < b.N; i++ {
-		r, err := client.getFilteredAds(-1, -1, time.Now().UTC().Format(time.DateOnly))
+		r, err := client.FilterAds(ctx, adsclient.AdFilter{CreatedOn: time.Now().UTC()})
 		assert.NoError(b, err)
-		assert.Len(b, r.Data, 4)
+		assert.Len(b, r, 4)
 	}
 }
 
 func BenchmarkCreateUser(b *testing.B) {
 	client := getTestClient()
+	ctx := context.Background()
 
 	for i := 0; i < b.N; i++ {
-		response, err := client.createUser(int64(i), "danil", "mail@example.com")
+		nickname, email := fmt.Sprintf("danil%d", i), fmt.Sprintf("mail%d@example.com", i)
+		response, err := client.CreateUser(ctx, nickname, email, "password")
 		assert.NoError(b, err)
-		assert.Equal(b, response.Data.Nickname, "danil")
-		assert.Equal(b, response.Data.Email, "mail@example.com")
+		assert.Equal(b, response.Nickname, nickname)
+		assert.Equal(b, response.Email, email)
 	}
 }
 
 func BenchmarkGetUser(b *testing.B) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	_, err := client.createUser(123, "danil", "mail@example.com")
+	u, err := client.CreateUser(ctx, "danil", "mail@example.com", "password")
 	assert.NoError(b, err)
 
 	for i := 0; i < b.N; i++ {
-		response, err := client.getUser(123)
+		response, err := client.GetUser(ctx, u.ID)
 		assert.NoError(b, err)
-		assert.Equal(b, response.Data.Email, "mail@example.com")
-		assert.Equal(b, response.Data.Nickname, "danil")
+		assert.Equal(b, response.Email, "mail@example.com")
+		assert.Equal(b, response.Nickname, "danil")
 	}
 }
 
 func BenchmarkDeleteUser(b *testing.B) {
 	client := getTestClient()
+	ctx := context.Background()
 
 	for i := 0; i < b.N; i++ {
-		_, err := client.deleteUser(int64(i))
+		_, err := client.DeleteUser(ctx, int64(i))
 		assert.Error(b, err)
 	}
 
@@ -178,15 +192,16 @@ func BenchmarkDeleteUser(b *testing.B) {
 
 func BenchmarkDeleteAd(b *testing.B) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	_, err := client.createUser(123, "danil", "mail@example.com")
+	u, err := client.CreateUser(ctx, "danil", "mail@example.com", "password")
 	assert.NoError(b, err)
 
-	_, err = client.createAd(123, "best", "world")
+	_, err = client.CreateAd(ctx, u.ID, "best", "world")
 	assert.NoError(b, err)
 
 	for i := 1; i < b.N; i++ {
-		_, err = client.deleteAd(int64(i), 123)
+		_, err = client.DeleteAd(ctx, int64(i), u.ID)
 		assert.Error(b, err)
 	}
 }
@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/usersrepo"
+	"homework10/internal/app"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/pkg/adsclient"
+)
+
+func TestImportAdsJSONL(t *testing.T) {
+	client := getTestClient()
+	ctx := context.Background()
+
+	body := `{"title": "hello", "text": "world", "user_id": 1}
+{"title": "", "text": "empty title", "user_id": 1}
+not a json
+
+{"title": "no author", "text": "text"}
+{"title": "best cat", "text": "not for sale", "author_id": 2}
+`
+
+	res, err := client.ImportAds(ctx, strings.NewReader(body), adsclient.FormatJSONL, true)
+	assert.NoError(t, err)
+	assert.True(t, res.DryRun)
+	assert.Equal(t, int64(5), res.Total)
+	assert.Equal(t, int64(2), res.Imported)
+	assert.Equal(t, int64(3), res.Failed)
+	assert.Equal(t, []int64{2, 3, 5}, []int64{res.Errors[0].Line, res.Errors[1].Line, res.Errors[2].Line})
+
+	ads, err := client.FilterAds(ctx, adsclient.AdFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, ads, 0)
+
+	res, err = client.ImportAds(ctx, strings.NewReader(body), adsclient.FormatJSONL, false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), res.Imported)
+
+	ads, err = client.FilterAds(ctx, adsclient.AdFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, ads, 2)
+	assert.Equal(t, "best cat", ads[1].Title)
+	assert.Equal(t, int64(2), ads[1].AuthorID)
+}
+
+func TestImportAdsCSV(t *testing.T) {
+	client := getTestClient()
+	ctx := context.Background()
+
+	body := "title,text,user_id\nhello,world,1\n\"multi\nline\",text,2\n" + strings.Repeat("a", 100) + ",text,3\nbad,id,x\n"
+
+	res, err := client.ImportAds(ctx, strings.NewReader(body), adsclient.FormatCSV, false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), res.Total)
+	assert.Equal(t, int64(2), res.Imported)
+	assert.Equal(t, []adsclient.ImportError{
+		{Line: 5, Error: "some fields does not pass the validation"},
+		{Line: 6, Error: `user_id: strconv.ParseInt: parsing "x": invalid syntax`},
+	}, res.Errors)
+
+	_, err = client.ImportAds(ctx, strings.NewReader(""), adsclient.FormatCSV, false)
+	assert.ErrorIs(t, err, adsclient.ErrBadRequest)
+
+	_, err = client.ImportAds(ctx, strings.NewReader(""), adsclient.Format("xml"), false)
+	assert.ErrorIs(t, err, adsclient.ErrBadRequest)
+}
+
+func TestExportAds(t *testing.T) {
+	client := getTestClientWithApp(app.NewApp(adrepo.New(), usersrepo.New(), app.WithAdmins(adminID)))
+	ctx := context.Background()
+
+	ad, err := client.CreateAd(ctx, 1, "hello", "world")
+	assert.NoError(t, err)
+	_, err = client.ChangeAdStatus(ctx, 1, ad.ID, true)
+	assert.NoError(t, err)
+	_, err = client.CreateAd(ctx, 2, "best, cat", "not for sale")
+	assert.NoError(t, err)
+
+	exported := func(c *adsclient.Client) []map[string]any {
+		r, err := c.ExportAds(ctx, adsclient.FormatJSONL)
+		assert.NoError(t, err)
+		defer r.Close()
+
+		var res []map[string]any
+		sc := bufio.NewScanner(r)
+		for sc.Scan() {
+			var ad map[string]any
+			assert.NoError(t, json.Unmarshal(sc.Bytes(), &ad))
+			assert.Contains(t, ad, "create_date")
+			res = append(res, ad)
+		}
+		return res
+	}
+
+	// неопубликованное объявление видно только администратору
+	ads := exported(client)
+	assert.Len(t, ads, 1)
+	assert.Equal(t, "hello", ads[0]["title"])
+	assert.Len(t, exported(client.As(2)), 1)
+	assert.Len(t, exported(client.As(adminID)), 2)
+
+	r, err := client.As(adminID).ExportAds(ctx, adsclient.FormatCSV)
+	assert.NoError(t, err)
+	rows, err := csv.NewReader(r).ReadAll()
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+	assert.Len(t, rows, 3)
+	assert.Equal(t, "best, cat", rows[2][1])
+
+	// экспорт можно импортировать обратно
+	r, err = client.As(adminID).ExportAds(ctx, adsclient.FormatCSV)
+	assert.NoError(t, err)
+	res, err := client.ImportAds(ctx, r, adsclient.FormatCSV, true)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+	assert.Equal(t, int64(2), res.Imported)
+
+	_, err = client.ExportAds(ctx, adsclient.Format("xml"))
+	assert.ErrorIs(t, err, adsclient.ErrBadRequest)
+}
+
+func TestGRPCImportAds(t *testing.T) {
+	client := getTestGRPCClient(t)
+	ctx := context.Background()
+
+	stream, err := client.ImportAds(ctx)
+	assert.NoError(t, err)
+
+	assert.NoError(t, stream.Send(&grpcPort.ImportAdRequest{Title: "hello", Text: "world", UserId: 1}))
+	assert.NoError(t, stream.Send(&grpcPort.ImportAdRequest{Title: "", Text: "world", UserId: 1}))
+	assert.NoError(t, stream.Send(&grpcPort.ImportAdRequest{Title: "best cat", Text: "not for sale", UserId: 2}))
+
+	res, err := stream.CloseAndRecv()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), res.Total)
+	assert.Equal(t, int64(2), res.Imported)
+	assert.Len(t, res.Errors, 1)
+	assert.Equal(t, int64(2), res.Errors[0].Line)
+
+	list, err := client.FilterAds(ctx, &grpcPort.FilterAdsRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, list.List, 2)
+
+	stream, err = client.ImportAds(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, stream.Send(&grpcPort.ImportAdRequest{Title: "dry", Text: "run", UserId: 1, DryRun: true}))
+	res, err = stream.CloseAndRecv()
+	assert.NoError(t, err)
+	assert.True(t, res.DryRun)
+
+	list, err = client.FilterAds(ctx, &grpcPort.FilterAdsRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, list.List, 2)
+}
+
+func BenchmarkImportAds(b *testing.B) {
+	client := getTestClient()
+	ctx := context.Background()
+
+	pr, pw := io.Pipe()
+	go func() {
+		w := bufio.NewWriter(pw)
+		for i := 0; i < b.N; i++ {
+			_, _ = w.WriteString(`{"title": "hello", "text": "world", "user_id": 1}` + "\n")
+		}
+		_ = w.Flush()
+		_ = pw.Close()
+	}()
+
+	res, err := client.ImportAds(ctx, pr, adsclient.FormatJSONL, true)
+	assert.NoError(b, err)
+	assert.Equal(b, int64(b.N), res.Imported)
+}
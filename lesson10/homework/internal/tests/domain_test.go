@@ -1,43 +1,49 @@
 package tests
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"homework10/pkg/adsclient"
 )
 
 func TestChangeStatusAdOfAnotherUser(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	resp, err := client.createAd(123, "hello", "world")
+	resp, err := client.CreateAd(ctx, 123, "hello", "world")
 	assert.NoError(t, err)
 
-	_, err = client.changeAdStatus(100, resp.Data.ID, true)
-	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.ChangeAdStatus(ctx, 100, resp.ID, true)
+	assert.ErrorIs(t, err, adsclient.ErrForbidden)
 }
 
 func TestUpdateAdOfAnotherUser(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	resp, err := client.createAd(123, "hello", "world")
+	resp, err := client.CreateAd(ctx, 123, "hello", "world")
 	assert.NoError(t, err)
 
-	_, err = client.updateAd(100, resp.Data.ID, "title", "text")
-	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.UpdateAd(ctx, 100, resp.ID, "title", "text")
+	assert.ErrorIs(t, err, adsclient.ErrForbidden)
 }
 
 func TestCreateAd_ID(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	resp, err := client.createAd(123, "hello", "world")
+	resp, err := client.CreateAd(ctx, 123, "hello", "world")
 	assert.NoError(t, err)
-	assert.Equal(t, resp.Data.ID, int64(0))
+	assert.Equal(t, resp.ID, int64(0))
 
-	resp, err = client.createAd(123, "hello", "world")
+	resp, err = client.CreateAd(ctx, 123, "hello", "world")
 	assert.NoError(t, err)
-	assert.Equal(t, resp.Data.ID, int64(1))
+	assert.Equal(t, resp.ID, int64(1))
 
-	resp, err = client.createAd(123, "hello", "world")
+	resp, err = client.CreateAd(ctx, 123, "hello", "world")
 	assert.NoError(t, err)
-	assert.Equal(t, resp.Data.ID, int64(2))
+	assert.Equal(t, resp.ID, int64(2))
 }
@@ -0,0 +1,248 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/favoritesrepo"
+	"homework10/internal/adapters/inbox"
+	"homework10/internal/adapters/notifyhook"
+	"homework10/internal/adapters/usersrepo"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/favorites"
+	"homework10/internal/notify"
+	"homework10/pkg/adsclient"
+)
+
+func TestFavorites(t *testing.T) {
+	a := app.NewApp(adrepo.New(), usersrepo.New(), app.WithPasswordCost(bcrypt.MinCost),
+		app.WithFavorites(favoritesrepo.New()))
+
+	u, err := a.CreateUser("oleg", "oleg@mail.ru", "password")
+	assert.NoError(t, err)
+
+	ad, err := a.CreateAd("hello", "world", 1)
+	assert.NoError(t, err)
+	hidden, err := a.CreateAd("draft", "text", 1)
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(ad.ID, 1, true)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, a.AddFavorite(u.ID, hidden.ID), app.AccessErr)
+	assert.Error(t, a.AddFavorite(u.ID, 100))
+	assert.Error(t, a.AddFavorite(100, ad.ID))
+
+	assert.NoError(t, a.AddFavorite(u.ID, ad.ID))
+	assert.NoError(t, a.AddFavorite(u.ID, ad.ID))
+	list, err := a.Favorites(u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	// снятое с публикации объявление пропадает из избранного и возвращается вместе с публикацией
+	_, err = a.ChangeAdStatus(ad.ID, 1, false)
+	assert.NoError(t, err)
+	list, err = a.Favorites(u.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+	_, err = a.ChangeAdStatus(ad.ID, 1, true)
+	assert.NoError(t, err)
+	list, err = a.Favorites(u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	assert.NoError(t, a.RemoveFavorite(u.ID, ad.ID))
+	list, err = a.Favorites(u.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestSavedSearches(t *testing.T) {
+	a := app.NewApp(adrepo.New(), usersrepo.New(), app.WithPasswordCost(bcrypt.MinCost),
+		app.WithFavorites(favoritesrepo.New()))
+
+	u, err := a.CreateUser("oleg", "oleg@mail.ru", "password")
+	assert.NoError(t, err)
+
+	_, err = a.SaveSearch(u.ID, "", ads.Filter{AuthorID: -1})
+	assert.ErrorIs(t, err, app.ValidationErr)
+	_, err = a.SaveSearch(u.ID, "bikes", ads.Filter{AuthorID: -1, Date: "01.05.2023"})
+	assert.ErrorIs(t, err, app.ValidationErr)
+
+	s, err := a.SaveSearch(u.ID, "bikes", ads.Filter{AuthorID: -1, Title: "bike"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, s.Filter.Published)
+
+	for _, title := range []string{"red bike", "blue bike", "car"} {
+		ad, err := a.CreateAd(title, "text", 1)
+		assert.NoError(t, err)
+		_, err = a.ChangeAdStatus(ad.ID, 1, true)
+		assert.NoError(t, err)
+	}
+	_, err = a.CreateAd("draft bike", "text", 1)
+	assert.NoError(t, err)
+
+	found, err := a.RunSearch(u.ID, s.ID)
+	assert.NoError(t, err)
+	assert.Len(t, found, 2)
+
+	list, err := a.SavedSearches(u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	assert.NoError(t, a.DeleteSearch(u.ID, s.ID))
+	assert.ErrorIs(t, a.DeleteSearch(u.ID, s.ID), favorites.ErrSearchNotFound)
+	_, err = a.RunSearch(u.ID, s.ID)
+	assert.ErrorIs(t, err, favorites.ErrSearchNotFound)
+}
+
+func TestSavedSearchNotifications(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)}
+	box := inbox.New()
+	a, moderatorID := newModeratedApp(t, adrepo.New(), app.WithClock(clock), app.WithPasswordCost(bcrypt.MinCost),
+		app.WithFavorites(favoritesrepo.New()), app.WithNotifier(box))
+
+	u, err := a.CreateUser("oleg", "oleg@mail.ru", "password")
+	assert.NoError(t, err)
+	s, err := a.SaveSearch(u.ID, "bikes", ads.Filter{AuthorID: -1, Title: "bike"})
+	assert.NoError(t, err)
+
+	// публикация
+	ad, err := a.CreateAd("red bike", "almost new", authorID)
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(ad.ID, authorID, true)
+	assert.NoError(t, err)
+
+	// объявление становится видно после одобрения модератором
+	flagged, err := a.CreateAd("blue bike", "see bikes.ru", authorID)
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(flagged.ID, authorID, true)
+	assert.NoError(t, err)
+	assert.Len(t, box.List(u.ID), 1)
+	_, err = a.ApproveAd(flagged.ID, moderatorID)
+	assert.NoError(t, err)
+
+	// отложенная публикация
+	scheduled, err := a.CreateAd("green bike", "almost new", authorID)
+	assert.NoError(t, err)
+	_, err = a.ScheduleAd(scheduled.ID, authorID, clock.Now().Add(time.Minute), time.Time{})
+	assert.NoError(t, err)
+	clock.Add(time.Hour)
+	runSchedulerOnce(t, a)
+
+	// неподходящее объявление и объявление самого владельца поиска
+	other, err := a.CreateAd("car", "almost new", authorID)
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(other.ID, authorID, true)
+	assert.NoError(t, err)
+	own, err := a.CreateAd("my bike", "almost new", u.ID)
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(own.ID, u.ID, true)
+	assert.NoError(t, err)
+
+	list := box.List(u.ID)
+	assert.Len(t, list, 3)
+	for i, id := range []int64{ad.ID, flagged.ID, scheduled.ID} {
+		assert.Equal(t, id, list[i].AdID)
+		assert.Equal(t, s.ID, list[i].SearchID)
+		assert.Equal(t, "bikes", list[i].SearchName)
+	}
+}
+
+func TestFavoritesDisabled(t *testing.T) {
+	a := app.NewApp(adrepo.New(), usersrepo.New(), app.WithPasswordCost(bcrypt.MinCost))
+	u, err := a.CreateUser("oleg", "oleg@mail.ru", "password")
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, a.AddFavorite(u.ID, 0), app.DisabledErr)
+	_, err = a.SaveSearch(u.ID, "bikes", ads.Filter{AuthorID: -1})
+	assert.ErrorIs(t, err, app.DisabledErr)
+
+	client := getTestClientWithApp(a)
+	_, err = client.Favorites(context.Background(), u.ID)
+	var apiErr *adsclient.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotImplemented, apiErr.StatusCode)
+}
+
+func TestFavoritesHTTP(t *testing.T) {
+	client := getTestClientWithApp(app.NewApp(adrepo.New(), usersrepo.New(), app.WithPasswordCost(bcrypt.MinCost),
+		app.WithFavorites(favoritesrepo.New())))
+	ctx := context.Background()
+
+	u, err := client.CreateUser(ctx, "oleg", "oleg@mail.ru", "password")
+	assert.NoError(t, err)
+	ad, err := client.CreateAd(ctx, 1, "red bike", "almost new")
+	assert.NoError(t, err)
+
+	_, err = client.AddFavorite(ctx, u.ID, ad.ID)
+	assert.ErrorIs(t, err, adsclient.ErrForbidden)
+
+	_, err = client.ChangeAdStatus(ctx, 1, ad.ID, true)
+	assert.NoError(t, err)
+	list, err := client.AddFavorite(ctx, u.ID, ad.ID)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	list, err = client.RemoveFavorite(ctx, u.ID, ad.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+
+	_, err = client.SaveSearch(ctx, u.ID, "", adsclient.SearchCriteria{})
+	assert.ErrorIs(t, err, adsclient.ErrBadRequest)
+
+	author := int64(1)
+	s, err := client.SaveSearch(ctx, u.ID, "bikes", adsclient.SearchCriteria{AuthorID: &author, Title: "bike",
+		CreatedOn: time.Now().UTC()})
+	assert.NoError(t, err)
+	assert.Equal(t, "bikes", s.Name)
+	assert.Equal(t, &author, s.AuthorID)
+
+	searches, err := client.SavedSearches(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []adsclient.SavedSearch{s}, searches)
+
+	found, err := client.RunSearch(ctx, u.ID, s.ID)
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+
+	assert.NoError(t, client.DeleteSearch(ctx, u.ID, s.ID))
+	assert.ErrorIs(t, client.DeleteSearch(ctx, u.ID, s.ID), adsclient.ErrNotFound)
+}
+
+func TestNotifyHook(t *testing.T) {
+	received := make(chan notify.Notification, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n notify.Notification
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&n))
+		received <- n
+	}))
+	defer server.Close()
+
+	hook := notifyhook.New(server.URL, notifyhook.WithHTTPClient(server.Client()), notifyhook.WithQueueSize(1))
+	sent := notify.Notification{UserID: 1, SearchID: 2, SearchName: "bikes", AdID: 3, AdTitle: "red bike",
+		At: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)}
+	assert.NoError(t, hook.Notify(sent))
+	assert.ErrorIs(t, hook.Notify(sent), notify.ErrQueueFull)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- hook.Run(ctx) }()
+
+	select {
+	case n := <-received:
+		assert.Equal(t, sent, n)
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification was not delivered")
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
@@ -10,14 +10,13 @@ import (
 	"homework10/internal/adapters/usersrepo"
 	"homework10/internal/app"
 	grpcPort "homework10/internal/ports/grpc"
-	"math"
 	"net"
-	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
 
-func FuzzGRPCCreateUserWithWrongId_Fuzz(f *testing.F) {
+func FuzzGRPCCreateUserWithWrongPassword_Fuzz(f *testing.F) {
 	lis := bufconn.Listen(1024 * 1024)
 	f.Cleanup(func() {
 		lis.Close()
@@ -54,18 +53,21 @@ func FuzzGRPCCreateUserWithWrongId_Fuzz(f *testing.F) {
 
 	client := grpcPort.NewAdServiceClient(conn)
 
-	tests := [...]int64{-1, -5, -100, math.MinInt64}
+	tests := [...]string{"", "short", "1234567", strings.Repeat("x", 73)}
 
 	for _, test := range tests {
 		f.Add(test)
 	}
 
-	f.Fuzz(func(t *testing.T, test int64) {
-		s := strconv.Itoa(int(test))
+	f.Fuzz(func(t *testing.T, test string) {
+		if len(test) >= 8 && len(test) <= 72 {
+			t.Skip()
+		}
+
 		_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{
-			Id:    test,
-			Name:  "Oleg" + s,
-			Email: "email@example.com",
+			Name:     "Oleg",
+			Email:    "email@example.com",
+			Password: test,
 		})
 
 		assert.Error(t, err)
@@ -53,7 +53,7 @@ func TestGRRPCCreateUser(t *testing.T) {
 	})
 
 	client := grpcPort.NewAdServiceClient(conn)
-	res, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg", Id: 15, Email: "email@exmple.com"})
+	res, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg", Email: "email@exmple.com", Password: "password"})
 	assert.NoError(t, err, "client.GetUser")
 
 	assert.Equal(t, "Oleg", res.Name)
@@ -307,16 +307,17 @@ func TestGRPCGetUser(t *testing.T) {
 	})
 
 	client := grpcPort.NewAdServiceClient(conn)
-	res, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg", Id: 15, Email: "email@exmple.com"})
+	res, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg", Email: "email@exmple.com", Password: "password"})
 	assert.NoError(t, err, "client.CreateUser")
 
 	assert.Equal(t, "Oleg", res.Name)
 
-	res, err = client.GetUser(ctx, &grpcPort.GetUserRequest{Id: 15})
+	id := res.Id
+	res, err = client.GetUser(ctx, &grpcPort.GetUserRequest{Id: id})
 	assert.NoError(t, err, "client.GetUser")
 
 	assert.Equal(t, "Oleg", res.Name)
-	assert.Equal(t, int64(15), res.Id)
+	assert.Equal(t, id, res.Id)
 }
 
 func TestGRPCDeleteUser(t *testing.T) {
@@ -355,15 +356,15 @@ func TestGRPCDeleteUser(t *testing.T) {
 	})
 
 	client := grpcPort.NewAdServiceClient(conn)
-	res, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg", Id: 15, Email: "email@exmple.com"})
+	res, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg", Email: "email@exmple.com", Password: "password"})
 	assert.NoError(t, err, "client.CreateUser")
 
 	assert.Equal(t, "Oleg", res.Name)
 
-	_, err = client.DeleteUser(ctx, &grpcPort.DeleteUserRequest{Id: 15})
+	_, err = client.DeleteUser(ctx, &grpcPort.DeleteUserRequest{Id: res.Id})
 	assert.NoError(t, err, "client.DeleteUser")
 
-	_, err = client.GetUser(ctx, &grpcPort.GetUserRequest{Id: 15})
+	_, err = client.GetUser(ctx, &grpcPort.GetUserRequest{Id: res.Id})
 	assert.Error(t, err, "client.GetUSer")
 }
 
@@ -0,0 +1,283 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/idempotencyrepo"
+	"homework10/internal/adapters/usersrepo"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/idempotency"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/ports/httpgin"
+	mockApp "homework10/internal/tests/mocks/app"
+	"homework10/pkg/adsclient"
+)
+
+func getIdempotentServer(a app.App, k *idempotency.Keeper) *httptest.Server {
+	server := httpgin.NewHTTPServer(":18080", a, httpgin.WithIdempotency(k))
+	return httptest.NewServer(server.Handler())
+}
+
+func getIdempotentClient(a app.App, k *idempotency.Keeper) *adsclient.Client {
+	testServer := getIdempotentServer(a, k)
+	return adsclient.New(testServer.URL, adsclient.WithHTTPClient(testServer.Client()))
+}
+
+func TestIdempotentCreateAd(t *testing.T) {
+	repo := adrepo.New()
+	testServer := getIdempotentServer(app.NewApp(repo, usersrepo.New()), idempotency.NewKeeper(idempotencyrepo.New()))
+	defer testServer.Close()
+
+	post := func(key string, body string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, testServer.URL+"/api/v1/ads", strings.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", key)
+
+		resp, err := testServer.Client().Do(req)
+		assert.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+
+	body := `{"title": "hello", "text": "world", "user_id": 123}`
+	first := post("key-1", body)
+	assert.Equal(t, http.StatusOK, first.StatusCode)
+	assert.Empty(t, first.Header.Get("Idempotency-Replayed"))
+
+	second := post("key-1", body)
+	assert.Equal(t, http.StatusOK, second.StatusCode)
+	assert.Equal(t, "true", second.Header.Get("Idempotency-Replayed"))
+
+	var b1, b2 bytes.Buffer
+	_, _ = b1.ReadFrom(first.Body)
+	_, _ = b2.ReadFrom(second.Body)
+	assert.Equal(t, b1.String(), b2.String())
+	assert.Equal(t, int64(1), repo.GetSize())
+
+	resp := post("key-1", `{"title": "hello", "text": "other", "user_id": 123}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, int64(1), repo.GetSize())
+
+	resp = post("key-2", body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int64(2), repo.GetSize())
+
+	resp = post(strings.Repeat("k", 256), body)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestIdempotentCreateUserAndImport(t *testing.T) {
+	repo := adrepo.New()
+	client := getIdempotentClient(app.NewApp(repo, usersrepo.New()), idempotency.NewKeeper(idempotencyrepo.New()))
+	ctx := adsclient.WithIdempotencyKey(context.Background(), "signup")
+
+	u1, err := client.CreateUser(ctx, "ivan", "ivan@example.com", "password")
+	assert.NoError(t, err)
+	u2, err := client.CreateUser(ctx, "ivan", "ivan@example.com", "password")
+	assert.NoError(t, err)
+	assert.Equal(t, u1, u2)
+
+	_, err = client.CreateUser(context.Background(), "ivan", "ivan@example.com", "password")
+	assert.ErrorIs(t, err, adsclient.ErrConflict)
+
+	_, err = client.CreateUser(ctx, "petr", "petr@example.com", "password")
+	assert.ErrorIs(t, err, adsclient.ErrUnprocessable)
+
+	// ключи разных маршрутов не пересекаются
+	body := `{"title": "hello", "text": "world", "user_id": 1}
+{"title": "best cat", "text": "not for sale", "user_id": 2}
+`
+	res1, err := client.ImportAds(ctx, strings.NewReader(body), adsclient.FormatJSONL, false)
+	assert.NoError(t, err)
+	res2, err := client.ImportAds(ctx, strings.NewReader(body), adsclient.FormatJSONL, false)
+	assert.NoError(t, err)
+	assert.Equal(t, res1, res2)
+	assert.Equal(t, int64(2), repo.GetSize())
+
+	_, err = client.ImportAds(ctx, strings.NewReader(body), adsclient.FormatJSONL, true)
+	assert.ErrorIs(t, err, adsclient.ErrUnprocessable)
+}
+
+func TestIdempotentImportStreams(t *testing.T) {
+	repo := adrepo.New()
+	testServer := getIdempotentServer(app.NewApp(repo, usersrepo.New()), idempotency.NewKeeper(idempotencyrepo.New()))
+	defer testServer.Close()
+
+	post := func(body io.Reader) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, testServer.URL+"/api/v1/ads:import", body)
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		req.Header.Set("Idempotency-Key", "import")
+
+		resp, err := testServer.Client().Do(req)
+		assert.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+
+	hello := `{"title": "hello", "text": "world", "user_id": 1}` + "\n"
+	cat := `{"title": "best cat", "text": "not for sale", "user_id": 2}` + "\n"
+
+	// первая запись импортируется, пока остальное тело еще не отправлено
+	r, w := io.Pipe()
+	responses := make(chan *http.Response)
+	go func() { responses <- post(r) }()
+
+	_, err := io.WriteString(w, hello)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return repo.GetSize() == 1 }, 5*time.Second, 10*time.Millisecond)
+	_, err = io.WriteString(w, cat)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	first := <-responses
+	assert.Equal(t, http.StatusOK, first.StatusCode)
+	assert.Equal(t, int64(2), repo.GetSize())
+
+	second := post(strings.NewReader(hello + cat))
+	assert.Equal(t, http.StatusOK, second.StatusCode)
+	assert.Equal(t, "true", second.Header.Get("Idempotency-Replayed"))
+
+	var b1, b2 bytes.Buffer
+	_, _ = b1.ReadFrom(first.Body)
+	_, _ = b2.ReadFrom(second.Body)
+	assert.Equal(t, b1.String(), b2.String())
+	assert.Equal(t, int64(2), repo.GetSize())
+
+	resp := post(strings.NewReader(cat + hello))
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, int64(2), repo.GetSize())
+}
+
+func TestIdempotencyKeyExpires(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	repo := adrepo.New()
+	k := idempotency.NewKeeper(idempotencyrepo.New(), idempotency.WithTTL(time.Hour), idempotency.WithNow(clock.Now))
+	client := getIdempotentClient(app.NewApp(repo, usersrepo.New()), k)
+	ctx := adsclient.WithIdempotencyKey(context.Background(), "key")
+
+	ad1, err := client.CreateAd(ctx, 123, "hello", "world")
+	assert.NoError(t, err)
+
+	clock.Add(59 * time.Minute)
+	ad2, err := client.CreateAd(ctx, 123, "hello", "world")
+	assert.NoError(t, err)
+	assert.Equal(t, ad1.ID, ad2.ID)
+
+	clock.Add(time.Minute)
+	ad3, err := client.CreateAd(ctx, 123, "hello", "world")
+	assert.NoError(t, err)
+	assert.NotEqual(t, ad1.ID, ad3.ID)
+	assert.Equal(t, int64(2), repo.GetSize())
+}
+
+func TestIdempotencyServerErrorReleasesKey(t *testing.T) {
+	a := mockApp.NewApp(t)
+	a.On("WithRequestID", mock.Anything).Return(a).Maybe()
+	a.On("CreateAd", "hello", "world", int64(123)).Return(ads.Ad{}, errors.New("storage is down")).Once()
+	a.On("CreateAd", "hello", "world", int64(123)).Return(ads.Ad{ID: 7, Title: "hello", Text: "world", AuthorID: 123}, nil).Once()
+
+	client := getIdempotentClient(a, idempotency.NewKeeper(idempotencyrepo.New()))
+	ctx := adsclient.WithIdempotencyKey(context.Background(), "key")
+
+	_, err := client.CreateAd(ctx, 123, "hello", "world")
+	assert.ErrorIs(t, err, adsclient.ErrInternal)
+
+	ad, err := client.CreateAd(ctx, 123, "hello", "world")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), ad.ID)
+}
+
+func TestClientRetriesPostWithIdempotencyKey(t *testing.T) {
+	repo := adrepo.New()
+	server := httpgin.NewHTTPServer(":18080", app.NewApp(repo, usersrepo.New()),
+		httpgin.WithIdempotency(idempotency.NewKeeper(idempotencyrepo.New())))
+
+	// первый ответ теряется, хотя объявление уже создано
+	var calls int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			server.Handler().ServeHTTP(httptest.NewRecorder(), r)
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		}
+		server.Handler().ServeHTTP(w, r)
+	}))
+	defer testServer.Close()
+
+	client := adsclient.New(testServer.URL, adsclient.WithHTTPClient(testServer.Client()),
+		adsclient.WithRetries(2), adsclient.WithBackoff(time.Millisecond, 5*time.Millisecond))
+
+	ad, err := client.CreateAd(adsclient.WithIdempotencyKey(context.Background(), "key"), 123, "hello", "world")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", ad.Title)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Equal(t, int64(1), repo.GetSize())
+}
+
+func TestGRPCIdempotency(t *testing.T) {
+	repo := adrepo.New()
+	k := idempotency.NewKeeper(idempotencyrepo.New())
+	client := getTestGRPCClientWithApp(t, app.NewApp(repo, usersrepo.New()),
+		grpc.ChainUnaryInterceptor(grpcPort.IdempotencyInterceptor(k)),
+		grpc.ChainStreamInterceptor(grpcPort.IdempotencyStreamInterceptor(k)))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "idempotency-key", "key")
+
+	ad1, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: 123})
+	assert.NoError(t, err)
+	ad2, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: 123})
+	assert.NoError(t, err)
+	assert.Equal(t, ad1.Id, ad2.Id)
+	assert.Equal(t, int64(1), repo.GetSize())
+
+	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hello", Text: "other", UserId: 123})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// сохраняется и ошибка клиента
+	bad := metadata.AppendToOutgoingContext(context.Background(), "idempotency-key", "bad")
+	_, err = client.CreateAd(bad, &grpcPort.CreateAdRequest{Title: "", Text: "world", UserId: 123})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.CreateAd(bad, &grpcPort.CreateAdRequest{Title: "", Text: "world", UserId: 123})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	importAds := func(requests ...*grpcPort.ImportAdRequest) (*grpcPort.ImportAdsResponse, error) {
+		stream, err := client.ImportAds(ctx)
+		assert.NoError(t, err)
+		for _, r := range requests {
+			assert.NoError(t, stream.Send(r))
+		}
+		return stream.CloseAndRecv()
+	}
+
+	hello := &grpcPort.ImportAdRequest{Title: "hello", Text: "world", UserId: 1}
+	cat := &grpcPort.ImportAdRequest{Title: "best cat", Text: "not for sale", UserId: 2}
+	res1, err := importAds(hello, cat)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), res1.Imported)
+	res2, err := importAds(hello, cat)
+	assert.NoError(t, err)
+	assert.Equal(t, res1.Imported, res2.Imported)
+	assert.Equal(t, int64(3), repo.GetSize())
+
+	_, err = importAds(cat, hello)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
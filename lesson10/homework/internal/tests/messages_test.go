@@ -0,0 +1,244 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/messagesrepo"
+	"homework10/internal/adapters/usersrepo"
+	"homework10/internal/app"
+	"homework10/internal/messages"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/pkg/adsclient"
+)
+
+// newMessagingApp возвращает app с перепиской, автора seller с опубликованным объявлением и покупателя buyer
+func newMessagingApp(t *testing.T, opts ...app.Option) (a app.App, seller int64, buyer int64, adID int64) {
+	opts = append([]app.Option{app.WithPasswordCost(bcrypt.MinCost), app.WithMessages(messagesrepo.New())}, opts...)
+	a = app.NewApp(adrepo.New(), usersrepo.New(), opts...)
+
+	s, err := a.CreateUser("seller", "seller@mail.ru", "password")
+	assert.NoError(t, err)
+	b, err := a.CreateUser("buyer", "buyer@mail.ru", "password")
+	assert.NoError(t, err)
+
+	ad, err := a.CreateAd("red bike", "almost new", s.ID)
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(ad.ID, s.ID, true)
+	assert.NoError(t, err)
+
+	return a, s.ID, b.ID, ad.ID
+}
+
+func TestMessages(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)}
+	a, seller, buyer, adID := newMessagingApp(t, app.WithClock(clock))
+
+	first, err := a.ContactAuthor(adID, buyer, "is it still available?")
+	assert.NoError(t, err)
+	clock.Add(time.Minute)
+	second, err := a.ContactAuthor(adID, buyer, "hello?")
+	assert.NoError(t, err)
+	assert.Equal(t, first.ThreadID, second.ThreadID)
+
+	clock.Add(time.Minute)
+	_, err = a.SendMessage(first.ThreadID, seller, "yes")
+	assert.NoError(t, err)
+
+	threads, err := a.Threads(seller)
+	assert.NoError(t, err)
+	assert.Len(t, threads, 1)
+	assert.Equal(t, adID, threads[0].AdID)
+	assert.Equal(t, 2, threads[0].Unread(seller))
+	assert.Equal(t, 1, threads[0].Unread(buyer))
+	assert.Equal(t, clock.Now(), threads[0].LastMessageAt)
+
+	list, err := a.Messages(first.ThreadID, buyer)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"is it still available?", "hello?", "yes"},
+		[]string{list[0].Text, list[1].Text, list[2].Text})
+
+	thread, err := a.MarkThreadRead(first.ThreadID, seller)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, thread.Unread(seller))
+	assert.Equal(t, 1, thread.Unread(buyer))
+
+	// последней активной переписка становится после нового сообщения
+	other, err := a.CreateAd("blue bike", "almost new", seller)
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(other.ID, seller, true)
+	assert.NoError(t, err)
+	clock.Add(time.Minute)
+	_, err = a.ContactAuthor(other.ID, buyer, "and this one?")
+	assert.NoError(t, err)
+	threads, err = a.Threads(buyer)
+	assert.NoError(t, err)
+	assert.Len(t, threads, 2)
+	assert.Equal(t, other.ID, threads[0].AdID)
+}
+
+func TestMessagesErrors(t *testing.T) {
+	a, seller, buyer, adID := newMessagingApp(t)
+	stranger, err := a.CreateUser("stranger", "stranger@mail.ru", "password")
+	assert.NoError(t, err)
+
+	draft, err := a.CreateAd("draft", "text", seller)
+	assert.NoError(t, err)
+
+	type Test struct {
+		Name   string
+		AdID   int64
+		UserID int64
+		Text   string
+		Err    error
+	}
+
+	tests := [...]Test{
+		{Name: "own ad", AdID: adID, UserID: seller, Text: "hi", Err: app.ValidationErr},
+		{Name: "unpublished ad", AdID: draft.ID, UserID: buyer, Text: "hi", Err: app.AccessErr},
+		{Name: "empty text", AdID: adID, UserID: buyer, Text: "  ", Err: app.ValidationErr},
+		{Name: "unknown user", AdID: adID, UserID: 100, Text: "hi"},
+	}
+
+	for _, test := range tests {
+		_, err := a.ContactAuthor(test.AdID, test.UserID, test.Text)
+		if test.Err == nil {
+			assert.Error(t, err, test.Name)
+			continue
+		}
+		assert.ErrorIs(t, err, test.Err, test.Name)
+	}
+
+	m, err := a.ContactAuthor(adID, buyer, "hi")
+	assert.NoError(t, err)
+
+	_, err = a.Messages(m.ThreadID, stranger.ID)
+	assert.ErrorIs(t, err, app.AccessErr)
+	_, err = a.SendMessage(m.ThreadID, stranger.ID, "hi")
+	assert.ErrorIs(t, err, app.AccessErr)
+	_, err = a.MarkThreadRead(100, buyer)
+	assert.ErrorIs(t, err, messages.ErrThreadNotFound)
+
+	disabled := app.NewApp(adrepo.New(), usersrepo.New())
+	_, err = disabled.Threads(buyer)
+	assert.ErrorIs(t, err, app.DisabledErr)
+}
+
+func TestMessagesSubscription(t *testing.T) {
+	a, seller, buyer, adID := newMessagingApp(t)
+
+	incoming, cancel, err := a.SubscribeMessages(seller)
+	assert.NoError(t, err)
+
+	m, err := a.ContactAuthor(adID, buyer, "hi")
+	assert.NoError(t, err)
+	assert.Equal(t, m, <-incoming)
+
+	cancel()
+	cancel()
+	_, ok := <-incoming
+	assert.False(t, ok)
+
+	_, err = a.ContactAuthor(adID, buyer, "hi again")
+	assert.NoError(t, err)
+}
+
+func TestMessagesHTTP(t *testing.T) {
+	a, seller, buyer, adID := newMessagingApp(t)
+	client := getTestClientWithApp(a)
+	ctx := context.Background()
+
+	m, err := client.ContactAuthor(ctx, buyer, adID, "is it still available?")
+	assert.NoError(t, err)
+	assert.Equal(t, buyer, m.SenderID)
+
+	_, err = client.SendMessage(ctx, seller, m.ThreadID, "")
+	assert.ErrorIs(t, err, adsclient.ErrBadRequest)
+	_, err = client.SendMessage(ctx, seller, m.ThreadID, "yes")
+	assert.NoError(t, err)
+
+	threads, err := client.Threads(ctx, seller)
+	assert.NoError(t, err)
+	assert.Len(t, threads, 1)
+	assert.Equal(t, 1, threads[0].Unread)
+	assert.Equal(t, buyer, threads[0].BuyerID)
+
+	list, err := client.Messages(ctx, buyer, m.ThreadID)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	thread, err := client.MarkThreadRead(ctx, seller, m.ThreadID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, thread.Unread)
+
+	_, err = client.Messages(ctx, seller, 100)
+	assert.ErrorIs(t, err, adsclient.ErrNotFound)
+}
+
+func TestGRPCChat(t *testing.T) {
+	a, seller, buyer, adID := newMessagingApp(t)
+	client := getTestGRPCClientWithApp(t, a)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sellerChat, err := client.Chat(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, sellerChat.Send(&grpcPort.ChatRequest{UserId: seller}))
+	_, err = sellerChat.Header()
+	assert.NoError(t, err)
+
+	// покупатель пишет по объявлению, продавец получает сообщение в свой поток
+	buyerChat, err := client.Chat(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, buyerChat.Send(&grpcPort.ChatRequest{UserId: buyer,
+		To: &grpcPort.ChatRequest_AdId{AdId: adID}, Text: "is it still available?"}))
+
+	echo, err := buyerChat.Recv()
+	assert.NoError(t, err)
+	got, err := sellerChat.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, "is it still available?", got.Text)
+	assert.Equal(t, buyer, got.SenderId)
+	assert.Equal(t, echo.Id, got.Id)
+
+	assert.NoError(t, sellerChat.Send(&grpcPort.ChatRequest{
+		To: &grpcPort.ChatRequest_ThreadId{ThreadId: got.ThreadId}, Text: "yes"}))
+	for _, stream := range []grpcPort.AdService_ChatClient{sellerChat, buyerChat} {
+		got, err = stream.Recv()
+		assert.NoError(t, err)
+		assert.Equal(t, "yes", got.Text)
+		assert.Equal(t, seller, got.SenderId)
+	}
+
+	// сообщения, отправленные не через поток, тоже приходят подписчикам
+	_, err = client.SendMessage(ctx, &grpcPort.SendMessageRequest{ThreadId: got.ThreadId, UserId: buyer, Text: "great"})
+	assert.NoError(t, err)
+	for _, stream := range []grpcPort.AdService_ChatClient{sellerChat, buyerChat} {
+		got, err = stream.Recv()
+		assert.NoError(t, err)
+		assert.Equal(t, "great", got.Text)
+	}
+
+	threads, err := client.ListThreads(ctx, &grpcPort.ListThreadsRequest{UserId: seller})
+	assert.NoError(t, err)
+	assert.Len(t, threads.List, 1)
+	assert.Equal(t, int64(2), threads.List[0].Unread)
+
+	assert.NoError(t, sellerChat.CloseSend())
+	_, err = sellerChat.Recv()
+	assert.ErrorIs(t, err, io.EOF)
+
+	// ошибка запроса завершает поток
+	assert.NoError(t, buyerChat.Send(&grpcPort.ChatRequest{
+		To: &grpcPort.ChatRequest_ThreadId{ThreadId: 100}, Text: "hi"}))
+	_, err = buyerChat.Recv()
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -1,35 +1,37 @@
 package tests
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
 
 func TestCreateGetAd(t *testing.T) {
 	client := getTestMockClient(t)
+	ctx := context.Background()
 
-	response, err := client.createAd(123, "hello", "world")
+	response, err := client.CreateAd(ctx, 123, "hello", "world")
 	assert.NoError(t, err)
-	assert.Zero(t, response.Data.ID)
-	assert.Equal(t, response.Data.Title, "hello")
-	assert.Equal(t, response.Data.Text, "world")
-	assert.Equal(t, response.Data.AuthorID, int64(123))
-	assert.False(t, response.Data.Published)
+	assert.Zero(t, response.ID)
+	assert.Equal(t, response.Title, "hello")
+	assert.Equal(t, response.Text, "world")
+	assert.Equal(t, response.AuthorID, int64(123))
+	assert.False(t, response.Published)
 
-	response, err = client.getAdByID(0)
+	response, err = client.GetAd(ctx, 0)
 	assert.NoError(t, err)
-	assert.Zero(t, response.Data.ID)
-	assert.Equal(t, response.Data.Title, "hello")
-	assert.Equal(t, response.Data.Text, "world")
-	assert.Equal(t, response.Data.AuthorID, int64(123))
-	assert.False(t, response.Data.Published)
+	assert.Zero(t, response.ID)
+	assert.Equal(t, response.Title, "hello")
+	assert.Equal(t, response.Text, "world")
+	assert.Equal(t, response.AuthorID, int64(123))
+	assert.False(t, response.Published)
 
-	ads, err := client.listAds()
+	ads, err := client.ListAds(ctx)
 	assert.NoError(t, err)
-	assert.Len(t, ads.Data, 1)
-	assert.Zero(t, ads.Data[0].ID)
-	assert.Equal(t, ads.Data[0].Title, "hello")
-	assert.Equal(t, ads.Data[0].Text, "world")
-	assert.Equal(t, ads.Data[0].AuthorID, int64(123))
+	assert.Len(t, ads, 1)
+	assert.Zero(t, ads[0].ID)
+	assert.Equal(t, ads[0].Title, "hello")
+	assert.Equal(t, ads[0].Text, "world")
+	assert.Equal(t, ads[0].AuthorID, int64(123))
 
 }
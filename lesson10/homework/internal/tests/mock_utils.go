@@ -4,13 +4,19 @@ import (
 	"homework10/internal/ads"
 	"homework10/internal/ports/httpgin"
 	mockApp "homework10/internal/tests/mocks/app"
+	"homework10/pkg/adsclient"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/stretchr/testify/mock"
 )
 
-func getTestMockClient(t *testing.T) *testClient {
+func getTestMockClient(t *testing.T) *adsclient.Client {
 	a := mockApp.NewApp(t)
 
+	// изменяющие обработчики работают с копией app, помеченной идентификатором запроса
+	a.On("WithRequestID", mock.Anything).Return(a).Maybe()
+
 	a.On("CreateAd", "hello", "world", int64(123)).Return(ads.Ad{
 		ID:        int64(0),
 		Title:     "hello",
@@ -38,8 +44,5 @@ func getTestMockClient(t *testing.T) *testClient {
 	server := httpgin.NewHTTPServer(":18080", a)
 	testServer := httptest.NewServer(server.Handler())
 
-	return &testClient{
-		client:  testServer.Client(),
-		baseURL: testServer.URL,
-	}
+	return adsclient.New(testServer.URL, adsclient.WithHTTPClient(testServer.Client()))
 }
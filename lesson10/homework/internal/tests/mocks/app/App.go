@@ -5,9 +5,23 @@ package app
 import (
 	ads "homework10/internal/ads"
 
+	app "homework10/internal/app"
+
+	audit "homework10/internal/audit"
+
+	context "context"
+
+	favorites "homework10/internal/favorites"
+
+	messages "homework10/internal/messages"
+
 	mock "github.com/stretchr/testify/mock"
 
+	time "time"
+
 	users "homework10/internal/users"
+
+	webhooks "homework10/internal/webhooks"
 )
 
 // App is an autogenerated mock type for the App type
@@ -15,6 +29,70 @@ type App struct {
 	mock.Mock
 }
 
+// AddFavorite provides a mock function with given fields: userID, adID
+func (_m *App) AddFavorite(userID int64, adID int64) error {
+	ret := _m.Called(userID, adID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64, int64) error); ok {
+		r0 = rf(userID, adID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ApproveAd provides a mock function with given fields: adID, moderatorID
+func (_m *App) ApproveAd(adID int64, moderatorID int64) (ads.Ad, error) {
+	ret := _m.Called(adID, moderatorID)
+
+	var r0 ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64, int64) (ads.Ad, error)); ok {
+		return rf(adID, moderatorID)
+	}
+	if rf, ok := ret.Get(0).(func(int64, int64) ads.Ad); ok {
+		r0 = rf(adID, moderatorID)
+	} else {
+		r0 = ret.Get(0).(ads.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, int64) error); ok {
+		r1 = rf(adID, moderatorID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AuditLog provides a mock function with given fields: actorID, f
+func (_m *App) AuditLog(actorID int64, f audit.Filter) ([]audit.Entry, error) {
+	ret := _m.Called(actorID, f)
+
+	var r0 []audit.Entry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64, audit.Filter) ([]audit.Entry, error)); ok {
+		return rf(actorID, f)
+	}
+	if rf, ok := ret.Get(0).(func(int64, audit.Filter) []audit.Entry); ok {
+		r0 = rf(actorID, f)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]audit.Entry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, audit.Filter) error); ok {
+		r1 = rf(actorID, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ChangeAdStatus provides a mock function with given fields: adID, userID, published
 func (_m *App) ChangeAdStatus(adID int64, userID int64, published bool) (ads.Ad, error) {
 	ret := _m.Called(adID, userID, published)
@@ -39,6 +117,30 @@ func (_m *App) ChangeAdStatus(adID int64, userID int64, published bool) (ads.Ad,
 	return r0, r1
 }
 
+// ContactAuthor provides a mock function with given fields: adID, userID, text
+func (_m *App) ContactAuthor(adID int64, userID int64, text string) (messages.Message, error) {
+	ret := _m.Called(adID, userID, text)
+
+	var r0 messages.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64, int64, string) (messages.Message, error)); ok {
+		return rf(adID, userID, text)
+	}
+	if rf, ok := ret.Get(0).(func(int64, int64, string) messages.Message); ok {
+		r0 = rf(adID, userID, text)
+	} else {
+		r0 = ret.Get(0).(messages.Message)
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, int64, string) error); ok {
+		r1 = rf(adID, userID, text)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateAd provides a mock function with given fields: title, text, authorID
 func (_m *App) CreateAd(title string, text string, authorID int64) (ads.Ad, error) {
 	ret := _m.Called(title, text, authorID)
@@ -63,23 +165,47 @@ func (_m *App) CreateAd(title string, text string, authorID int64) (ads.Ad, erro
 	return r0, r1
 }
 
-// CreateUser provides a mock function with given fields: id, nickname, email
-func (_m *App) CreateUser(id int64, nickname string, email string) (users.User, error) {
-	ret := _m.Called(id, nickname, email)
+// CreateUser provides a mock function with given fields: nickname, email, password
+func (_m *App) CreateUser(nickname string, email string, password string) (users.User, error) {
+	ret := _m.Called(nickname, email, password)
 
 	var r0 users.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64, string, string) (users.User, error)); ok {
-		return rf(id, nickname, email)
+	if rf, ok := ret.Get(0).(func(string, string, string) (users.User, error)); ok {
+		return rf(nickname, email, password)
 	}
-	if rf, ok := ret.Get(0).(func(int64, string, string) users.User); ok {
-		r0 = rf(id, nickname, email)
+	if rf, ok := ret.Get(0).(func(string, string, string) users.User); ok {
+		r0 = rf(nickname, email, password)
 	} else {
 		r0 = ret.Get(0).(users.User)
 	}
 
-	if rf, ok := ret.Get(1).(func(int64, string, string) error); ok {
-		r1 = rf(id, nickname, email)
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(nickname, email, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateWebhook provides a mock function with given fields: actorID, url, secret, events
+func (_m *App) CreateWebhook(actorID int64, url string, secret string, events []string) (webhooks.Subscription, error) {
+	ret := _m.Called(actorID, url, secret, events)
+
+	var r0 webhooks.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64, string, string, []string) (webhooks.Subscription, error)); ok {
+		return rf(actorID, url, secret, events)
+	}
+	if rf, ok := ret.Get(0).(func(int64, string, string, []string) webhooks.Subscription); ok {
+		r0 = rf(actorID, url, secret, events)
+	} else {
+		r0 = ret.Get(0).(webhooks.Subscription)
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, string, string, []string) error); ok {
+		r1 = rf(actorID, url, secret, events)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -111,23 +237,91 @@ func (_m *App) DeleteAd(adID int64, authorID int64) (ads.Ad, error) {
 	return r0, r1
 }
 
-// DeleteUser provides a mock function with given fields: id
-func (_m *App) DeleteUser(id int64) (users.User, error) {
-	ret := _m.Called(id)
+// DeleteSearch provides a mock function with given fields: userID, searchID
+func (_m *App) DeleteSearch(userID int64, searchID int64) error {
+	ret := _m.Called(userID, searchID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64, int64) error); ok {
+		r0 = rf(userID, searchID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteUser provides a mock function with given fields: id, actorID
+func (_m *App) DeleteUser(id int64, actorID int64) (users.User, error) {
+	ret := _m.Called(id, actorID)
 
 	var r0 users.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64) (users.User, error)); ok {
-		return rf(id)
+	if rf, ok := ret.Get(0).(func(int64, int64) (users.User, error)); ok {
+		return rf(id, actorID)
 	}
-	if rf, ok := ret.Get(0).(func(int64) users.User); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(int64, int64) users.User); ok {
+		r0 = rf(id, actorID)
 	} else {
 		r0 = ret.Get(0).(users.User)
 	}
 
+	if rf, ok := ret.Get(1).(func(int64, int64) error); ok {
+		r1 = rf(id, actorID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteWebhook provides a mock function with given fields: actorID, id
+func (_m *App) DeleteWebhook(actorID int64, id int64) error {
+	ret := _m.Called(actorID, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64, int64) error); ok {
+		r0 = rf(actorID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExportAds provides a mock function with given fields: actorID, fn
+func (_m *App) ExportAds(actorID int64, fn func(ad ads.Ad) error) error {
+	ret := _m.Called(actorID, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64, func(ad ads.Ad) error) error); ok {
+		r0 = rf(actorID, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Favorites provides a mock function with given fields: userID
+func (_m *App) Favorites(userID int64) ([]ads.Ad, error) {
+	ret := _m.Called(userID)
+
+	var r0 []ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64) ([]ads.Ad, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(int64) []ads.Ad); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ads.Ad)
+		}
+	}
+
 	if rf, ok := ret.Get(1).(func(int64) error); ok {
-		r1 = rf(id)
+		r1 = rf(userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -261,6 +455,429 @@ func (_m *App) GetUser(id int64) (users.User, error) {
 	return r0, r1
 }
 
+// ImportAd provides a mock function with given fields: title, text, authorID, dryRun
+func (_m *App) ImportAd(title string, text string, authorID int64, dryRun bool) (ads.Ad, error) {
+	ret := _m.Called(title, text, authorID, dryRun)
+
+	var r0 ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, int64, bool) (ads.Ad, error)); ok {
+		return rf(title, text, authorID, dryRun)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, int64, bool) ads.Ad); ok {
+		r0 = rf(title, text, authorID, dryRun)
+	} else {
+		r0 = ret.Get(0).(ads.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, int64, bool) error); ok {
+		r1 = rf(title, text, authorID, dryRun)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkThreadRead provides a mock function with given fields: threadID, userID
+func (_m *App) MarkThreadRead(threadID int64, userID int64) (messages.Thread, error) {
+	ret := _m.Called(threadID, userID)
+
+	var r0 messages.Thread
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64, int64) (messages.Thread, error)); ok {
+		return rf(threadID, userID)
+	}
+	if rf, ok := ret.Get(0).(func(int64, int64) messages.Thread); ok {
+		r0 = rf(threadID, userID)
+	} else {
+		r0 = ret.Get(0).(messages.Thread)
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, int64) error); ok {
+		r1 = rf(threadID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Messages provides a mock function with given fields: threadID, userID
+func (_m *App) Messages(threadID int64, userID int64) ([]messages.Message, error) {
+	ret := _m.Called(threadID, userID)
+
+	var r0 []messages.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64, int64) ([]messages.Message, error)); ok {
+		return rf(threadID, userID)
+	}
+	if rf, ok := ret.Get(0).(func(int64, int64) []messages.Message); ok {
+		r0 = rf(threadID, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]messages.Message)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, int64) error); ok {
+		r1 = rf(threadID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ModerationQueue provides a mock function with given fields: moderatorID
+func (_m *App) ModerationQueue(moderatorID int64) ([]ads.Ad, error) {
+	ret := _m.Called(moderatorID)
+
+	var r0 []ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64) ([]ads.Ad, error)); ok {
+		return rf(moderatorID)
+	}
+	if rf, ok := ret.Get(0).(func(int64) []ads.Ad); ok {
+		r0 = rf(moderatorID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(moderatorID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PatchAd provides a mock function with given fields: adID, userID, p
+func (_m *App) PatchAd(adID int64, userID int64, p ads.Patch) (ads.Ad, error) {
+	ret := _m.Called(adID, userID, p)
+
+	var r0 ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64, int64, ads.Patch) (ads.Ad, error)); ok {
+		return rf(adID, userID, p)
+	}
+	if rf, ok := ret.Get(0).(func(int64, int64, ads.Patch) ads.Ad); ok {
+		r0 = rf(adID, userID, p)
+	} else {
+		r0 = ret.Get(0).(ads.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, int64, ads.Patch) error); ok {
+		r1 = rf(adID, userID, p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RedeliverWebhook provides a mock function with given fields: actorID, deliveryID
+func (_m *App) RedeliverWebhook(actorID int64, deliveryID int64) (webhooks.Delivery, error) {
+	ret := _m.Called(actorID, deliveryID)
+
+	var r0 webhooks.Delivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64, int64) (webhooks.Delivery, error)); ok {
+		return rf(actorID, deliveryID)
+	}
+	if rf, ok := ret.Get(0).(func(int64, int64) webhooks.Delivery); ok {
+		r0 = rf(actorID, deliveryID)
+	} else {
+		r0 = ret.Get(0).(webhooks.Delivery)
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, int64) error); ok {
+		r1 = rf(actorID, deliveryID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RejectAd provides a mock function with given fields: adID, moderatorID, reason
+func (_m *App) RejectAd(adID int64, moderatorID int64, reason string) (ads.Ad, error) {
+	ret := _m.Called(adID, moderatorID, reason)
+
+	var r0 ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64, int64, string) (ads.Ad, error)); ok {
+		return rf(adID, moderatorID, reason)
+	}
+	if rf, ok := ret.Get(0).(func(int64, int64, string) ads.Ad); ok {
+		r0 = rf(adID, moderatorID, reason)
+	} else {
+		r0 = ret.Get(0).(ads.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, int64, string) error); ok {
+		r1 = rf(adID, moderatorID, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveFavorite provides a mock function with given fields: userID, adID
+func (_m *App) RemoveFavorite(userID int64, adID int64) error {
+	ret := _m.Called(userID, adID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64, int64) error); ok {
+		r0 = rf(userID, adID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResendVerification provides a mock function with given fields: id
+func (_m *App) ResendVerification(id int64) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RunScheduler provides a mock function with given fields: ctx, interval
+func (_m *App) RunScheduler(ctx context.Context, interval time.Duration) error {
+	ret := _m.Called(ctx, interval)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration) error); ok {
+		r0 = rf(ctx, interval)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RunSearch provides a mock function with given fields: userID, searchID
+func (_m *App) RunSearch(userID int64, searchID int64) ([]ads.Ad, error) {
+	ret := _m.Called(userID, searchID)
+
+	var r0 []ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64, int64) ([]ads.Ad, error)); ok {
+		return rf(userID, searchID)
+	}
+	if rf, ok := ret.Get(0).(func(int64, int64) []ads.Ad); ok {
+		r0 = rf(userID, searchID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, int64) error); ok {
+		r1 = rf(userID, searchID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveSearch provides a mock function with given fields: userID, name, f
+func (_m *App) SaveSearch(userID int64, name string, f ads.Filter) (favorites.Search, error) {
+	ret := _m.Called(userID, name, f)
+
+	var r0 favorites.Search
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64, string, ads.Filter) (favorites.Search, error)); ok {
+		return rf(userID, name, f)
+	}
+	if rf, ok := ret.Get(0).(func(int64, string, ads.Filter) favorites.Search); ok {
+		r0 = rf(userID, name, f)
+	} else {
+		r0 = ret.Get(0).(favorites.Search)
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, string, ads.Filter) error); ok {
+		r1 = rf(userID, name, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SavedSearches provides a mock function with given fields: userID
+func (_m *App) SavedSearches(userID int64) ([]favorites.Search, error) {
+	ret := _m.Called(userID)
+
+	var r0 []favorites.Search
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64) ([]favorites.Search, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(int64) []favorites.Search); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]favorites.Search)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ScheduleAd provides a mock function with given fields: adID, userID, publishAt, expiresAt
+func (_m *App) ScheduleAd(adID int64, userID int64, publishAt time.Time, expiresAt time.Time) (ads.Ad, error) {
+	ret := _m.Called(adID, userID, publishAt, expiresAt)
+
+	var r0 ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64, int64, time.Time, time.Time) (ads.Ad, error)); ok {
+		return rf(adID, userID, publishAt, expiresAt)
+	}
+	if rf, ok := ret.Get(0).(func(int64, int64, time.Time, time.Time) ads.Ad); ok {
+		r0 = rf(adID, userID, publishAt, expiresAt)
+	} else {
+		r0 = ret.Get(0).(ads.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, int64, time.Time, time.Time) error); ok {
+		r1 = rf(adID, userID, publishAt, expiresAt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SendMessage provides a mock function with given fields: threadID, userID, text
+func (_m *App) SendMessage(threadID int64, userID int64, text string) (messages.Message, error) {
+	ret := _m.Called(threadID, userID, text)
+
+	var r0 messages.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64, int64, string) (messages.Message, error)); ok {
+		return rf(threadID, userID, text)
+	}
+	if rf, ok := ret.Get(0).(func(int64, int64, string) messages.Message); ok {
+		r0 = rf(threadID, userID, text)
+	} else {
+		r0 = ret.Get(0).(messages.Message)
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, int64, string) error); ok {
+		r1 = rf(threadID, userID, text)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetUserRole provides a mock function with given fields: id, actorID, role
+func (_m *App) SetUserRole(id int64, actorID int64, role users.Role) (users.User, error) {
+	ret := _m.Called(id, actorID, role)
+
+	var r0 users.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64, int64, users.Role) (users.User, error)); ok {
+		return rf(id, actorID, role)
+	}
+	if rf, ok := ret.Get(0).(func(int64, int64, users.Role) users.User); ok {
+		r0 = rf(id, actorID, role)
+	} else {
+		r0 = ret.Get(0).(users.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, int64, users.Role) error); ok {
+		r1 = rf(id, actorID, role)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SubscribeMessages provides a mock function with given fields: userID
+func (_m *App) SubscribeMessages(userID int64) (<-chan messages.Message, func(), error) {
+	ret := _m.Called(userID)
+
+	var r0 <-chan messages.Message
+	var r1 func()
+	var r2 error
+	if rf, ok := ret.Get(0).(func(int64) (<-chan messages.Message, func(), error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(int64) <-chan messages.Message); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan messages.Message)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int64) func()); ok {
+		r1 = rf(userID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(func())
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(int64) error); ok {
+		r2 = rf(userID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Threads provides a mock function with given fields: userID
+func (_m *App) Threads(userID int64) ([]messages.Thread, error) {
+	ret := _m.Called(userID)
+
+	var r0 []messages.Thread
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64) ([]messages.Thread, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(int64) []messages.Thread); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]messages.Thread)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateAd provides a mock function with given fields: adID, userID, title, text
 func (_m *App) UpdateAd(adID int64, userID int64, title string, text string) (ads.Ad, error) {
 	ret := _m.Called(adID, userID, title, text)
@@ -285,23 +902,99 @@ func (_m *App) UpdateAd(adID int64, userID int64, title string, text string) (ad
 	return r0, r1
 }
 
-// UpdateUser provides a mock function with given fields: id, nickname, email
-func (_m *App) UpdateUser(id int64, nickname string, email string) (users.User, error) {
-	ret := _m.Called(id, nickname, email)
+// UpdateUser provides a mock function with given fields: id, actorID, nickname, email
+func (_m *App) UpdateUser(id int64, actorID int64, nickname string, email string) (users.User, error) {
+	ret := _m.Called(id, actorID, nickname, email)
+
+	var r0 users.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64, int64, string, string) (users.User, error)); ok {
+		return rf(id, actorID, nickname, email)
+	}
+	if rf, ok := ret.Get(0).(func(int64, int64, string, string) users.User); ok {
+		r0 = rf(id, actorID, nickname, email)
+	} else {
+		r0 = ret.Get(0).(users.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, int64, string, string) error); ok {
+		r1 = rf(id, actorID, nickname, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerifyEmail provides a mock function with given fields: id, token
+func (_m *App) VerifyEmail(id int64, token string) (users.User, error) {
+	ret := _m.Called(id, token)
 
 	var r0 users.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64, string, string) (users.User, error)); ok {
-		return rf(id, nickname, email)
+	if rf, ok := ret.Get(0).(func(int64, string) (users.User, error)); ok {
+		return rf(id, token)
 	}
-	if rf, ok := ret.Get(0).(func(int64, string, string) users.User); ok {
-		r0 = rf(id, nickname, email)
+	if rf, ok := ret.Get(0).(func(int64, string) users.User); ok {
+		r0 = rf(id, token)
 	} else {
 		r0 = ret.Get(0).(users.User)
 	}
 
-	if rf, ok := ret.Get(1).(func(int64, string, string) error); ok {
-		r1 = rf(id, nickname, email)
+	if rf, ok := ret.Get(1).(func(int64, string) error); ok {
+		r1 = rf(id, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WebhookDeliveries provides a mock function with given fields: actorID, f
+func (_m *App) WebhookDeliveries(actorID int64, f webhooks.Filter) ([]webhooks.Delivery, error) {
+	ret := _m.Called(actorID, f)
+
+	var r0 []webhooks.Delivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64, webhooks.Filter) ([]webhooks.Delivery, error)); ok {
+		return rf(actorID, f)
+	}
+	if rf, ok := ret.Get(0).(func(int64, webhooks.Filter) []webhooks.Delivery); ok {
+		r0 = rf(actorID, f)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]webhooks.Delivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, webhooks.Filter) error); ok {
+		r1 = rf(actorID, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Webhooks provides a mock function with given fields: actorID
+func (_m *App) Webhooks(actorID int64) ([]webhooks.Subscription, error) {
+	ret := _m.Called(actorID)
+
+	var r0 []webhooks.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64) ([]webhooks.Subscription, error)); ok {
+		return rf(actorID)
+	}
+	if rf, ok := ret.Get(0).(func(int64) []webhooks.Subscription); ok {
+		r0 = rf(actorID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]webhooks.Subscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(actorID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -309,6 +1002,22 @@ func (_m *App) UpdateUser(id int64, nickname string, email string) (users.User,
 	return r0, r1
 }
 
+// WithRequestID provides a mock function with given fields: requestID
+func (_m *App) WithRequestID(requestID string) app.App {
+	ret := _m.Called(requestID)
+
+	var r0 app.App
+	if rf, ok := ret.Get(0).(func(string) app.App); ok {
+		r0 = rf(requestID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(app.App)
+		}
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewApp interface {
 	mock.TestingT
 	Cleanup(func())
@@ -0,0 +1,212 @@
+package tests
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/usersrepo"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/moderation"
+	"homework10/internal/users"
+	"homework10/pkg/adsclient"
+)
+
+const (
+	adminID = 999
+	// автор объявлений в тестах модерации, не зарегистрирован и имеет роль user
+	authorID = 100
+)
+
+// newModeratedApp возвращает app с правилами модерации и ID зарегистрированного в нем модератора
+func newModeratedApp(t *testing.T, repo ads.Repository, opts ...app.Option) (app.App, int64) {
+	opts = append([]app.Option{
+		app.WithModeration(moderation.NewEngine(
+			moderation.BannedWords("scam"),
+			moderation.Links(),
+			moderation.Phones(),
+			moderation.Duplicates(),
+		)),
+		app.WithAdmins(adminID),
+	}, opts...)
+	a := app.NewApp(repo, usersrepo.New(), opts...)
+
+	moderator, err := a.CreateUser("moderator", "moderator@mail.ru", "password")
+	assert.NoError(t, err)
+	_, err = a.SetUserRole(moderator.ID, adminID, users.RoleModerator)
+	assert.NoError(t, err)
+
+	return a, moderator.ID
+}
+
+func TestModerationRules(t *testing.T) {
+	repo := adrepo.New()
+	repo.AddAd(ads.Ad{ID: 0, Title: "Best  Cat", Text: "not for sale", AuthorID: 1})
+	engine := moderation.NewEngine(
+		moderation.BannedWords("Scam"),
+		moderation.Regexp("mentions crypto", regexp.MustCompile(`(?i)bitcoin`)),
+		moderation.Links(),
+		moderation.Phones(),
+		moderation.Duplicates(),
+	)
+
+	type Test struct {
+		Name  string
+		Ad    ads.Ad
+		Flags []string
+	}
+
+	tests := [...]Test{
+		{"clean", ads.Ad{ID: 1, Title: "bike", Text: "almost new", AuthorID: 1}, nil},
+		{"banned word", ads.Ad{ID: 1, Title: "bike", Text: "no SCAM!", AuthorID: 1}, []string{`banned word "scam"`}},
+		{"banned word part", ads.Ad{ID: 1, Title: "bike", Text: "scampi", AuthorID: 1}, nil},
+		{"regexp", ads.Ad{ID: 1, Title: "bike", Text: "pay in Bitcoin", AuthorID: 1}, []string{"mentions crypto"}},
+		{"link", ads.Ad{ID: 1, Title: "bike", Text: "see https://example.org/bike", AuthorID: 1}, []string{"contains a link"}},
+		{"domain", ads.Ad{ID: 1, Title: "bike", Text: "see bikes.ru", AuthorID: 1}, []string{"contains a link"}},
+		{"phone", ads.Ad{ID: 1, Title: "bike", Text: "call +7 (999) 123-45-67", AuthorID: 1}, []string{"contains a phone number"}},
+		{"short number", ads.Ad{ID: 1, Title: "bike", Text: "price 15000", AuthorID: 1}, nil},
+		{"duplicate", ads.Ad{ID: 1, Title: "best cat", Text: "Not for sale", AuthorID: 1}, []string{"duplicate of ad 0"}},
+		{"same text other author", ads.Ad{ID: 1, Title: "best cat", Text: "not for sale", AuthorID: 2}, nil},
+		{"itself", ads.Ad{ID: 0, Title: "best cat", Text: "not for sale", AuthorID: 1}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert.Equal(t, test.Flags, engine.Review(test.Ad, repo))
+		})
+	}
+}
+
+func TestModerationDuplicates(t *testing.T) {
+	a, moderatorID := newModeratedApp(t, adrepo.New())
+
+	first, err := a.CreateAd("Cat", "for sale", authorID)
+	assert.NoError(t, err)
+	_, err = a.CreateAd("cat", "for sale", authorID+1)
+	assert.NoError(t, err)
+
+	dup, err := a.CreateAd("CAT", "for  sale", authorID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"duplicate of ad 0"}, dup.Flags)
+	_, err = a.RejectAd(dup.ID, moderatorID, "duplicate")
+	assert.NoError(t, err)
+
+	// отклоненное объявление дубликатом не считается
+	again, err := a.CreateAd("cat", "for sale", authorID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"duplicate of ad 0"}, again.Flags)
+
+	// после удаления оригинала объявления сдвигаются, а дубликат проходит проверку
+	_, err = a.DeleteAd(first.ID, authorID)
+	assert.NoError(t, err)
+	edited, err := a.UpdateAd(2, authorID, "cat", "for sale")
+	assert.NoError(t, err)
+	assert.Equal(t, again.ID, edited.ID)
+	assert.Equal(t, ads.ModerationApproved, edited.Moderation)
+	assert.Empty(t, edited.Flags)
+}
+
+func TestModerationFlow(t *testing.T) {
+	var events []app.Event
+	a, moderatorID := newModeratedApp(t, adrepo.New(), app.WithEventHandler(func(e app.Event) { events = append(events, e) }))
+
+	clean, err := a.CreateAd("bike", "almost new", authorID)
+	assert.NoError(t, err)
+	assert.Equal(t, ads.ModerationApproved, clean.Moderation)
+
+	flagged, err := a.CreateAd("bike", "call me 89991234567", authorID)
+	assert.NoError(t, err)
+	assert.Equal(t, ads.ModerationPending, flagged.Moderation)
+	assert.Equal(t, []string{"contains a phone number"}, flagged.Flags)
+
+	_, err = a.ChangeAdStatus(clean.ID, authorID, true)
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(flagged.ID, authorID, true)
+	assert.NoError(t, err)
+
+	// опубликованное, но не одобренное объявление не видно остальным
+	list, err := a.GetAds()
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	_, err = a.GetAd(flagged.ID)
+	assert.ErrorIs(t, err, app.AccessErr)
+
+	_, err = a.ModerationQueue(authorID)
+	assert.ErrorIs(t, err, app.AccessErr)
+	queue, err := a.ModerationQueue(moderatorID)
+	assert.NoError(t, err)
+	assert.Len(t, queue, 1)
+	assert.Equal(t, flagged.ID, queue[0].ID)
+
+	_, err = a.RejectAd(flagged.ID, moderatorID, "")
+	assert.ErrorIs(t, err, app.ValidationErr)
+	rejected, err := a.RejectAd(flagged.ID, moderatorID, "no phone numbers please")
+	assert.NoError(t, err)
+	assert.Equal(t, ads.ModerationRejected, rejected.Moderation)
+	assert.Equal(t, "no phone numbers please", rejected.RejectReason)
+
+	// исправленное объявление проходит проверку заново
+	edited, err := a.UpdateAd(flagged.ID, authorID, "bike", "write me here")
+	assert.NoError(t, err)
+	assert.Equal(t, ads.ModerationApproved, edited.Moderation)
+	assert.Empty(t, edited.RejectReason)
+	list, err = a.GetAds()
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	_, err = a.UpdateAd(clean.ID, authorID, "bike", "scam")
+	assert.NoError(t, err)
+	list, err = a.GetAds()
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	approved, err := a.ApproveAd(clean.ID, moderatorID)
+	assert.NoError(t, err)
+	assert.True(t, approved.Visible())
+
+	types := make([]app.EventType, 0, len(events))
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	// автоматическое одобрение при правке отдельного события не дает
+	assert.Equal(t, []app.EventType{app.EventAdCreated, app.EventAdCreated, app.EventAdPublished,
+		app.EventAdPublished, app.EventAdRejected, app.EventAdUpdated, app.EventAdUpdated, app.EventAdApproved}, types)
+}
+
+func TestModerationHTTP(t *testing.T) {
+	a, moderatorID := newModeratedApp(t, adrepo.New())
+	client := getTestClientWithApp(a)
+	ctx := context.Background()
+
+	ad, err := client.CreateAd(ctx, authorID, "bike", "see www.bikes.example")
+	assert.NoError(t, err)
+	assert.Equal(t, adsclient.ModerationPending, ad.Moderation)
+	assert.Equal(t, []string{"contains a link"}, ad.Flags)
+
+	_, err = client.ModerationQueue(ctx, authorID)
+	assert.ErrorIs(t, err, adsclient.ErrForbidden)
+
+	queue, err := client.ModerationQueue(ctx, moderatorID)
+	assert.NoError(t, err)
+	assert.Len(t, queue, 1)
+
+	ad, err = client.RejectAd(ctx, moderatorID, ad.ID, "links are not allowed")
+	assert.NoError(t, err)
+	assert.Equal(t, adsclient.ModerationRejected, ad.Moderation)
+	assert.Equal(t, "links are not allowed", ad.RejectReason)
+
+	ad, err = client.ApproveAd(ctx, moderatorID, ad.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, adsclient.ModerationApproved, ad.Moderation)
+	assert.Empty(t, ad.RejectReason)
+
+	_, err = client.ChangeAdStatus(ctx, authorID, ad.ID, true)
+	assert.NoError(t, err)
+	ad, err = client.GetAd(ctx, ad.ID)
+	assert.NoError(t, err)
+	assert.True(t, ad.Published)
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/usersrepo"
+	"homework10/internal/app"
+	"homework10/internal/ports/httpgin"
+)
+
+var ginParam = regexp.MustCompile(`/:(\w+)`)
+
+func TestOpenAPIMatchesRouter(t *testing.T) {
+	spec, err := httpgin.OpenAPI()
+	assert.NoError(t, err)
+	assert.NoError(t, spec.Validate(context.Background()))
+
+	r := gin.New()
+	a := app.NewApp(adrepo.New(), usersrepo.New())
+	httpgin.AppRouter(r, a)
+	httpgin.AppRouterV2(r, a)
+
+	routes := make([]string, 0)
+	for _, route := range r.Routes() {
+		routes = append(routes, route.Method+" "+ginParam.ReplaceAllString(route.Path, "/{$1}"))
+	}
+
+	documented := make([]string, 0)
+	for path, item := range spec.Paths {
+		for method := range item.Operations() {
+			documented = append(documented, method+" "+path)
+		}
+	}
+
+	sort.Strings(routes)
+	sort.Strings(documented)
+	assert.Equal(t, routes, documented)
+}
+
+func TestSwaggerEndpoints(t *testing.T) {
+	server := httpgin.NewHTTPServer(":18080", app.NewApp(adrepo.New(), usersrepo.New()))
+	testServer := httptest.NewServer(server.Handler())
+	defer testServer.Close()
+
+	resp, err := testServer.Client().Get(testServer.URL + "/swagger/openapi.json")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_ = resp.Body.Close()
+
+	resp, err = testServer.Client().Get(testServer.URL + "/swagger/index.html")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/auditrepo"
+	"homework10/internal/adapters/usersrepo"
+	"homework10/internal/app"
+	"homework10/internal/audit"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/users"
+	"homework10/pkg/adsclient"
+)
+
+func TestRBACPolicy(t *testing.T) {
+	a := app.NewApp(adrepo.New(), usersrepo.New(), app.WithAdmins(adminID), app.WithAuditLog(auditrepo.New()))
+
+	ids := make([]int64, 0, 3)
+	for _, name := range []string{"oleg", "danil", "moderator"} {
+		u, err := a.CreateUser(name, name+"@mail.ru", "password")
+		assert.NoError(t, err)
+		ids = append(ids, u.ID)
+	}
+	user1, user2, moderatorID := ids[0], ids[1], ids[2]
+
+	_, err := a.SetUserRole(moderatorID, user1, users.RoleModerator)
+	assert.ErrorIs(t, err, app.AccessErr)
+	_, err = a.SetUserRole(moderatorID, adminID, "superuser")
+	assert.ErrorIs(t, err, app.ValidationErr)
+	moderator, err := a.SetUserRole(moderatorID, adminID, users.RoleModerator)
+	assert.NoError(t, err)
+	assert.Equal(t, users.RoleModerator, moderator.Role)
+
+	ad, err := a.CreateAd("hello", "world", user1)
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(ad.ID, user1, true)
+	assert.NoError(t, err)
+
+	// модератор может снять с публикации чужое объявление, но не изменить или опубликовать его
+	_, err = a.UpdateAd(ad.ID, moderatorID, "new", "text")
+	assert.ErrorIs(t, err, app.AccessErr)
+	ad, err = a.ChangeAdStatus(ad.ID, moderatorID, false)
+	assert.NoError(t, err)
+	assert.False(t, ad.Published)
+	_, err = a.ChangeAdStatus(ad.ID, moderatorID, true)
+	assert.ErrorIs(t, err, app.AccessErr)
+
+	// пользователь не может удалить чужое объявление или другого пользователя
+	_, err = a.DeleteAd(ad.ID, user2)
+	assert.ErrorIs(t, err, app.AccessErr)
+	_, err = a.DeleteUser(user1, user2)
+	assert.ErrorIs(t, err, app.AccessErr)
+	_, err = a.DeleteUser(user1, moderatorID)
+	assert.ErrorIs(t, err, app.AccessErr)
+
+	_, err = a.DeleteUser(user2, adminID)
+	assert.NoError(t, err)
+	_, err = a.DeleteUser(user1, user1)
+	assert.NoError(t, err)
+
+	// действия модератора и администратора над чужими ресурсами попадают в журнал аудита
+	id := moderatorID
+	entries, err := a.AuditLog(adminID, audit.Filter{ActorID: &id})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, string(app.ActionCreateUser), entries[0].Action)
+	assert.Equal(t, string(app.ActionUnpublishAd), entries[1].Action)
+	assert.Equal(t, string(users.RoleModerator), entries[1].Role)
+	assert.Equal(t, audit.Target("ad", ad.ID), entries[1].Target)
+
+	id = int64(adminID)
+	entries, err = a.AuditLog(adminID, audit.Filter{ActorID: &id})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{string(app.ActionSetRole), string(app.ActionDeleteUser)},
+		[]string{entries[0].Action, entries[1].Action})
+}
+
+func TestRBACCustomPolicy(t *testing.T) {
+	a := app.NewApp(adrepo.New(), usersrepo.New(), app.WithAdmins(adminID),
+		app.WithPolicy(app.Policy{users.RoleAdmin: {app.ActionSetRole}}))
+
+	ad, err := a.CreateAd("hello", "world", 1)
+	assert.NoError(t, err)
+
+	_, err = a.DeleteAd(ad.ID, adminID)
+	assert.ErrorIs(t, err, app.AccessErr)
+}
+
+func TestRBACHTTP(t *testing.T) {
+	client := getTestClientWithApp(app.NewApp(adrepo.New(), usersrepo.New(), app.WithAdmins(adminID)))
+	ctx := context.Background()
+
+	oleg, err := client.CreateUser(ctx, "oleg", "oleg@mail.ru", "password")
+	assert.NoError(t, err)
+	danil, err := client.CreateUser(ctx, "danil", "danil@mail.ru", "password")
+	assert.NoError(t, err)
+	assert.Equal(t, adsclient.RoleUser, danil.Role)
+
+	_, err = client.As(oleg.ID).UpdateUser(ctx, danil.ID, "hacker", "")
+	assert.ErrorIs(t, err, adsclient.ErrForbidden)
+	_, err = client.As(oleg.ID).DeleteUser(ctx, danil.ID)
+	assert.ErrorIs(t, err, adsclient.ErrForbidden)
+	_, err = client.SetUserRole(ctx, oleg.ID, oleg.ID, adsclient.RoleAdmin)
+	assert.ErrorIs(t, err, adsclient.ErrForbidden)
+
+	u, err := client.SetUserRole(ctx, adminID, oleg.ID, adsclient.RoleModerator)
+	assert.NoError(t, err)
+	assert.Equal(t, adsclient.RoleModerator, u.Role)
+
+	ad, err := client.CreateAd(ctx, danil.ID, "hello", "world")
+	assert.NoError(t, err)
+	_, err = client.ChangeAdStatus(ctx, danil.ID, ad.ID, true)
+	assert.NoError(t, err)
+	ad, err = client.ChangeAdStatus(ctx, oleg.ID, ad.ID, false)
+	assert.NoError(t, err)
+	assert.False(t, ad.Published)
+
+	_, err = client.As(adminID).DeleteUser(ctx, danil.ID)
+	assert.NoError(t, err)
+	_, err = client.GetUser(ctx, danil.ID)
+	assert.Error(t, err)
+}
+
+func TestRBACGRPC(t *testing.T) {
+	client := getTestGRPCClient(t)
+	ctx := context.Background()
+
+	ids := make([]int64, 0, 2)
+	for _, name := range []string{"oleg", "danil"} {
+		u, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: name, Email: name + "@mail.ru", Password: "password"})
+		assert.NoError(t, err)
+		ids = append(ids, u.Id)
+	}
+
+	actorID := ids[0]
+	_, err := client.DeleteUser(ctx, &grpcPort.DeleteUserRequest{Id: ids[1], ActorId: &actorID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.UpdateUser(ctx, &grpcPort.UpdateUserRequest{Id: ids[1], Name: "hacker", ActorId: &actorID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.SetUserRole(ctx, &grpcPort.SetUserRoleRequest{Id: ids[0], ActorId: ids[0], Role: "admin"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	u, err := client.UpdateUser(ctx, &grpcPort.UpdateUserRequest{Id: ids[1], Name: "danil"})
+	assert.NoError(t, err)
+	assert.Equal(t, "user", u.Role)
+}
@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/auditrepo"
+	"homework10/internal/adapters/mailbox"
+	"homework10/internal/adapters/usersrepo"
+	"homework10/internal/app"
+	"homework10/internal/audit"
+	"homework10/internal/users"
+	"homework10/pkg/adsclient"
+)
+
+var tokenRe = regexp.MustCompile(`Код подтверждения для пользователя \d+: ([0-9a-f]+)`)
+
+// lastToken достает код подтверждения из последнего письма на адрес email
+func lastToken(t *testing.T, box *mailbox.Mailbox, email string) string {
+	m, ok := box.Last(email)
+	assert.True(t, ok, "no mail to %s", email)
+
+	match := tokenRe.FindStringSubmatch(m.Body)
+	assert.Len(t, match, 2, "no token in %q", m.Body)
+	if len(match) < 2 {
+		return ""
+	}
+	return match[1]
+}
+
+func TestRegistration(t *testing.T) {
+	repo := usersrepo.New()
+	a := app.NewApp(adrepo.New(), repo, app.WithPasswordCost(bcrypt.MinCost),
+		app.WithAdmins(adminID), app.WithAuditLog(auditrepo.New()))
+
+	oleg, err := a.CreateUser("oleg", "oleg@mail.ru", "password1")
+	assert.NoError(t, err)
+	danil, err := a.CreateUser("danil", "danil@mail.ru", "password2")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), oleg.ID)
+	assert.Equal(t, int64(2), danil.ID)
+	assert.Equal(t, users.RoleUser, danil.Role)
+	assert.False(t, danil.EmailVerified)
+
+	// хранится только bcrypt-хеш пароля
+	stored, err := repo.GetById(oleg.ID)
+	assert.NoError(t, err)
+	assert.NotEqual(t, "password1", string(stored.PasswordHash))
+	assert.NoError(t, bcrypt.CompareHashAndPassword(stored.PasswordHash, []byte("password1")))
+
+	type Test struct {
+		Name     string
+		Nickname string
+		Email    string
+		Password string
+		Err      error
+	}
+
+	tests := [...]Test{
+		{Name: "taken nickname", Nickname: "oleg", Email: "new@mail.ru", Password: "password", Err: users.ErrNicknameTaken},
+		{Name: "taken email", Nickname: "new", Email: "OLEG@mail.ru", Password: "password", Err: users.ErrEmailTaken},
+		{Name: "short password", Nickname: "new", Email: "new@mail.ru", Password: "1234567", Err: app.ValidationErr},
+		{Name: "empty nickname", Nickname: "", Email: "new@mail.ru", Password: "password", Err: app.ValidationErr},
+		{Name: "wrong email", Nickname: "new", Email: "new", Password: "password", Err: app.ValidationErr},
+	}
+
+	for _, test := range tests {
+		_, err := a.CreateUser(test.Nickname, test.Email, test.Password)
+		assert.ErrorIs(t, err, test.Err, test.Name)
+	}
+
+	// уникальность проверяется и при изменении пользователя, а освобожденные значения можно занять снова
+	_, err = a.UpdateUser(danil.ID, danil.ID, "oleg", "")
+	assert.ErrorIs(t, err, users.ErrNicknameTaken)
+	_, err = a.DeleteUser(oleg.ID, oleg.ID)
+	assert.NoError(t, err)
+	_, err = a.UpdateUser(danil.ID, danil.ID, "oleg", "oleg@mail.ru")
+	assert.NoError(t, err)
+
+	// хеш пароля и токен подтверждения не попадают в журнал аудита
+	entries, err := a.AuditLog(adminID, audit.Filter{Target: audit.Target("user", danil.ID)})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, entries)
+	for _, e := range entries {
+		assert.NotContains(t, string(e.After), "PasswordHash")
+		assert.NotContains(t, string(e.After), "VerifyToken")
+	}
+}
+
+func TestEmailVerification(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)}
+	box := mailbox.New()
+	a := app.NewApp(adrepo.New(), usersrepo.New(), app.WithPasswordCost(bcrypt.MinCost),
+		app.WithClock(clock), app.WithMailer(box), app.WithVerificationTTL(time.Hour))
+
+	u, err := a.CreateUser("oleg", "oleg@mail.ru", "password")
+	assert.NoError(t, err)
+	token := lastToken(t, box, "oleg@mail.ru")
+
+	_, err = a.VerifyEmail(u.ID, "wrong")
+	assert.ErrorIs(t, err, app.VerificationErr)
+
+	// чужой повтор не отменяет только что отправленный код
+	assert.ErrorIs(t, a.ResendVerification(u.ID), app.ThrottledErr)
+	assert.Len(t, box.Messages("oleg@mail.ru"), 1)
+
+	// просроченный токен не принимается, но можно запросить новый
+	clock.Add(2 * time.Hour)
+	_, err = a.VerifyEmail(u.ID, token)
+	assert.ErrorIs(t, err, app.VerificationErr)
+
+	assert.NoError(t, a.ResendVerification(u.ID))
+	assert.Len(t, box.Messages("oleg@mail.ru"), 2)
+	fresh := lastToken(t, box, "oleg@mail.ru")
+	assert.NotEqual(t, token, fresh)
+	assert.ErrorIs(t, a.ResendVerification(u.ID), app.ThrottledErr)
+
+	u, err = a.VerifyEmail(u.ID, fresh)
+	assert.NoError(t, err)
+	assert.True(t, u.EmailVerified)
+	assert.ErrorIs(t, a.ResendVerification(u.ID), app.ValidationErr)
+
+	// после смены email его нужно подтвердить заново
+	u, err = a.UpdateUser(u.ID, u.ID, "", "new@mail.ru")
+	assert.NoError(t, err)
+	assert.False(t, u.EmailVerified)
+	u, err = a.VerifyEmail(u.ID, lastToken(t, box, "new@mail.ru"))
+	assert.NoError(t, err)
+	assert.True(t, u.EmailVerified)
+}
+
+func TestEmailVerificationHTTP(t *testing.T) {
+	box := mailbox.New()
+	client := getTestClientWithApp(app.NewApp(adrepo.New(), usersrepo.New(),
+		app.WithPasswordCost(bcrypt.MinCost), app.WithMailer(box)))
+	ctx := context.Background()
+
+	u, err := client.CreateUser(ctx, "oleg", "oleg@mail.ru", "password")
+	assert.NoError(t, err)
+	assert.False(t, u.EmailVerified)
+
+	_, err = client.CreateUser(ctx, "oleg", "other@mail.ru", "password")
+	assert.ErrorIs(t, err, adsclient.ErrConflict)
+
+	_, err = client.VerifyEmail(ctx, u.ID, "wrong")
+	assert.ErrorIs(t, err, adsclient.ErrBadRequest)
+
+	// код только что отправлен при регистрации
+	assert.ErrorIs(t, client.ResendVerification(ctx, u.ID), adsclient.ErrTooManyRequests)
+	u, err = client.VerifyEmail(ctx, u.ID, lastToken(t, box, "oleg@mail.ru"))
+	assert.NoError(t, err)
+	assert.True(t, u.EmailVerified)
+
+	u, err = client.GetUser(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.True(t, u.EmailVerified)
+}
@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/usersrepo"
+	"homework10/internal/app"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/pkg/adsclient"
+)
+
+type fakeClock struct {
+	m   sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// runSchedulerOnce выполняет ровно один проход планировщика
+func runSchedulerOnce(t *testing.T, a app.App) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, a.RunScheduler(ctx, time.Hour), context.Canceled)
+}
+
+func TestScheduleAd(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)}
+	repo := adrepo.New()
+	var events []app.Event
+	a := app.NewApp(repo, usersrepo.New(), app.WithClock(clock),
+		app.WithEventHandler(func(e app.Event) { events = append(events, e) }))
+
+	ad, err := a.CreateAd("hello", "world", 123)
+	assert.NoError(t, err)
+
+	publishAt, expiresAt := clock.Now().Add(time.Hour), clock.Now().Add(2*time.Hour)
+	ad, err = a.ScheduleAd(ad.ID, 123, publishAt, expiresAt)
+	assert.NoError(t, err)
+	assert.Equal(t, publishAt, ad.PublishAt)
+	assert.Equal(t, expiresAt, ad.ExpiresAt)
+
+	runSchedulerOnce(t, a)
+	ad, err = repo.GetById(ad.ID)
+	assert.NoError(t, err)
+	assert.False(t, ad.Published)
+	assert.Len(t, events, 1)
+	assert.Equal(t, app.EventAdCreated, events[0].Type)
+
+	clock.Add(time.Hour)
+	runSchedulerOnce(t, a)
+	ad, err = repo.GetById(ad.ID)
+	assert.NoError(t, err)
+	assert.True(t, ad.Published)
+	assert.True(t, ad.PublishAt.IsZero())
+	assert.Len(t, events, 2)
+	assert.Equal(t, app.EventAdPublished, events[1].Type)
+
+	clock.Add(time.Hour)
+	runSchedulerOnce(t, a)
+	runSchedulerOnce(t, a)
+	ad, err = repo.GetById(ad.ID)
+	assert.NoError(t, err)
+	assert.False(t, ad.Published)
+	assert.True(t, ad.ExpiresAt.IsZero())
+	assert.Len(t, events, 3)
+	assert.Equal(t, app.EventAdUnpublished, events[2].Type)
+	assert.Equal(t, clock.Now(), events[2].At)
+}
+
+func TestScheduleAdValidation(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)}
+	a := app.NewApp(adrepo.New(), usersrepo.New(), app.WithClock(clock))
+
+	ad, err := a.CreateAd("hello", "world", 123)
+	assert.NoError(t, err)
+
+	_, err = a.ScheduleAd(ad.ID, 100, clock.Now().Add(time.Hour), time.Time{})
+	assert.ErrorIs(t, err, app.AccessErr)
+
+	_, err = a.ScheduleAd(ad.ID, 123, time.Time{}, clock.Now().Add(-time.Hour))
+	assert.ErrorIs(t, err, app.ValidationErr)
+
+	_, err = a.ScheduleAd(ad.ID, 123, clock.Now().Add(2*time.Hour), clock.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, app.ValidationErr)
+}
+
+func TestScheduleSurvivesRestart(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)}
+	repo := adrepo.New()
+
+	a := app.NewApp(repo, usersrepo.New(), app.WithClock(clock))
+	ad, err := a.CreateAd("hello", "world", 123)
+	assert.NoError(t, err)
+	_, err = a.ScheduleAd(ad.ID, 123, clock.Now().Add(time.Minute), time.Time{})
+	assert.NoError(t, err)
+
+	// новый экземпляр поверх того же хранилища видит расписание и выполняет пропущенный переход
+	clock.Add(time.Hour)
+	var events []app.Event
+	restarted := app.NewApp(repo, usersrepo.New(), app.WithClock(clock),
+		app.WithEventHandler(func(e app.Event) { events = append(events, e) }))
+	runSchedulerOnce(t, restarted)
+
+	ad, err = restarted.GetAd(ad.ID)
+	assert.NoError(t, err)
+	assert.True(t, ad.Published)
+	assert.Len(t, events, 1)
+}
+
+func TestScheduleAdHTTP(t *testing.T) {
+	client := getTestClient()
+	ctx := context.Background()
+
+	ad, err := client.CreateAd(ctx, 123, "hello", "world")
+	assert.NoError(t, err)
+	assert.Nil(t, ad.PublishAt)
+
+	publishAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	ad, err = client.ScheduleAd(ctx, 123, ad.ID, &publishAt, nil)
+	assert.NoError(t, err)
+	assert.True(t, publishAt.Equal(*ad.PublishAt))
+	assert.Nil(t, ad.ExpiresAt)
+
+	ad, err = client.ScheduleAd(ctx, 123, ad.ID, nil, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, ad.PublishAt)
+
+	_, err = client.ScheduleAd(ctx, 100, ad.ID, &publishAt, nil)
+	assert.ErrorIs(t, err, adsclient.ErrForbidden)
+}
+
+func TestScheduleAdGRPC(t *testing.T) {
+	client := getTestGRPCClient(t)
+	ctx := context.Background()
+
+	ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: 123})
+	assert.NoError(t, err)
+
+	expiresAt := time.Now().Add(time.Hour).UTC()
+	ad, err = client.ScheduleAd(ctx, &grpcPort.ScheduleAdRequest{
+		AdId:      ad.Id,
+		UserId:    123,
+		ExpiresAt: timestamppb.New(expiresAt),
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, ad.PublishAt)
+	assert.True(t, expiresAt.Equal(ad.ExpiresAt.AsTime()))
+}
@@ -61,28 +61,28 @@ func TestGRPCUsers(t *testing.T) {
 
 	tests := [...]TestUser{
 		{Name: "create",
-			In: users.User{ID: 15,
+			In: users.User{
 				Nickname: "Oleg",
 				Email:    "email@example.com"},
-			Out: users.User{ID: 15,
+			Out: users.User{ID: 1,
 				Nickname: "Oleg",
 				Email:    "email@example.com"}},
 		{Name: "get",
-			In: users.User{ID: 15},
-			Out: users.User{ID: 15,
+			In: users.User{ID: 1},
+			Out: users.User{ID: 1,
 				Nickname: "Oleg",
 				Email:    "email@example.com"}},
 		{Name: "delete",
-			In: users.User{ID: 15}},
+			In: users.User{ID: 1}},
 	}
 
 	for _, test := range tests {
 		switch test.Name {
 		case "create":
 			res, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{
-				Name:  test.In.Nickname,
-				Id:    test.In.ID,
-				Email: test.In.Email,
+				Name:     test.In.Nickname,
+				Email:    test.In.Email,
+				Password: "password",
 			})
 			assert.NoError(t, err, "client.CreateUser")
 			assert.Equal(t, test.Out.(users.User).Nickname, res.Name)
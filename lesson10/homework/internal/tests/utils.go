@@ -1,338 +1,21 @@
 package tests
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
 	"homework10/internal/adapters/adrepo"
 	"homework10/internal/adapters/usersrepo"
 	"homework10/internal/app"
 	"homework10/internal/ports/httpgin"
-	"io"
-	"net/http"
+	"homework10/pkg/adsclient"
 	"net/http/httptest"
-	"strconv"
-	"strings"
 )
 
-type adData struct {
-	ID        int64  `json:"id"`
-	Title     string `json:"title"`
-	Text      string `json:"text"`
-	AuthorID  int64  `json:"author_id"`
-	Published bool   `json:"published"`
+func getTestClient() *adsclient.Client {
+	return getTestClientWithApp(app.NewApp(adrepo.New(), usersrepo.New()))
 }
 
-type userData struct {
-	ID       int64  `json:"id"`
-	Nickname string `json:"nickname"`
-	Email    string `json:"email"`
-}
-
-type adResponse struct {
-	Data adData `json:"data"`
-}
-
-type userResponse struct {
-	Data userData `json:"data"`
-}
-
-type adsResponse struct {
-	Data []adData `json:"data"`
-}
-
-var (
-	ErrBadRequest = fmt.Errorf("bad request")
-	ErrForbidden  = fmt.Errorf("forbidden")
-)
-
-type testClient struct {
-	client  *http.Client
-	baseURL string
-}
-
-func getTestClient() *testClient {
-	a := app.NewApp(adrepo.New(), usersrepo.New())
+func getTestClientWithApp(a app.App) *adsclient.Client {
 	server := httpgin.NewHTTPServer(":18080", a)
 	testServer := httptest.NewServer(server.Handler())
 
-	return &testClient{
-		client:  testServer.Client(),
-		baseURL: testServer.URL,
-	}
-}
-
-func (tc *testClient) getResponse(req *http.Request, out any) error {
-	resp, err := tc.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("unexpected error: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		if resp.StatusCode == http.StatusBadRequest {
-			return ErrBadRequest
-		}
-		if resp.StatusCode == http.StatusForbidden {
-			return ErrForbidden
-		}
-		return fmt.Errorf("unexpected status code: %s", resp.Status)
-	}
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("unable to read response: %w", err)
-	}
-
-	err = json.Unmarshal(respBody, out)
-	if err != nil {
-		return fmt.Errorf("unable to unmarshal: %w", err)
-	}
-
-	return nil
-}
-
-func (tc *testClient) createAd(userID int64, title string, text string) (adResponse, error) {
-	body := map[string]any{
-		"user_id": userID,
-		"title":   title,
-		"text":    text,
-	}
-
-	data, err := json.Marshal(body)
-	if err != nil {
-		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
-	}
-
-	req, err := http.NewRequest(http.MethodPost, tc.baseURL+"/api/v1/ads", bytes.NewReader(data))
-	if err != nil {
-		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
-	}
-
-	req.Header.Add("Content-Type", "application/json")
-
-	var response adResponse
-	err = tc.getResponse(req, &response)
-	if err != nil {
-		return adResponse{}, err
-	}
-
-	return response, nil
-}
-
-func (tc *testClient) changeAdStatus(userID int64, adID int64, published bool) (adResponse, error) {
-	body := map[string]any{
-		"user_id":   userID,
-		"published": published,
-	}
-
-	data, err := json.Marshal(body)
-	if err != nil {
-		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
-	}
-
-	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/status", adID), bytes.NewReader(data))
-	if err != nil {
-		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
-	}
-
-	req.Header.Add("Content-Type", "application/json")
-
-	var response adResponse
-	err = tc.getResponse(req, &response)
-	if err != nil {
-		return adResponse{}, err
-	}
-
-	return response, nil
-}
-
-func (tc *testClient) updateAd(userID int64, adID int64, title string, text string) (adResponse, error) {
-	body := map[string]any{
-		"user_id": userID,
-		"title":   title,
-		"text":    text,
-	}
-
-	data, err := json.Marshal(body)
-	if err != nil {
-		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
-	}
-
-	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d", adID), bytes.NewReader(data))
-	if err != nil {
-		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
-	}
-
-	req.Header.Add("Content-Type", "application/json")
-
-	var response adResponse
-	err = tc.getResponse(req, &response)
-	if err != nil {
-		return adResponse{}, err
-	}
-
-	return response, nil
-}
-
-func (tc *testClient) listAds() (adsResponse, error) {
-	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/ads", nil)
-	if err != nil {
-		return adsResponse{}, fmt.Errorf("unable to create request: %w", err)
-	}
-
-	var response adsResponse
-	err = tc.getResponse(req, &response)
-	if err != nil {
-		return adsResponse{}, err
-	}
-
-	return response, nil
-}
-
-func (tc *testClient) getAdByID(adID int64) (adResponse, error) {
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d", adID), nil)
-	if err != nil {
-		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
-	}
-
-	var response adResponse
-	err = tc.getResponse(req, &response)
-	if err != nil {
-		return adResponse{}, err
-	}
-
-	return response, nil
-}
-
-func (tc *testClient) getAdsByTitle(title string) (adsResponse, error) {
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/ads/find/%s", title), nil)
-	if err != nil {
-		return adsResponse{}, fmt.Errorf("unable to create request: %w", err)
-	}
-
-	var response adsResponse
-	err = tc.getResponse(req, &response)
-	if err != nil {
-		return adsResponse{}, err
-	}
-
-	return response, nil
-}
-
-func (tc *testClient) getFilteredAds(published int, authorID int, date string) (adsResponse, error) {
-	params := make([]string, 0)
-
-	if published != -1 {
-		params = append(params, fmt.Sprintf("published=%s", strconv.Itoa(published)))
-	}
-	if authorID != -1 {
-		params = append(params, fmt.Sprintf("author=%s", strconv.Itoa(authorID)))
-	}
-	if date != "" {
-		params = append(params, fmt.Sprintf("date=%s", date))
-	}
-
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/ads/filter?%s", strings.Join(params, "&")), nil)
-	if err != nil {
-		return adsResponse{}, fmt.Errorf("unable to create request: %w", err)
-	}
-
-	var response adsResponse
-	err = tc.getResponse(req, &response)
-	if err != nil {
-		return adsResponse{}, err
-	}
-
-	return response, nil
-}
-
-func (tc *testClient) createUser(userID int64, nickname string, email string) (userResponse, error) {
-	body := map[string]any{
-		"user_id":  userID,
-		"nickname": nickname,
-		"email":    email,
-	}
-
-	data, err := json.Marshal(body)
-	if err != nil {
-		return userResponse{}, fmt.Errorf("unable to marshal: %w", err)
-	}
-
-	req, err := http.NewRequest(http.MethodPost, tc.baseURL+"/api/v1/users", bytes.NewReader(data))
-	if err != nil {
-		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
-	}
-
-	req.Header.Add("Content-Type", "application/json")
-
-	var response userResponse
-	err = tc.getResponse(req, &response)
-	if err != nil {
-		return userResponse{}, err
-	}
-
-	return response, nil
-}
-
-func (tc *testClient) getUser(userID int64) (userResponse, error) {
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d", userID), nil)
-	if err != nil {
-		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
-	}
-
-	req.Header.Add("Content-Type", "application/json")
-
-	var response userResponse
-	err = tc.getResponse(req, &response)
-	if err != nil {
-		return userResponse{}, err
-	}
-
-	return response, nil
-}
-
-func (tc *testClient) deleteUser(userID int64) (userResponse, error) {
-	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d", userID), nil)
-	if err != nil {
-		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
-	}
-
-	req.Header.Add("Content-Type", "application/json")
-
-	var response userResponse
-	err = tc.getResponse(req, &response)
-	if err != nil {
-		return userResponse{}, err
-	}
-
-	return response, nil
-}
-
-func (tc *testClient) deleteAd(id int64, authorID int64) (adResponse, error) {
-	body := map[string]any{
-		"id":        id,
-		"author_id": authorID,
-	}
-
-	data, err := json.Marshal(body)
-	if err != nil {
-		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
-	}
-
-	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d", authorID),
-		bytes.NewReader(data))
-
-	if err != nil {
-		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
-	}
-
-	req.Header.Add("Content-Type", "application/json")
-
-	var response adResponse
-	err = tc.getResponse(req, &response)
-	if err != nil {
-		return adResponse{}, err
-	}
-
-	return response, nil
+	return adsclient.New(testServer.URL, adsclient.WithHTTPClient(testServer.Client()))
 }
@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/usersrepo"
+	"homework10/internal/app"
+	"homework10/internal/ports/httpgin"
+)
+
+type v2Ad struct {
+	ID        int64     `json:"id"`
+	Title     string    `json:"title"`
+	Text      string    `json:"text"`
+	AuthorID  int64     `json:"author_id"`
+	Published bool      `json:"published"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type v2User struct {
+	ID        int64     `json:"id"`
+	Nickname  string    `json:"nickname"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type v2Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance"`
+}
+
+type v2Client struct {
+	t   *testing.T
+	srv *httptest.Server
+}
+
+func getTestV2Client(t *testing.T) *v2Client {
+	server := httpgin.NewHTTPServer(":18080", app.NewApp(adrepo.New(), usersrepo.New()))
+	srv := httptest.NewServer(server.Handler())
+	t.Cleanup(srv.Close)
+	return &v2Client{t: t, srv: srv}
+}
+
+// do отправляет запрос и декодирует data успешного ответа в out, а problem+json - в *v2Problem
+func (c *v2Client) do(method string, path string, body string, out any) (*http.Response, *v2Problem) {
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, c.srv.URL+path, r)
+	assert.NoError(c.t, err)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.srv.Client().Do(req)
+	assert.NoError(c.t, err)
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	assert.NoError(c.t, err)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		assert.Equal(c.t, "application/problem+json", resp.Header.Get("Content-Type"))
+		var p v2Problem
+		assert.NoError(c.t, json.Unmarshal(data, &p))
+		return resp, &p
+	}
+
+	if out != nil {
+		assert.NoError(c.t, json.Unmarshal(data, &struct {
+			Data any `json:"data"`
+		}{Data: out}))
+	}
+	return resp, nil
+}
+
+func TestV2Ads(t *testing.T) {
+	c := getTestV2Client(t)
+
+	var ad v2Ad
+	resp, p := c.do(http.MethodPost, "/api/v2/ads", `{"title": "hello", "text": "world", "author_id": 123}`, &ad)
+	assert.Nil(t, p)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "/api/v2/ads/0", resp.Header.Get("Location"))
+	assert.Empty(t, resp.Header.Get("Deprecation"))
+	assert.Equal(t, int64(123), ad.AuthorID)
+	assert.False(t, ad.CreatedAt.IsZero())
+	assert.Equal(t, ad.CreatedAt, ad.UpdatedAt)
+
+	resp, p = c.do(http.MethodGet, "/api/v2/ads/0", "", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, v2Problem{Type: "about:blank", Title: "Forbidden", Status: http.StatusForbidden,
+		Detail: app.AccessErr.Error(), Instance: "/api/v2/ads/0"}, *p)
+
+	resp, p = c.do(http.MethodPatch, "/api/v2/ads/0", `{"published": true}`, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, p.Detail, "actor_id")
+
+	resp, _ = c.do(http.MethodPatch, "/api/v2/ads/0?actor_id=1", `{"published": true}`, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, _ = c.do(http.MethodPatch, "/api/v2/ads/0?actor_id=123", `{"title": ""}`, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	// частичное изменение не трогает текст
+	var patched v2Ad
+	resp, p = c.do(http.MethodPatch, "/api/v2/ads/0?actor_id=123", `{"title": "bye", "published": true}`, &patched)
+	assert.Nil(t, p)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "bye", patched.Title)
+	assert.Equal(t, "world", patched.Text)
+	assert.True(t, patched.Published)
+	assert.Equal(t, ad.CreatedAt, patched.CreatedAt)
+
+	c.do(http.MethodPost, "/api/v2/ads", `{"title": "best cat", "text": "not for sale", "author_id": 7}`, nil)
+
+	var list []v2Ad
+	c.do(http.MethodGet, "/api/v2/ads", "", &list)
+	assert.Len(t, list, 1)
+	c.do(http.MethodGet, "/api/v2/ads?all=true", "", &list)
+	assert.Len(t, list, 2)
+	c.do(http.MethodGet, "/api/v2/ads?all=true&title=cat&author_id=7", "", &list)
+	assert.Len(t, list, 1)
+	resp, _ = c.do(http.MethodGet, "/api/v2/ads?author_id=x", "", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, _ = c.do(http.MethodDelete, "/api/v2/ads/1?actor_id=123", "", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp, p = c.do(http.MethodDelete, "/api/v2/ads/1?actor_id=7", "", nil)
+	assert.Nil(t, p)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, p = c.do(http.MethodGet, "/api/v2/ads/1", "", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "Not Found", p.Title)
+}
+
+func TestV2Users(t *testing.T) {
+	c := getTestV2Client(t)
+
+	var u v2User
+	resp, p := c.do(http.MethodPost, "/api/v2/users", `{"nickname": "ivan", "email": "ivan@example.com", "password": "password"}`, &u)
+	assert.Nil(t, p)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	path := resp.Header.Get("Location")
+	assert.Equal(t, fmt.Sprintf("/api/v2/users/%d", u.ID), path)
+	assert.False(t, u.CreatedAt.IsZero())
+
+	resp, _ = c.do(http.MethodPost, "/api/v2/users", `{"nickname": "ivan", "email": "other@example.com", "password": "password"}`, nil)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp, _ = c.do(http.MethodPost, "/api/v2/users", `{"nickname": "petr", "email": "petr", "password": "password"}`, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	resp, _ = c.do(http.MethodPost, "/api/v2/users", `{"nickname":`, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var patched v2User
+	resp, p = c.do(http.MethodPatch, path, `{"nickname": "ivan2"}`, &patched)
+	assert.Nil(t, p)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "ivan2", patched.Nickname)
+	assert.Equal(t, "ivan@example.com", patched.Email)
+	assert.False(t, patched.UpdatedAt.Before(u.UpdatedAt))
+
+	resp, _ = c.do(http.MethodPatch, path, `{"nickname": ""}`, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	resp, _ = c.do(http.MethodPatch, path+"?actor_id=5", `{"nickname": "hacker"}`, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, _ = c.do(http.MethodDelete, path, "", nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp, _ = c.do(http.MethodGet, path, "", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestV1Deprecated(t *testing.T) {
+	c := getTestV2Client(t)
+
+	resp, _ := c.do(http.MethodGet, "/api/v1/ads", "", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "true", resp.Header.Get("Deprecation"))
+	assert.Equal(t, `</api/v2>; rel="successor-version"`, resp.Header.Get("Link"))
+}
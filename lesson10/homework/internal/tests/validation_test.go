@@ -1,84 +1,95 @@
 package tests
 
 import (
+	"context"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"homework10/pkg/adsclient"
 )
 
 func TestCreateAd_EmptyTitle(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	_, err := client.createAd(123, "", "world")
-	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err := client.CreateAd(ctx, 123, "", "world")
+	assert.ErrorIs(t, err, adsclient.ErrBadRequest)
 }
 
 func TestCreateAd_TooLongTitle(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
 	title := strings.Repeat("a", 101)
 
-	_, err := client.createAd(123, title, "world")
-	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err := client.CreateAd(ctx, 123, title, "world")
+	assert.ErrorIs(t, err, adsclient.ErrBadRequest)
 }
 
 func TestCreateAd_EmptyText(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	_, err := client.createAd(123, "title", "")
-	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err := client.CreateAd(ctx, 123, "title", "")
+	assert.ErrorIs(t, err, adsclient.ErrBadRequest)
 }
 
 func TestCreateAd_TooLongText(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
 	text := strings.Repeat("a", 501)
 
-	_, err := client.createAd(123, "title", text)
-	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err := client.CreateAd(ctx, 123, "title", text)
+	assert.ErrorIs(t, err, adsclient.ErrBadRequest)
 }
 
 func TestUpdateAd_EmptyTitle(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	resp, err := client.createAd(123, "hello", "world")
+	resp, err := client.CreateAd(ctx, 123, "hello", "world")
 	assert.NoError(t, err)
 
-	_, err = client.updateAd(123, resp.Data.ID, "", "new_world")
-	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.UpdateAd(ctx, 123, resp.ID, "", "new_world")
+	assert.ErrorIs(t, err, adsclient.ErrBadRequest)
 }
 
 func TestUpdateAd_TooLongTitle(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	resp, err := client.createAd(123, "hello", "world")
+	resp, err := client.CreateAd(ctx, 123, "hello", "world")
 	assert.NoError(t, err)
 
 	title := strings.Repeat("a", 101)
 
-	_, err = client.updateAd(123, resp.Data.ID, title, "world")
-	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.UpdateAd(ctx, 123, resp.ID, title, "world")
+	assert.ErrorIs(t, err, adsclient.ErrBadRequest)
 }
 
 func TestUpdateAd_EmptyText(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
-	resp, err := client.createAd(123, "hello", "world")
+	resp, err := client.CreateAd(ctx, 123, "hello", "world")
 	assert.NoError(t, err)
 
-	_, err = client.updateAd(123, resp.Data.ID, "title", "")
-	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.UpdateAd(ctx, 123, resp.ID, "title", "")
+	assert.ErrorIs(t, err, adsclient.ErrBadRequest)
 }
 
 func TestUpdateAd_TooLongText(t *testing.T) {
 	client := getTestClient()
+	ctx := context.Background()
 
 	text := strings.Repeat("a", 501)
 
-	resp, err := client.createAd(123, "hello", "world")
+	resp, err := client.CreateAd(ctx, 123, "hello", "world")
 	assert.NoError(t, err)
 
-	_, err = client.updateAd(123, resp.Data.ID, "title", text)
-	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.UpdateAd(ctx, 123, resp.ID, "title", text)
+	assert.ErrorIs(t, err, adsclient.ErrBadRequest)
 }
@@ -0,0 +1,367 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/usersrepo"
+	"homework10/internal/adapters/webhookrepo"
+	"homework10/internal/app"
+	"homework10/internal/webhooks"
+	"homework10/pkg/adsclient"
+)
+
+const webhookSecret = "0123456789abcdef"
+
+// runDispatcher запускает доставку webhook до конца теста
+func runDispatcher(t *testing.T, d *webhooks.Dispatcher) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- d.Run(ctx) }()
+
+	t.Cleanup(func() {
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+	})
+}
+
+// waitDelivery ждет, пока доставка id получит статус status
+func waitDelivery(t *testing.T, a app.App, id int64, status webhooks.Status) webhooks.Delivery {
+	var res webhooks.Delivery
+	assert.Eventually(t, func() bool {
+		list, err := a.WebhookDeliveries(adminID, webhooks.Filter{})
+		assert.NoError(t, err)
+		for _, d := range list {
+			if d.ID == id {
+				res = d
+				return d.Status == status
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+	return res
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	received := make(chan *http.Request, 10)
+	bodies := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		received <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	dispatcher := webhooks.NewDispatcher(webhookrepo.New(), webhooks.WithHTTPClient(server.Client()))
+	a, moderatorID := newModeratedApp(t, adrepo.New(), app.WithWebhooks(dispatcher))
+	runDispatcher(t, dispatcher)
+
+	s, err := a.CreateWebhook(adminID, server.URL, webhookSecret, []string{string(app.EventAdApproved)})
+	assert.NoError(t, err)
+
+	flagged, err := a.CreateAd("bike", "call me 89991234567", authorID)
+	assert.NoError(t, err)
+	_, err = a.ApproveAd(flagged.ID, moderatorID)
+	assert.NoError(t, err)
+	rejected, err := a.CreateAd("bike", "see bikes.ru", authorID)
+	assert.NoError(t, err)
+	_, err = a.RejectAd(rejected.ID, moderatorID, "links are not allowed")
+	assert.NoError(t, err)
+
+	var r *http.Request
+	select {
+	case r = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not delivered")
+	}
+	body := <-bodies
+
+	assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+	assert.Equal(t, "ad.approved", r.Header.Get(webhooks.EventHeader))
+	assert.Equal(t, webhooks.Sign(webhookSecret, r.Header.Get(webhooks.TimestampHeader), body),
+		r.Header.Get(webhooks.SignatureHeader))
+	assert.NotEqual(t, webhooks.Sign("wrong secret", r.Header.Get(webhooks.TimestampHeader), body),
+		r.Header.Get(webhooks.SignatureHeader))
+
+	var payload webhooks.Payload
+	assert.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, "ad.approved", payload.Event)
+	assert.Equal(t, flagged.ID, payload.Ad.ID)
+	assert.Equal(t, "approved", payload.Ad.Moderation)
+
+	list, err := a.WebhookDeliveries(adminID, webhooks.Filter{SubscriptionID: s.ID})
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	d := waitDelivery(t, a, list[0].ID, webhooks.StatusDelivered)
+	assert.Len(t, d.Attempts, 1)
+	assert.Equal(t, http.StatusOK, d.Attempts[0].StatusCode)
+
+	// события без подписки не доставляются
+	select {
+	case r := <-received:
+		t.Fatalf("unexpected event %s", r.Header.Get(webhooks.EventHeader))
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWebhookManualPublish(t *testing.T) {
+	received := make(chan *http.Request, 10)
+	bodies := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		received <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	dispatcher := webhooks.NewDispatcher(webhookrepo.New(), webhooks.WithHTTPClient(server.Client()))
+	a := app.NewApp(adrepo.New(), usersrepo.New(), app.WithAdmins(adminID), app.WithWebhooks(dispatcher))
+	runDispatcher(t, dispatcher)
+
+	_, err := a.CreateWebhook(adminID, server.URL, webhookSecret,
+		[]string{string(app.EventAdPublished), string(app.EventAdUnpublished), string(app.EventAdDeleted)})
+	assert.NoError(t, err)
+
+	ad, err := a.CreateAd("hello", "world", authorID)
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(ad.ID, authorID, true)
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(ad.ID, authorID, false)
+	assert.NoError(t, err)
+	_, err = a.DeleteAd(ad.ID, authorID)
+	assert.NoError(t, err)
+
+	// доставки идут параллельно, поэтому порядок не проверяется
+	events := make(map[string]webhooks.Payload)
+	for i := 0; i < 3; i++ {
+		var r *http.Request
+		select {
+		case r = <-received:
+		case <-time.After(5 * time.Second):
+			t.Fatal("event was not delivered")
+		}
+		body := <-bodies
+
+		assert.Equal(t, webhooks.Sign(webhookSecret, r.Header.Get(webhooks.TimestampHeader), body),
+			r.Header.Get(webhooks.SignatureHeader))
+
+		var payload webhooks.Payload
+		assert.NoError(t, json.Unmarshal(body, &payload))
+		assert.Equal(t, r.Header.Get(webhooks.EventHeader), payload.Event)
+		events[payload.Event] = payload
+	}
+
+	assert.True(t, events["ad.published"].Ad.Published)
+	assert.False(t, events["ad.unpublished"].Ad.Published)
+	assert.Equal(t, ad.ID, events["ad.deleted"].Ad.ID)
+
+	// на создание объявления подписки нет
+	select {
+	case r := <-received:
+		t.Fatalf("unexpected event %s", r.Header.Get(webhooks.EventHeader))
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	dispatcher := webhooks.NewDispatcher(webhookrepo.New(), webhooks.WithHTTPClient(server.Client()),
+		webhooks.WithBackoff(10*time.Millisecond, 20*time.Millisecond), webhooks.WithMaxAttempts(5))
+	a, moderatorID := newModeratedApp(t, adrepo.New(), app.WithWebhooks(dispatcher))
+	runDispatcher(t, dispatcher)
+
+	_, err := a.CreateWebhook(adminID, server.URL, webhookSecret, []string{string(app.EventAdApproved)})
+	assert.NoError(t, err)
+	ad, err := a.CreateAd("bike", "call me 89991234567", authorID)
+	assert.NoError(t, err)
+	_, err = a.ApproveAd(ad.ID, moderatorID)
+	assert.NoError(t, err)
+
+	d := waitDelivery(t, a, 1, webhooks.StatusDelivered)
+	assert.Len(t, d.Attempts, 3)
+	assert.Equal(t, http.StatusServiceUnavailable, d.Attempts[0].StatusCode)
+	assert.Equal(t, "unexpected status 503", d.Attempts[0].Error)
+	assert.Empty(t, d.Attempts[2].Error)
+	// задержка между попытками растет
+	assert.GreaterOrEqual(t, d.Attempts[1].At.Sub(d.Attempts[0].At), 10*time.Millisecond)
+	assert.GreaterOrEqual(t, d.Attempts[2].At.Sub(d.Attempts[1].At), 20*time.Millisecond)
+}
+
+func TestWebhookDeadLetter(t *testing.T) {
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	dispatcher := webhooks.NewDispatcher(webhookrepo.New(), webhooks.WithHTTPClient(server.Client()),
+		webhooks.WithBackoff(time.Millisecond, time.Millisecond), webhooks.WithMaxAttempts(3))
+	a, moderatorID := newModeratedApp(t, adrepo.New(), app.WithWebhooks(dispatcher))
+	runDispatcher(t, dispatcher)
+
+	_, err := a.CreateWebhook(adminID, server.URL, webhookSecret, []string{string(app.EventAdApproved)})
+	assert.NoError(t, err)
+	ad, err := a.CreateAd("bike", "call me 89991234567", authorID)
+	assert.NoError(t, err)
+	_, err = a.ApproveAd(ad.ID, moderatorID)
+	assert.NoError(t, err)
+
+	d := waitDelivery(t, a, 1, webhooks.StatusDead)
+	assert.Len(t, d.Attempts, 3)
+
+	dead, err := a.WebhookDeliveries(adminID, webhooks.Filter{Status: webhooks.StatusDead})
+	assert.NoError(t, err)
+	assert.Len(t, dead, 1)
+
+	healthy.Store(true)
+	redelivered, err := a.RedeliverWebhook(adminID, d.ID)
+	assert.NoError(t, err)
+	assert.NotEqual(t, d.ID, redelivered.ID)
+	assert.Equal(t, d.Payload, redelivered.Payload)
+	redelivered = waitDelivery(t, a, redelivered.ID, webhooks.StatusDelivered)
+	assert.Len(t, redelivered.Attempts, 1)
+}
+
+func TestWebhookQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	dispatcher := webhooks.NewDispatcher(webhookrepo.New(), webhooks.WithHTTPClient(server.Client()),
+		webhooks.WithQueueSize(1), webhooks.WithWorkers(1), webhooks.WithBackoff(10*time.Millisecond, 10*time.Millisecond))
+	a := app.NewApp(adrepo.New(), usersrepo.New(), app.WithAdmins(adminID), app.WithWebhooks(dispatcher))
+
+	_, err := a.CreateWebhook(adminID, server.URL, webhookSecret, []string{string(app.EventAdCreated)})
+	assert.NoError(t, err)
+
+	// до запуска лишние доставки остаются в хранилище и ставятся в очередь при старте
+	for i := 0; i < 3; i++ {
+		_, err = a.CreateAd("bike", "almost new", authorID)
+		assert.NoError(t, err)
+	}
+	runDispatcher(t, dispatcher)
+
+	// во время работы воркер занят, очередь переполнена, доставки откладываются
+	for i := 0; i < 3; i++ {
+		_, err = a.CreateAd("bike", "almost new", authorID)
+		assert.NoError(t, err)
+	}
+	close(release)
+
+	for id := int64(1); id <= 6; id++ {
+		waitDelivery(t, a, id, webhooks.StatusDelivered)
+	}
+}
+
+func TestWebhooksHTTP(t *testing.T) {
+	dispatcher := webhooks.NewDispatcher(webhookrepo.New())
+	a, moderatorID := newModeratedApp(t, adrepo.New(), app.WithWebhooks(dispatcher))
+	client := getTestClientWithApp(a)
+	ctx := context.Background()
+
+	type Test struct {
+		Name    string
+		ActorID int64
+		URL     string
+		Secret  string
+		Events  []string
+		Err     error
+	}
+
+	tests := [...]Test{
+		{Name: "not admin", ActorID: authorID, URL: "http://partner.example/hook", Secret: webhookSecret, Err: adsclient.ErrForbidden},
+		{Name: "moderator", ActorID: moderatorID, URL: "http://partner.example/hook", Secret: webhookSecret, Err: adsclient.ErrForbidden},
+		{Name: "relative url", ActorID: adminID, URL: "/hook", Secret: webhookSecret, Err: adsclient.ErrBadRequest},
+		{Name: "wrong scheme", ActorID: adminID, URL: "ftp://partner.example/hook", Secret: webhookSecret, Err: adsclient.ErrBadRequest},
+		{Name: "short secret", ActorID: adminID, URL: "http://partner.example/hook", Secret: "secret", Err: adsclient.ErrBadRequest},
+		{Name: "unknown event", ActorID: adminID, URL: "http://partner.example/hook", Secret: webhookSecret,
+			Events: []string{"ad.sold"}, Err: adsclient.ErrBadRequest},
+	}
+
+	for _, test := range tests {
+		_, err := client.CreateWebhook(ctx, test.ActorID, test.URL, test.Secret, test.Events)
+		assert.ErrorIs(t, err, test.Err, test.Name)
+	}
+
+	w, err := client.CreateWebhook(ctx, adminID, "http://partner.example/hook", webhookSecret,
+		[]string{"ad.published", "ad.approved"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ad.published", "ad.approved"}, w.Events)
+
+	list, err := client.Webhooks(ctx, adminID)
+	assert.NoError(t, err)
+	assert.Equal(t, []adsclient.Webhook{w}, list)
+
+	// доставки видны до запуска воркеров и ждут в очереди
+	ad, err := a.CreateAd("bike", "call me 89991234567", authorID)
+	assert.NoError(t, err)
+	_, err = a.ApproveAd(ad.ID, moderatorID)
+	assert.NoError(t, err)
+
+	deliveries, err := client.WebhookDeliveries(ctx, adminID, adsclient.DeliveryFilter{WebhookID: w.ID,
+		Status: adsclient.DeliveryPending})
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, "ad.approved", deliveries[0].Event)
+	assert.Empty(t, deliveries[0].Attempts)
+	assert.NotNil(t, deliveries[0].NextAttemptAt)
+	assert.Contains(t, string(deliveries[0].Payload), `"event":"ad.approved"`)
+
+	_, err = client.WebhookDeliveries(ctx, adminID, adsclient.DeliveryFilter{Status: "lost"})
+	assert.ErrorIs(t, err, adsclient.ErrBadRequest)
+
+	assert.NoError(t, client.DeleteWebhook(ctx, adminID, w.ID))
+	assert.ErrorIs(t, client.DeleteWebhook(ctx, adminID, w.ID), adsclient.ErrNotFound)
+	_, err = client.RedeliverWebhook(ctx, adminID, deliveries[0].ID)
+	assert.ErrorIs(t, err, adsclient.ErrNotFound)
+
+	disabled := getTestClientWithApp(app.NewApp(adrepo.New(), usersrepo.New(), app.WithAdmins(adminID)))
+	_, err = disabled.Webhooks(ctx, adminID)
+	var apiErr *adsclient.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotImplemented, apiErr.StatusCode)
+}
+
+func TestWebhookSubscriptionDeleted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	dispatcher := webhooks.NewDispatcher(webhookrepo.New(), webhooks.WithHTTPClient(server.Client()))
+	a, moderatorID := newModeratedApp(t, adrepo.New(), app.WithWebhooks(dispatcher))
+
+	s, err := a.CreateWebhook(adminID, server.URL, webhookSecret, nil)
+	assert.NoError(t, err)
+	ad, err := a.CreateAd("bike", "call me 89991234567", authorID)
+	assert.NoError(t, err)
+	_, err = a.ApproveAd(ad.ID, moderatorID)
+	assert.NoError(t, err)
+
+	// ожидающая доставка по удаленной подписке не отправляется
+	assert.NoError(t, a.DeleteWebhook(adminID, s.ID))
+	runDispatcher(t, dispatcher)
+
+	d := waitDelivery(t, a, 1, webhooks.StatusDead)
+	assert.Equal(t, webhooks.ErrSubscriptionNotFound.Error(), d.Attempts[0].Error)
+}
@@ -1,15 +1,50 @@
 package users
 
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrNicknameTaken = errors.New("nickname is already taken")
+	ErrEmailTaken    = errors.New("email is already taken")
+	ErrUserNotFound  = errors.New("user not found")
+)
+
+// Repository сам назначает ID новым пользователям и следит за уникальностью никнеймов и email
+//
 //go:generate go run github.com/vektra/mockery/v2@v2.20.2 --output=./tests/mocks --name=Repository
 type Repository interface {
-	AddUser(u User) error
+	AddUser(u User) (User, error)
 	GetById(id int64) (User, error)
 	ReplaceByID(id int64, u User) error
 	DeleteByID(id int64) (User, error)
 }
 
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+func (r Role) Valid() bool {
+	return r == RoleUser || r == RoleModerator || r == RoleAdmin
+}
+
+// User - учетная запись пользователя. Хеш пароля и токен подтверждения email
+// не сериализуются, поэтому не попадают в журнал аудита
 type User struct {
-	ID       int64
-	Nickname string
-	Email    string
+	ID            int64
+	Nickname      string
+	Email         string
+	Role          Role
+	EmailVerified bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	PasswordHash  []byte    `json:"-"`
+	VerifyToken   string    `json:"-"`
+	VerifyExpires time.Time `json:"-"`
 }
@@ -0,0 +1,386 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"homework10/internal/ads"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Заголовки запроса, с которыми доставляется событие
+const (
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+const (
+	defaultWorkers     = 4
+	defaultMaxAttempts = 5
+	defaultBackoff     = time.Second
+	defaultMaxBackoff  = 5 * time.Minute
+	defaultQueueSize   = 1024
+	defaultTimeout     = 10 * time.Second
+)
+
+// Payload - тело запроса с событием
+type Payload struct {
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Ad        AdPayload `json:"ad"`
+}
+
+// AdPayload - объявление в том виде, в котором его получают партнеры
+type AdPayload struct {
+	ID         int64     `json:"id"`
+	Title      string    `json:"title"`
+	Text       string    `json:"text"`
+	AuthorID   int64     `json:"author_id"`
+	Published  bool      `json:"published"`
+	Moderation string    `json:"moderation"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Dispatcher сохраняет доставки событий по подпискам и отправляет их пулом воркеров.
+// Неудачные попытки повторяются с экспоненциальной задержкой, после MaxAttempts попыток
+// доставка получает статус StatusDead
+type Dispatcher struct {
+	repo   Repository
+	client *http.Client
+
+	workers     int
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+
+	queueSize int
+	queue     chan int64
+	// queued - доставки в очереди или в работе, чтобы одна доставка не отправлялась дважды
+	queued map[int64]struct{}
+	// postponed - доставки, отложенные из-за переполненной очереди, у каждой не больше одного таймера
+	postponed map[int64]struct{}
+	// done закрывается, когда Run завершается, nil - Run еще не запущен
+	done <-chan struct{}
+	m    sync.Mutex
+}
+
+type Option func(d *Dispatcher)
+
+// WithHTTPClient задает http-клиент, по умолчанию используется клиент с таймаутом 10 секунд
+func WithHTTPClient(c *http.Client) Option {
+	return func(d *Dispatcher) {
+		d.client = c
+	}
+}
+
+// WithWorkers задает число одновременных доставок, по умолчанию 4
+func WithWorkers(n int) Option {
+	return func(d *Dispatcher) {
+		d.workers = n
+	}
+}
+
+// WithMaxAttempts задает число попыток доставки, по умолчанию 5
+func WithMaxAttempts(n int) Option {
+	return func(d *Dispatcher) {
+		d.maxAttempts = n
+	}
+}
+
+// WithQueueSize задает размер очереди доставок, по умолчанию 1024
+func WithQueueSize(n int) Option {
+	return func(d *Dispatcher) {
+		d.queueSize = n
+	}
+}
+
+// WithBackoff задает задержку перед второй попыткой, каждая следующая задержка вдвое больше
+// предыдущей, но не больше max. По умолчанию от секунды до 5 минут
+func WithBackoff(base time.Duration, max time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.backoff, d.maxBackoff = base, max
+	}
+}
+
+func NewDispatcher(repo Repository, opts ...Option) *Dispatcher {
+	d := &Dispatcher{repo: repo,
+		client:      &http.Client{Timeout: defaultTimeout},
+		workers:     defaultWorkers,
+		maxAttempts: defaultMaxAttempts,
+		backoff:     defaultBackoff,
+		maxBackoff:  defaultMaxBackoff,
+		queueSize:   defaultQueueSize,
+		queued:      make(map[int64]struct{}),
+		postponed:   make(map[int64]struct{})}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.queue = make(chan int64, d.queueSize)
+
+	return d
+}
+
+func (d *Dispatcher) Subscribe(s Subscription) (Subscription, error) {
+	return d.repo.AddSubscription(s)
+}
+
+// Unsubscribe удаляет подписку. Ожидающие доставки по ней получат статус StatusDead
+func (d *Dispatcher) Unsubscribe(id int64) (Subscription, error) {
+	return d.repo.DeleteSubscription(id)
+}
+
+func (d *Dispatcher) Subscriptions() ([]Subscription, error) {
+	return d.repo.Subscriptions()
+}
+
+func (d *Dispatcher) Deliveries(f Filter) ([]Delivery, error) {
+	return d.repo.Deliveries(f)
+}
+
+// Redeliver создает новую доставку с тем же событием, попытки считаются заново
+func (d *Dispatcher) Redeliver(id int64) (Delivery, error) {
+	old, err := d.repo.GetDelivery(id)
+	if err != nil {
+		return Delivery{}, err
+	}
+
+	if _, err := d.repo.GetSubscription(old.SubscriptionID); err != nil {
+		return Delivery{}, err
+	}
+
+	now := time.Now().UTC()
+	res, err := d.repo.AddDelivery(Delivery{SubscriptionID: old.SubscriptionID, Event: old.Event,
+		Payload: old.Payload, Status: StatusPending, NextAttemptAt: now, CreatedAt: now})
+	if err != nil {
+		return Delivery{}, err
+	}
+
+	d.enqueue(res.ID)
+	return res, nil
+}
+
+// Publish создает доставки события по всем подпискам на него и ставит их в очередь
+func (d *Dispatcher) Publish(event string, at time.Time, ad ads.Ad) error {
+	subs, err := d.repo.Subscriptions()
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(Payload{Event: event, CreatedAt: at, Ad: AdPayload{ID: ad.ID, Title: ad.Title,
+		Text: ad.Text, AuthorID: ad.AuthorID, Published: ad.Published, Moderation: string(ad.Moderation),
+		CreatedAt: ad.CreateDate, UpdatedAt: ad.LastUpdate}})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, s := range subs {
+		if !s.Wants(event) {
+			continue
+		}
+
+		res, err := d.repo.AddDelivery(Delivery{SubscriptionID: s.ID, Event: event, Payload: payload,
+			Status: StatusPending, NextAttemptAt: now, CreatedAt: now})
+		if err != nil {
+			return err
+		}
+		d.enqueue(res.ID)
+	}
+	return nil
+}
+
+// Run запускает воркеры и работает, пока не отменен ctx. Доставки, оставшиеся в хранилище
+// в статусе StatusPending, например после перезапуска, ставятся в очередь при старте
+func (d *Dispatcher) Run(ctx context.Context) error {
+	d.m.Lock()
+	d.done = ctx.Done()
+	d.m.Unlock()
+
+	pending, err := d.repo.Deliveries(Filter{Status: StatusPending})
+	if err != nil {
+		return err
+	}
+	for _, p := range pending {
+		d.retryAt(ctx, p.ID, p.NextAttemptAt)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < d.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-d.queue:
+					d.deliver(ctx, id)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	return ctx.Err()
+}
+
+// enqueue ставит доставку в очередь, если ее там еще нет. При переполненной очереди
+// доставка откладывается на время первой повторной попытки, пока Run работает.
+// До запуска и после остановки Run доставка остается в хранилище в статусе StatusPending,
+// и следующий Run поставит ее в очередь при старте
+func (d *Dispatcher) enqueue(id int64) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if _, ok := d.queued[id]; ok {
+		return
+	}
+
+	select {
+	case d.queue <- id:
+		d.queued[id] = struct{}{}
+		return
+	default:
+	}
+
+	if d.stopped() {
+		log.Printf("webhooks: queue is full, delivery %d left pending", id)
+		return
+	}
+	if _, ok := d.postponed[id]; ok {
+		return
+	}
+
+	log.Printf("webhooks: queue is full, delivery %d postponed", id)
+	d.postponed[id] = struct{}{}
+	time.AfterFunc(d.backoff, func() {
+		d.m.Lock()
+		delete(d.postponed, id)
+		d.m.Unlock()
+
+		d.enqueue(id)
+	})
+}
+
+// stopped сообщает, что Run не запущен или уже завершается. Вызывается под d.m
+func (d *Dispatcher) stopped() bool {
+	if d.done == nil {
+		return true
+	}
+
+	select {
+	case <-d.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryAt ставит доставку в очередь в момент at, если к нему ctx еще не отменен
+func (d *Dispatcher) retryAt(ctx context.Context, id int64, at time.Time) {
+	time.AfterFunc(time.Until(at), func() {
+		if ctx.Err() == nil {
+			d.enqueue(id)
+		}
+	})
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, id int64) {
+	defer func() {
+		d.m.Lock()
+		delete(d.queued, id)
+		d.m.Unlock()
+	}()
+
+	dl, err := d.repo.GetDelivery(id)
+	if err != nil || dl.Status != StatusPending {
+		return
+	}
+
+	var attempt Attempt
+	s, err := d.repo.GetSubscription(dl.SubscriptionID)
+	if err != nil {
+		// подписку удалили, пока доставка ждала очереди
+		attempt = Attempt{At: time.Now().UTC(), Error: err.Error()}
+		dl.Status = StatusDead
+	} else {
+		attempt = d.send(ctx, s, dl)
+	}
+	dl.Attempts = append(dl.Attempts, attempt)
+
+	switch {
+	case dl.Status == StatusDead:
+	case attempt.Error == "":
+		dl.Status = StatusDelivered
+	case len(dl.Attempts) >= d.maxAttempts:
+		dl.Status = StatusDead
+	default:
+		dl.NextAttemptAt = attempt.At.Add(d.delay(len(dl.Attempts)))
+	}
+
+	if err := d.repo.ReplaceDelivery(dl); err != nil {
+		log.Printf("webhooks: unable to save delivery %d: %s", dl.ID, err.Error())
+		return
+	}
+	if dl.Status == StatusPending {
+		d.retryAt(ctx, dl.ID, dl.NextAttemptAt)
+	}
+}
+
+// delay возвращает задержку после attempts неудачных попыток
+func (d *Dispatcher) delay(attempts int) time.Duration {
+	delay := d.backoff
+	for i := 1; i < attempts && delay < d.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.maxBackoff {
+		delay = d.maxBackoff
+	}
+	return delay
+}
+
+func (d *Dispatcher) send(ctx context.Context, s Subscription, dl Delivery) (attempt Attempt) {
+	start := time.Now()
+	attempt.At = start.UTC()
+	defer func() {
+		attempt.Duration = time.Since(start)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(dl.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	timestamp := strconv.FormatInt(start.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, dl.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(dl.ID, 10))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(s.Secret, timestamp, dl.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		attempt.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+	return attempt
+}
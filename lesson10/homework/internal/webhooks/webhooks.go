@@ -0,0 +1,110 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var (
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrDeliveryNotFound     = errors.New("webhook delivery not found")
+)
+
+// Repository хранит подписки партнеров и историю доставки событий по ним
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.20.2 --output=./tests/mocks --name=Repository
+type Repository interface {
+	// AddSubscription назначает подписке ID
+	AddSubscription(s Subscription) (Subscription, error)
+	GetSubscription(id int64) (Subscription, error)
+	DeleteSubscription(id int64) (Subscription, error)
+	// Subscriptions возвращает подписки в порядке создания
+	Subscriptions() ([]Subscription, error)
+
+	// AddDelivery назначает доставке ID
+	AddDelivery(d Delivery) (Delivery, error)
+	GetDelivery(id int64) (Delivery, error)
+	ReplaceDelivery(d Delivery) error
+	// Deliveries возвращает подходящие доставки в порядке создания
+	Deliveries(f Filter) ([]Delivery, error)
+}
+
+// Subscription - адрес, на который отправляются события Events. Пустой список - все события
+type Subscription struct {
+	ID  int64
+	URL string
+	// Secret - ключ подписи HMAC, в ответы API и журнал аудита не попадает
+	Secret    string `json:"-"`
+	Events    []string
+	CreatedAt time.Time
+}
+
+// Wants проверяет, подписан ли партнер на событие
+func (s Subscription) Wants(event string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+type Status string
+
+const (
+	// StatusPending - доставка ожидает очередной попытки
+	StatusPending Status = "pending"
+	// StatusDelivered - получатель ответил 2xx
+	StatusDelivered Status = "delivered"
+	// StatusDead - попытки исчерпаны, доставка попала в список недоставленных
+	StatusDead Status = "dead"
+)
+
+// Delivery - доставка одного события по одной подписке
+type Delivery struct {
+	ID             int64
+	SubscriptionID int64
+	Event          string
+	Payload        []byte
+	Status         Status
+	Attempts       []Attempt
+	// NextAttemptAt - время следующей попытки для доставок в статусе pending
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+}
+
+// Attempt - попытка доставки. StatusCode равен 0, если ответ не получен
+type Attempt struct {
+	At         time.Time
+	StatusCode int
+	Error      string
+	Duration   time.Duration
+}
+
+// Filter - критерии отбора доставок, нулевые значения полей не фильтруют
+type Filter struct {
+	SubscriptionID int64
+	Status         Status
+}
+
+func (f Filter) Match(d Delivery) bool {
+	return (f.SubscriptionID == 0 || d.SubscriptionID == f.SubscriptionID) &&
+		(f.Status == "" || d.Status == f.Status)
+}
+
+// Sign возвращает подпись тела запроса: hex(HMAC-SHA256(secret, timestamp + "." + body)).
+// Получатель считает ее так же и сравнивает с заголовком SignatureHeader
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,134 @@
+package adsclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type Ad struct {
+	ID        int64      `json:"id"`
+	Title     string     `json:"title"`
+	Text      string     `json:"text"`
+	AuthorID  int64      `json:"author_id"`
+	Published bool       `json:"published"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	Moderation   string   `json:"moderation"`
+	Flags        []string `json:"flags,omitempty"`
+	RejectReason string   `json:"reject_reason,omitempty"`
+}
+
+// AdFilter - критерии для FilterAds, нулевые значения полей не фильтруют
+type AdFilter struct {
+	OnlyPublished bool
+	AuthorID      *int64
+	CreatedOn     time.Time
+}
+
+// CreateAd создает объявление от имени пользователя userID
+func (c *Client) CreateAd(ctx context.Context, userID int64, title string, text string) (Ad, error) {
+	body := map[string]any{
+		"user_id": userID,
+		"title":   title,
+		"text":    text,
+	}
+
+	var ad Ad
+	err := c.do(ctx, http.MethodPost, "/api/v1/ads", nil, body, &ad)
+	return ad, err
+}
+
+// ListAds возвращает все опубликованные объявления
+func (c *Client) ListAds(ctx context.Context) ([]Ad, error) {
+	var res []Ad
+	err := c.do(ctx, http.MethodGet, "/api/v1/ads", nil, nil, &res)
+	return res, err
+}
+
+// GetAd возвращает опубликованное объявление по его ID
+func (c *Client) GetAd(ctx context.Context, adID int64) (Ad, error) {
+	var ad Ad
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/ads/%d", adID), nil, nil, &ad)
+	return ad, err
+}
+
+// ChangeAdStatus публикует или снимает с публикации объявление
+func (c *Client) ChangeAdStatus(ctx context.Context, userID int64, adID int64, published bool) (Ad, error) {
+	body := map[string]any{
+		"user_id":   userID,
+		"published": published,
+	}
+
+	var ad Ad
+	err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/v1/ads/%d/status", adID), nil, body, &ad)
+	return ad, err
+}
+
+// UpdateAd обновляет заголовок и текст объявления
+func (c *Client) UpdateAd(ctx context.Context, userID int64, adID int64, title string, text string) (Ad, error) {
+	body := map[string]any{
+		"user_id": userID,
+		"title":   title,
+		"text":    text,
+	}
+
+	var ad Ad
+	err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/v1/ads/%d", adID), nil, body, &ad)
+	return ad, err
+}
+
+// ScheduleAd планирует публикацию и снятие с публикации объявления,
+// nil отменяет соответствующий переход
+func (c *Client) ScheduleAd(ctx context.Context, userID int64, adID int64, publishAt, expiresAt *time.Time) (Ad, error) {
+	body := map[string]any{
+		"user_id":    userID,
+		"publish_at": publishAt,
+		"expires_at": expiresAt,
+	}
+
+	var ad Ad
+	err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/v1/ads/%d/schedule", adID), nil, body, &ad)
+	return ad, err
+}
+
+// FindAds возвращает опубликованные объявления, заголовок которых содержит title
+func (c *Client) FindAds(ctx context.Context, title string) ([]Ad, error) {
+	var res []Ad
+	err := c.do(ctx, http.MethodGet, "/api/v1/ads/find/"+url.PathEscape(title), nil, nil, &res)
+	return res, err
+}
+
+// FilterAds возвращает объявления, подходящие под фильтр
+func (c *Client) FilterAds(ctx context.Context, f AdFilter) ([]Ad, error) {
+	query := url.Values{}
+	if f.OnlyPublished {
+		query.Set("published", "1")
+	}
+	if f.AuthorID != nil {
+		query.Set("author", strconv.FormatInt(*f.AuthorID, 10))
+	}
+	if !f.CreatedOn.IsZero() {
+		query.Set("date", f.CreatedOn.Format(time.DateOnly))
+	}
+
+	var res []Ad
+	err := c.do(ctx, http.MethodGet, "/api/v1/ads/filter", query, nil, &res)
+	return res, err
+}
+
+// DeleteAd удаляет объявление автора authorID
+func (c *Client) DeleteAd(ctx context.Context, adID int64, authorID int64) (Ad, error) {
+	body := map[string]any{
+		"id":        adID,
+		"author_id": authorID,
+	}
+
+	var ad Ad
+	err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/api/v1/ads/%d", adID), nil, body, &ad)
+	return ad, err
+}
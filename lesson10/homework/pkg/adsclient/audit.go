@@ -0,0 +1,63 @@
+package adsclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// AuditEntry - запись журнала аудита об изменяющей операции
+type AuditEntry struct {
+	Seq       int64           `json:"seq"`
+	At        time.Time       `json:"at"`
+	ActorID   int64           `json:"actor_id"`
+	Role      string          `json:"role"`
+	Action    string          `json:"action"`
+	Target    string          `json:"target"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	RequestID string          `json:"request_id,omitempty"`
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `json:"hash"`
+}
+
+// AuditFilter - критерии выборки записей журнала аудита, нулевые значения полей не фильтруют
+type AuditFilter struct {
+	ActorID *int64
+	Target  string // например "ad:42" или "user:7"
+	From    time.Time
+	To      time.Time
+}
+
+func (f AuditFilter) query(adminID int64) url.Values {
+	query := url.Values{"user_id": {strconv.FormatInt(adminID, 10)}}
+	if f.ActorID != nil {
+		query.Set("actor_id", strconv.FormatInt(*f.ActorID, 10))
+	}
+	if f.Target != "" {
+		query.Set("target", f.Target)
+	}
+	if !f.From.IsZero() {
+		query.Set("from", f.From.Format(time.RFC3339))
+	}
+	if !f.To.IsZero() {
+		query.Set("to", f.To.Format(time.RFC3339))
+	}
+	return query
+}
+
+// AuditLog возвращает записи журнала аудита от имени администратора adminID
+func (c *Client) AuditLog(ctx context.Context, adminID int64, f AuditFilter) ([]AuditEntry, error) {
+	var res []AuditEntry
+	err := c.do(ctx, http.MethodGet, "/api/v1/audit", f.query(adminID), nil, &res)
+	return res, err
+}
+
+// ExportAuditLog возвращает поток записей журнала аудита в jsonl, закрыть его должен вызывающий
+func (c *Client) ExportAuditLog(ctx context.Context, adminID int64, f AuditFilter) (io.ReadCloser, error) {
+	return c.stream(ctx, "/api/v1/audit:export", f.query(adminID))
+}
@@ -0,0 +1,100 @@
+package adsclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type Format string
+
+const (
+	FormatJSONL Format = "jsonl"
+	FormatCSV   Format = "csv"
+)
+
+type ImportError struct {
+	Line  int64  `json:"line"`
+	Error string `json:"error"`
+}
+
+type ImportResult struct {
+	Total    int64         `json:"total"`
+	Imported int64         `json:"imported"`
+	Failed   int64         `json:"failed"`
+	DryRun   bool          `json:"dry_run"`
+	Errors   []ImportError `json:"errors"`
+}
+
+// ImportAds потоково отправляет записи из r. Запрос не повторяется и не ограничивается
+// таймаутом клиента, так как тело может быть очень большим. Ключ из WithIdempotencyKey
+// защищает от повторного импорта, если вызывающий отправит те же записи еще раз
+func (c *Client) ImportAds(ctx context.Context, r io.Reader, format Format, dryRun bool) (ImportResult, error) {
+	query := url.Values{
+		"format":  {string(format)},
+		"dry_run": {strconv.FormatBool(dryRun)},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/ads:import?"+query.Encode(), r)
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	if format == FormatCSV {
+		req.Header.Set("Content-Type", "text/csv")
+	} else {
+		req.Header.Set("Content-Type", "application/x-ndjson")
+	}
+	if key := keyFrom(ctx); key != "" {
+		req.Header.Set(idempotencyKeyHeader, key)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("unable to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("unable to read response: %w", err)
+	}
+
+	var res ImportResult
+	err = decode(resp.StatusCode, body, &res)
+	return res, err
+}
+
+// ExportAds возвращает поток опубликованных объявлений в заданном формате, закрыть его должен вызывающий.
+// Клиент из As с ID администратора получает все объявления, включая неопубликованные
+func (c *Client) ExportAds(ctx context.Context, format Format) (io.ReadCloser, error) {
+	query := c.actorQuery(url.Values{"format": {string(format)}})
+	return c.stream(ctx, "/api/v1/ads:export", query)
+}
+
+// stream выполняет GET-запрос и возвращает тело успешного ответа без чтения в память
+func (c *Client) stream(ctx context.Context, path string, query url.Values) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to send request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read response: %w", err)
+		}
+		return nil, decode(resp.StatusCode, body, nil)
+	}
+
+	return resp.Body, nil
+}
@@ -0,0 +1,227 @@
+// Package adsclient - типизированный клиент для REST API сервиса объявлений
+package adsclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout    = 10 * time.Second
+	defaultBackoff    = 100 * time.Millisecond
+	defaultMaxBackoff = 2 * time.Second
+)
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	timeout    time.Duration
+	retries    int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	actorID    *int64
+}
+
+type Option func(*Client)
+
+// WithHTTPClient задает http.Client, через который отправляются запросы
+func WithHTTPClient(c *http.Client) Option {
+	return func(client *Client) {
+		client.httpClient = c
+	}
+}
+
+// WithTimeout задает таймаут одной попытки запроса
+func WithTimeout(d time.Duration) Option {
+	return func(client *Client) {
+		client.timeout = d
+	}
+}
+
+// WithRetries задает число повторных попыток для идемпотентных запросов
+func WithRetries(n int) Option {
+	return func(client *Client) {
+		client.retries = n
+	}
+}
+
+// WithBackoff задает начальную и максимальную паузу между повторными попытками,
+// пауза удваивается после каждой неудачной попытки
+func WithBackoff(base, max time.Duration) Option {
+	return func(client *Client) {
+		client.backoff = base
+		client.maxBackoff = max
+	}
+}
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		timeout:    defaultTimeout,
+		backoff:    defaultBackoff,
+		maxBackoff: defaultMaxBackoff,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{}
+	}
+
+	return c
+}
+
+// As возвращает копию клиента, которая изменяет и удаляет пользователей и выгружает объявления
+// от имени actorID, например администратора. По умолчанию пользователь действует сам над собой
+func (c *Client) As(actorID int64) *Client {
+	cp := *c
+	cp.actorID = &actorID
+	return &cp
+}
+
+// CloseIdleConnections закрывает неиспользуемые соединения http.Client
+func (c *Client) CloseIdleConnections() {
+	c.httpClient.CloseIdleConnections()
+}
+
+type envelope struct {
+	Data  json.RawMessage `json:"data"`
+	Error *string         `json:"error"`
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, body any, out any) error {
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("unable to marshal: %w", err)
+		}
+	}
+
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		status, respBody, err := c.send(ctx, method, u, data)
+		if c.shouldRetry(method, keyFrom(ctx) != "", status, err) && attempt < c.retries {
+			if err := c.wait(ctx, attempt); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		return decode(status, respBody, out)
+	}
+}
+
+func (c *Client) send(ctx context.Context, method string, u string, data []byte) (int, []byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to create request: %w", err)
+	}
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if key := keyFrom(ctx); key != "" {
+		req.Header.Set(idempotencyKeyHeader, key)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to read response: %w", err)
+	}
+
+	return resp.StatusCode, respBody, nil
+}
+
+// shouldRetry повторяет только идемпотентные запросы: POST без ключа идемпотентности может создать дубликат
+func (c *Client) shouldRetry(method string, hasKey bool, status int, err error) bool {
+	if method == http.MethodPost && !hasKey {
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *Client) wait(ctx context.Context, attempt int) error {
+	d := c.backoff << attempt
+	if d > c.maxBackoff || d <= 0 {
+		d = c.maxBackoff
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func decode(status int, body []byte, out any) error {
+	var env envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		if status != http.StatusOK {
+			return &APIError{StatusCode: status, Message: http.StatusText(status)}
+		}
+		return fmt.Errorf("unable to unmarshal: %w", err)
+	}
+
+	if status != http.StatusOK {
+		msg := http.StatusText(status)
+		if env.Error != nil {
+			msg = *env.Error
+		}
+		return &APIError{StatusCode: status, Message: msg}
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(env.Data, out); err != nil {
+		return fmt.Errorf("unable to unmarshal: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package adsclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	ErrBadRequest = errors.New("bad request")
+	ErrForbidden  = errors.New("forbidden")
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	// ErrUnprocessable - ключ идемпотентности уже использован с другим запросом
+	ErrUnprocessable = errors.New("unprocessable entity")
+	// ErrTooManyRequests - запрос повторяется чаще, чем разрешает сервер
+	ErrTooManyRequests = errors.New("too many requests")
+	ErrInternal        = errors.New("internal server error")
+)
+
+// APIError - ошибка, которую вернул сервер в поле "error" конверта ответа.
+// Проверять категорию ошибки следует через errors.Is с ErrBadRequest, ErrForbidden и т.д.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("adsclient: status %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusBadRequest:
+		return ErrBadRequest
+	case e.StatusCode == http.StatusForbidden:
+		return ErrForbidden
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusConflict:
+		return ErrConflict
+	case e.StatusCode == http.StatusUnprocessableEntity:
+		return ErrUnprocessable
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrTooManyRequests
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrInternal
+	default:
+		return nil
+	}
+}
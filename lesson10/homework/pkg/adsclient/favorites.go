@@ -0,0 +1,84 @@
+package adsclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SavedSearch - сохраненный поиск, о новых подходящих объявлениях сервис присылает уведомления
+type SavedSearch struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Name      string    `json:"name"`
+	AuthorID  *int64    `json:"author_id,omitempty"`
+	Date      string    `json:"date,omitempty"`
+	Title     string    `json:"title,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SearchCriteria - критерии сохраненного поиска, нулевые значения полей не фильтруют
+type SearchCriteria struct {
+	AuthorID  *int64
+	CreatedOn time.Time
+	Title     string
+}
+
+// AddFavorite добавляет объявление в избранное пользователя и возвращает избранное целиком
+func (c *Client) AddFavorite(ctx context.Context, userID int64, adID int64) ([]Ad, error) {
+	var res []Ad
+	err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/v1/users/%d/favorites/%d", userID, adID), nil, nil, &res)
+	return res, err
+}
+
+// RemoveFavorite удаляет объявление из избранного пользователя и возвращает избранное целиком
+func (c *Client) RemoveFavorite(ctx context.Context, userID int64, adID int64) ([]Ad, error) {
+	var res []Ad
+	err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/api/v1/users/%d/favorites/%d", userID, adID), nil, nil, &res)
+	return res, err
+}
+
+// Favorites возвращает избранные объявления пользователя
+func (c *Client) Favorites(ctx context.Context, userID int64) ([]Ad, error) {
+	var res []Ad
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/users/%d/favorites", userID), nil, nil, &res)
+	return res, err
+}
+
+// SaveSearch сохраняет поиск пользователя под именем name
+func (c *Client) SaveSearch(ctx context.Context, userID int64, name string, sc SearchCriteria) (SavedSearch, error) {
+	body := map[string]any{
+		"name":  name,
+		"title": sc.Title,
+	}
+	if sc.AuthorID != nil {
+		body["author_id"] = *sc.AuthorID
+	}
+	if !sc.CreatedOn.IsZero() {
+		body["date"] = sc.CreatedOn.Format(time.DateOnly)
+	}
+
+	var s SavedSearch
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/users/%d/searches", userID), nil, body, &s)
+	return s, err
+}
+
+// SavedSearches возвращает сохраненные поиски пользователя
+func (c *Client) SavedSearches(ctx context.Context, userID int64) ([]SavedSearch, error) {
+	var res []SavedSearch
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/users/%d/searches", userID), nil, nil, &res)
+	return res, err
+}
+
+// DeleteSearch удаляет сохраненный поиск пользователя
+func (c *Client) DeleteSearch(ctx context.Context, userID int64, searchID int64) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/api/v1/users/%d/searches/%d", userID, searchID), nil, nil, nil)
+}
+
+// RunSearch возвращает объявления, подходящие под сохраненный поиск
+func (c *Client) RunSearch(ctx context.Context, userID int64, searchID int64) ([]Ad, error) {
+	var res []Ad
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/users/%d/searches/%d/ads", userID, searchID), nil, nil, &res)
+	return res, err
+}
@@ -0,0 +1,19 @@
+package adsclient
+
+import "context"
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey возвращает контекст, запросы с которым отправляются с заголовком Idempotency-Key.
+// Сервер выполнит создание или импорт с этим ключом один раз, поэтому такие запросы повторяются
+// так же, как GET. Ключ должен быть уникальным для каждой операции, например UUID
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+func keyFrom(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	return key
+}
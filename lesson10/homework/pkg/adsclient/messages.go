@@ -0,0 +1,72 @@
+package adsclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Thread - переписка покупателя с автором объявления
+type Thread struct {
+	ID            int64     `json:"id"`
+	AdID          int64     `json:"ad_id"`
+	AuthorID      int64     `json:"author_id"`
+	BuyerID       int64     `json:"buyer_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	LastMessageAt time.Time `json:"last_message_at"`
+	// непрочитанные сообщения пользователя, запросившего переписку
+	Unread int `json:"unread"`
+}
+
+type Message struct {
+	ID       int64     `json:"id"`
+	ThreadID int64     `json:"thread_id"`
+	SenderID int64     `json:"sender_id"`
+	Text     string    `json:"text"`
+	SentAt   time.Time `json:"sent_at"`
+}
+
+// ContactAuthor отправляет сообщение автору объявления. Переписка создается при первом сообщении
+func (c *Client) ContactAuthor(ctx context.Context, userID int64, adID int64, text string) (Message, error) {
+	body := map[string]any{
+		"user_id": userID,
+		"text":    text,
+	}
+
+	var m Message
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/ads/%d/messages", adID), nil, body, &m)
+	return m, err
+}
+
+// SendMessage отправляет сообщение в переписку, в которой участвует пользователь
+func (c *Client) SendMessage(ctx context.Context, userID int64, threadID int64, text string) (Message, error) {
+	body := map[string]any{
+		"text": text,
+	}
+
+	var m Message
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/users/%d/threads/%d/messages", userID, threadID), nil, body, &m)
+	return m, err
+}
+
+// Threads возвращает переписки пользователя, начиная с последней активной
+func (c *Client) Threads(ctx context.Context, userID int64) ([]Thread, error) {
+	var res []Thread
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/users/%d/threads", userID), nil, nil, &res)
+	return res, err
+}
+
+// Messages возвращает сообщения переписки в порядке отправки
+func (c *Client) Messages(ctx context.Context, userID int64, threadID int64) ([]Message, error) {
+	var res []Message
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/users/%d/threads/%d/messages", userID, threadID), nil, nil, &res)
+	return res, err
+}
+
+// MarkThreadRead отмечает прочитанными сообщения переписки, адресованные пользователю
+func (c *Client) MarkThreadRead(ctx context.Context, userID int64, threadID int64) (Thread, error) {
+	var t Thread
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/users/%d/threads/%d/read", userID, threadID), nil, nil, &t)
+	return t, err
+}
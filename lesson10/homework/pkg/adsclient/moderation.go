@@ -0,0 +1,49 @@
+package adsclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Статусы модерации из поля Ad.Moderation
+const (
+	ModerationPending  = "pending_review"
+	ModerationApproved = "approved"
+	ModerationRejected = "rejected"
+)
+
+// ModerationQueue возвращает объявления, ожидающие ручной проверки модератором moderatorID
+func (c *Client) ModerationQueue(ctx context.Context, moderatorID int64) ([]Ad, error) {
+	query := url.Values{}
+	query.Set("user_id", strconv.FormatInt(moderatorID, 10))
+
+	var res []Ad
+	err := c.do(ctx, http.MethodGet, "/api/v1/moderation/ads", query, nil, &res)
+	return res, err
+}
+
+// ApproveAd одобряет объявление от имени модератора moderatorID
+func (c *Client) ApproveAd(ctx context.Context, moderatorID int64, adID int64) (Ad, error) {
+	body := map[string]any{
+		"user_id": moderatorID,
+	}
+
+	var ad Ad
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/moderation/ads/%d/approve", adID), nil, body, &ad)
+	return ad, err
+}
+
+// RejectAd отклоняет объявление от имени модератора moderatorID, reason увидит автор
+func (c *Client) RejectAd(ctx context.Context, moderatorID int64, adID int64, reason string) (Ad, error) {
+	body := map[string]any{
+		"user_id": moderatorID,
+		"reason":  reason,
+	}
+
+	var ad Ad
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/moderation/ads/%d/reject", adID), nil, body, &ad)
+	return ad, err
+}
@@ -0,0 +1,103 @@
+package adsclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type User struct {
+	ID       int64  `json:"user_id"`
+	Nickname string `json:"nickname"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+
+	EmailVerified bool `json:"email_verified"`
+}
+
+// Роли пользователей из поля User.Role
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// CreateUser регистрирует пользователя, ID назначает сервер.
+// Никнейм и email должны быть уникальными, иначе возвращается ошибка ErrConflict
+func (c *Client) CreateUser(ctx context.Context, nickname string, email string, password string) (User, error) {
+	body := map[string]any{
+		"nickname": nickname,
+		"email":    email,
+		"password": password,
+	}
+
+	var u User
+	err := c.do(ctx, http.MethodPost, "/api/v1/users", nil, body, &u)
+	return u, err
+}
+
+// GetUser возвращает пользователя по его ID
+func (c *Client) GetUser(ctx context.Context, userID int64) (User, error) {
+	var u User
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/users/%d", userID), nil, nil, &u)
+	return u, err
+}
+
+// UpdateUser обновляет никнейм и email пользователя, пустые значения не изменяются
+func (c *Client) UpdateUser(ctx context.Context, userID int64, nickname string, email string) (User, error) {
+	body := map[string]any{
+		"nickname": nickname,
+		"email":    email,
+	}
+
+	// сервер читает ID пользователя из query-параметра, а не из пути
+	query := c.actorQuery(url.Values{"user_id": {strconv.FormatInt(userID, 10)}})
+
+	var u User
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/users/%d", userID), query, body, &u)
+	return u, err
+}
+
+// DeleteUser удаляет пользователя по его ID
+func (c *Client) DeleteUser(ctx context.Context, userID int64) (User, error) {
+	var u User
+	err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/api/v1/users/%d", userID), c.actorQuery(url.Values{}), nil, &u)
+	return u, err
+}
+
+// SetUserRole назначает пользователю роль от имени администратора actorID
+func (c *Client) SetUserRole(ctx context.Context, actorID int64, userID int64, role string) (User, error) {
+	body := map[string]any{
+		"actor_id": actorID,
+		"role":     role,
+	}
+
+	var u User
+	err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/v1/users/%d/role", userID), nil, body, &u)
+	return u, err
+}
+
+// VerifyEmail подтверждает email пользователя кодом из письма
+func (c *Client) VerifyEmail(ctx context.Context, userID int64, token string) (User, error) {
+	body := map[string]any{
+		"token": token,
+	}
+
+	var u User
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/users/%d/verify", userID), nil, body, &u)
+	return u, err
+}
+
+// ResendVerification повторно отправляет пользователю код подтверждения email
+func (c *Client) ResendVerification(ctx context.Context, userID int64) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/users/%d/verification", userID), nil, nil, nil)
+}
+
+func (c *Client) actorQuery(query url.Values) url.Values {
+	if c.actorID != nil {
+		query.Set("actor_id", strconv.FormatInt(*c.actorID, 10))
+	}
+	return query
+}
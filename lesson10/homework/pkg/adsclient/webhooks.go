@@ -0,0 +1,102 @@
+package adsclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Webhook - подписка партнера на события объявлений
+type Webhook struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookAttempt - попытка доставки события. StatusCode равен 0, если ответ не получен
+type WebhookAttempt struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// WebhookDelivery - доставка события по подписке
+type WebhookDelivery struct {
+	ID            int64            `json:"id"`
+	WebhookID     int64            `json:"webhook_id"`
+	Event         string           `json:"event"`
+	Payload       json.RawMessage  `json:"payload"`
+	Status        string           `json:"status"`
+	Attempts      []WebhookAttempt `json:"attempts"`
+	NextAttemptAt *time.Time       `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time        `json:"created_at"`
+}
+
+// Статусы доставки
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// DeliveryFilter - критерии выборки доставок, нулевые значения полей не фильтруют
+type DeliveryFilter struct {
+	WebhookID int64
+	Status    string
+}
+
+func adminQuery(adminID int64) url.Values {
+	return url.Values{"user_id": {strconv.FormatInt(adminID, 10)}}
+}
+
+// CreateWebhook подписывает url на события от имени администратора adminID. Пустой events - все события
+func (c *Client) CreateWebhook(ctx context.Context, adminID int64, webhookURL string, secret string, events []string) (Webhook, error) {
+	body := map[string]any{
+		"url":    webhookURL,
+		"secret": secret,
+		"events": events,
+	}
+
+	var w Webhook
+	err := c.do(ctx, http.MethodPost, "/api/v1/webhooks", adminQuery(adminID), body, &w)
+	return w, err
+}
+
+func (c *Client) Webhooks(ctx context.Context, adminID int64) ([]Webhook, error) {
+	var res []Webhook
+	err := c.do(ctx, http.MethodGet, "/api/v1/webhooks", adminQuery(adminID), nil, &res)
+	return res, err
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, adminID int64, id int64) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/api/v1/webhooks/%d", id), adminQuery(adminID), nil, nil)
+}
+
+// WebhookDeliveries возвращает доставки событий вместе с попытками
+func (c *Client) WebhookDeliveries(ctx context.Context, adminID int64, f DeliveryFilter) ([]WebhookDelivery, error) {
+	query := adminQuery(adminID)
+	if f.WebhookID != 0 {
+		query.Set("webhook_id", strconv.FormatInt(f.WebhookID, 10))
+	}
+	if f.Status != "" {
+		query.Set("status", f.Status)
+	}
+
+	var res []WebhookDelivery
+	err := c.do(ctx, http.MethodGet, "/api/v1/webhooks/deliveries", query, nil, &res)
+	return res, err
+}
+
+// RedeliverWebhook повторно отправляет событие из доставки id и возвращает новую доставку
+func (c *Client) RedeliverWebhook(ctx context.Context, adminID int64, id int64) (WebhookDelivery, error) {
+	var d WebhookDelivery
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/webhooks/deliveries/%d/redeliver", id),
+		adminQuery(adminID), nil, &d)
+	return d, err
+}
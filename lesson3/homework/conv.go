@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+// runeBuffer склеивает руны UTF-8, которые граница блока разрезала на две записи
+type runeBuffer struct {
+	pending []byte
+}
+
+// complete возвращает pending и p без незавершенной руны в конце, а ее начало
+// сохраняет до следующей записи
+func (b *runeBuffer) complete(p []byte) []byte {
+	data := p
+	if len(b.pending) > 0 {
+		data = append(b.pending, p...)
+		b.pending = nil
+	}
+
+	// начало последней руны находится не дальше utf8.UTFMax байт от конца
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if !utf8.RuneStart(data[i]) {
+			continue
+		}
+		if !utf8.FullRune(data[i:]) {
+			b.pending = append([]byte(nil), data[i:]...)
+			data = data[:i]
+		}
+		break
+	}
+	return data
+}
+
+// flush возвращает незавершенную руну: в конце потока ее уже не дополнить
+func (b *runeBuffer) flush() []byte {
+	data := b.pending
+	b.pending = nil
+	return data
+}
+
+// closeOutput закрывает следующий writer цепочки, чтобы он тоже дописал буферизованные данные
+func closeOutput(output io.Writer) error {
+	if c, ok := output.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// mapWriter применяет fn к целым рунам. Write возвращает длину p, так как fn может менять
+// длину данных в байтах
+type mapWriter struct {
+	output io.Writer
+	fn     func([]byte) []byte
+	runes  runeBuffer
+}
+
+func (m *mapWriter) Write(p []byte) (int, error) {
+	if data := m.runes.complete(p); len(data) > 0 {
+		if _, err := m.output.Write(m.fn(data)); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (m *mapWriter) Close() error {
+	if data := m.runes.flush(); len(data) > 0 {
+		if _, err := m.output.Write(m.fn(data)); err != nil {
+			return err
+		}
+	}
+	return closeOutput(m.output)
+}
+
+func NewUpperCase(output io.Writer) io.WriteCloser {
+	return &mapWriter{output: output, fn: bytes.ToUpper}
+}
+
+func NewLowerCase(output io.Writer) io.WriteCloser {
+	return &mapWriter{output: output, fn: bytes.ToLower}
+}
+
+// trimSpace убирает пробельные символы в начале и в конце всего потока. Пробелы внутри
+// потока придерживаются, пока не придет непробельный символ, поэтому длинная серия
+// пробелов целиком хранится в памяти
+type trimSpace struct {
+	output  io.Writer
+	runes   runeBuffer
+	started bool   // уже записан непробельный символ
+	spaces  []byte // пробелы, которые могут оказаться концом потока
+}
+
+func NewTrimSpace(output io.Writer) io.WriteCloser {
+	return &trimSpace{output: output}
+}
+
+func (t *trimSpace) Write(p []byte) (int, error) {
+	data := t.runes.complete(p)
+
+	if !t.started {
+		data = bytes.TrimLeftFunc(data, unicode.IsSpace)
+		if len(data) == 0 {
+			return len(p), nil
+		}
+		t.started = true
+	}
+
+	body := bytes.TrimRightFunc(data, unicode.IsSpace)
+	if len(body) > 0 {
+		if len(t.spaces) > 0 {
+			if _, err := t.output.Write(t.spaces); err != nil {
+				return 0, err
+			}
+			t.spaces = t.spaces[:0]
+		}
+		if _, err := t.output.Write(body); err != nil {
+			return 0, err
+		}
+	}
+	t.spaces = append(t.spaces, data[len(body):]...)
+
+	return len(p), nil
+}
+
+// Close отбрасывает пробелы в конце потока. Незавершенная руна не пробел, поэтому
+// записывается вместе с пробелами перед ней
+func (t *trimSpace) Close() error {
+	if tail := t.runes.flush(); len(tail) > 0 {
+		if t.started {
+			if _, err := t.output.Write(t.spaces); err != nil {
+				return err
+			}
+		}
+		if _, err := t.output.Write(tail); err != nil {
+			return err
+		}
+	}
+	return closeOutput(t.output)
+}
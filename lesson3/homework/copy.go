@@ -0,0 +1,224 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// defaultBlockSize - размер блока, если -block-size не задан
+const defaultBlockSize = 32 * 1024
+
+// maxReadErrors - сколько ошибок чтения подряд пропускается с conv=noerror
+const maxReadErrors = 16
+
+var errOffsetTooBig = errors.New("offset is bigger than file size. unable to read the file")
+
+// errOutput - поток, куда conv=noerror сообщает о пропущенных ошибках чтения
+var errOutput io.Writer = os.Stderr
+
+// blockSize возвращает размер блока чтения и записи
+func (opts *Options) blockSize() int64 {
+	if opts.BlockSize > 0 {
+		return opts.BlockSize
+	}
+	return defaultBlockSize
+}
+
+// inputBlockSize возвращает размер блока чтения
+func (opts *Options) inputBlockSize() int64 {
+	if opts.InputBlockSize > 0 {
+		return opts.InputBlockSize
+	}
+	return opts.blockSize()
+}
+
+// outputBlockSize возвращает размер блока записи
+func (opts *Options) outputBlockSize() int64 {
+	if opts.OutputBlockSize > 0 {
+		return opts.OutputBlockSize
+	}
+	return opts.blockSize()
+}
+
+// Copy пропускает opts.Offset байт из in и копирует в out не больше opts.Limit байт
+// (все до EOF, если Limit отрицательный) и не больше opts.Count блоков. Чтение идет
+// блоками не больше inputBlockSize байт, поэтому в памяти одновременно хранится только
+// один блок. conv=sync дополняет неполные блоки нулями до размера блока, conv=noerror
+// сообщает об ошибке чтения в errOutput и продолжает со следующего блока.
+// Возвращает количество прочитанных после пропуска байт
+func Copy(out io.Writer, in io.Reader, opts *Options) (int64, error) {
+	buf := make([]byte, opts.inputBlockSize())
+
+	if err := skip(in, opts.Offset, buf); err != nil {
+		return 0, err
+	}
+
+	src := in
+	var limited *io.LimitedReader
+	if opts.Limit >= 0 {
+		limited = &io.LimitedReader{R: in, N: opts.Limit}
+		in = limited
+	}
+
+	var copied int64
+	readErrors := 0
+	for blocks := int64(0); opts.Count <= 0 || blocks < opts.Count; blocks++ {
+		n, err := in.Read(buf)
+		copied += int64(n)
+		if opts.Stats != nil {
+			opts.Stats.addIn(n, len(buf))
+		}
+		failed := false
+
+		if err != nil && !errors.Is(err, io.EOF) && opts.Conv["noerror"] {
+			readErrors++
+			if readErrors > maxReadErrors {
+				return copied, err
+			}
+			_, _ = fmt.Fprintln(errOutput, "error with reading: ", err)
+
+			// плохой блок пропускается целиком, если вход это позволяет
+			if seeker, ok := src.(io.Seeker); ok {
+				rest := int64(len(buf) - n)
+				if limited != nil && rest > limited.N {
+					rest = limited.N
+				}
+				if _, serr := seeker.Seek(rest, io.SeekCurrent); serr == nil && limited != nil {
+					limited.N -= rest
+				}
+			}
+			err, failed = nil, true
+		} else if err == nil {
+			readErrors = 0
+		}
+
+		// с conv=sync блок с ошибкой чтения заменяется нулями
+		if (n > 0 || failed) && n < len(buf) && opts.Conv["sync"] {
+			for i := n; i < len(buf); i++ {
+				buf[i] = 0
+			}
+			n = len(buf)
+		}
+
+		if n > 0 {
+			if _, werr := out.Write(buf[:n]); werr != nil {
+				return copied, werr
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			return copied, nil
+		}
+		if err != nil {
+			return copied, err
+		}
+	}
+	return copied, nil
+}
+
+// skip пропускает offset байт из in. Обычный файл сдвигается через Seek, остальные потоки
+// читаются блоками в buf и отбрасываются
+func skip(in io.Reader, offset int64, buf []byte) error {
+	if offset == 0 {
+		return nil
+	}
+
+	if f, ok := in.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			pos, err := f.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return err
+			}
+			if pos+offset > info.Size() {
+				return errOffsetTooBig
+			}
+			_, err = f.Seek(offset, io.SeekCurrent)
+			return err
+		}
+	}
+
+	for offset > 0 {
+		chunk := buf
+		if int64(len(chunk)) > offset {
+			chunk = chunk[:offset]
+		}
+
+		n, err := in.Read(chunk)
+		offset -= int64(n)
+		if offset == 0 {
+			return nil
+		}
+		if errors.Is(err, io.EOF) {
+			return errOffsetTooBig
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// obsWriter собирает вывод в блоки ровно по size байт, как dd с obs=. Последний
+// неполный блок записывается в Close
+type obsWriter struct {
+	output io.Writer
+	buf    []byte
+}
+
+func NewBlockWriter(output io.Writer, size int64) io.WriteCloser {
+	return &obsWriter{output: output, buf: make([]byte, 0, size)}
+}
+
+func (w *obsWriter) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		// целые блоки пишутся сразу, без копирования в буфер
+		if len(w.buf) == 0 && len(p) >= cap(w.buf) {
+			if _, err := w.output.Write(p[:cap(w.buf)]); err != nil {
+				return 0, err
+			}
+			p = p[cap(w.buf):]
+			continue
+		}
+
+		n := cap(w.buf) - len(w.buf)
+		if n > len(p) {
+			n = len(p)
+		}
+		w.buf = append(w.buf, p[:n]...)
+		p = p[n:]
+
+		if len(w.buf) == cap(w.buf) {
+			if _, err := w.output.Write(w.buf); err != nil {
+				return 0, err
+			}
+			w.buf = w.buf[:0]
+		}
+	}
+	return written, nil
+}
+
+func (w *obsWriter) Close() error {
+	if len(w.buf) > 0 {
+		if _, err := w.output.Write(w.buf); err != nil {
+			return err
+		}
+		w.buf = w.buf[:0]
+	}
+	return closeOutput(w.output)
+}
+
+// syncFile сбрасывает данные на диск перед закрытием файла, как dd с conv=fsync
+type syncFile struct {
+	*os.File
+}
+
+func (f syncFile) Close() error {
+	if err := f.File.Sync(); err != nil {
+		_ = f.File.Close()
+		return err
+	}
+	return f.File.Close()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// blockWriter запоминает размер самой большой записи
+type blockWriter struct {
+	bytes.Buffer
+	maxWrite int
+}
+
+func (w *blockWriter) Write(p []byte) (int, error) {
+	if len(p) > w.maxWrite {
+		w.maxWrite = len(p)
+	}
+	return w.Buffer.Write(p)
+}
+
+// blockReader запоминает размер самого большого запроса на чтение
+type blockReader struct {
+	io.Reader
+	maxRead int
+}
+
+func (r *blockReader) Read(p []byte) (int, error) {
+	if len(p) > r.maxRead {
+		r.maxRead = len(p)
+	}
+	return r.Reader.Read(p)
+}
+
+// copyConverted копирует input блоками blockSize через преобразование newConv
+func copyConverted(t *testing.T, input string, blockSize int64, newConv func(io.Writer) io.WriteCloser) string {
+	out := &bytes.Buffer{}
+	w := newConv(out)
+
+	opts := &Options{Limit: -1, BlockSize: blockSize}
+	_, err := Copy(w, strings.NewReader(input), opts)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	return out.String()
+}
+
+func TestCopyBlocks(t *testing.T) {
+	in := &blockReader{Reader: strings.NewReader(testInput)}
+	out := &blockWriter{}
+
+	n, err := Copy(out, in, &Options{Offset: 10, Limit: 500, BlockSize: 7})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(500), n)
+	assert.Equal(t, testInput[10:510], out.String())
+	assert.Equal(t, 7, in.maxRead)
+	assert.Equal(t, 7, out.maxWrite)
+
+	_, err = Copy(out, strings.NewReader("test"), &Options{Offset: 5, Limit: -1, BlockSize: 2})
+	assert.ErrorIs(t, err, errOffsetTooBig)
+}
+
+func TestCopySeeksRegularFile(t *testing.T) {
+	name := path.Join(t.TempDir(), "input.txt")
+	assert.NoError(t, os.WriteFile(name, []byte(testInput), 0o600))
+
+	f, err := os.Open(name)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	out := &bytes.Buffer{}
+	_, err = Copy(out, f, &Options{Offset: 1200, Limit: 100, BlockSize: 16})
+	assert.NoError(t, err)
+	assert.Equal(t, testInput[1200:1300], out.String())
+
+	_, err = f.Seek(0, io.SeekStart)
+	assert.NoError(t, err)
+	_, err = Copy(out, f, &Options{Offset: int64(len(testInput)) + 1, Limit: -1})
+	assert.ErrorIs(t, err, errOffsetTooBig)
+}
+
+func TestConversionsAcrossBlocks(t *testing.T) {
+	// руны от одного до четырех байт в начале, середине и конце каждого блока
+	input := "  　 hELlO Машинное 😊🎉 обучение  \n\t " + testInput + " ß日本   "
+
+	for blockSize := int64(1); blockSize <= 9; blockSize++ {
+		assert.Equal(t, strings.ToUpper(input), copyConverted(t, input, blockSize, NewUpperCase), "block size %d", blockSize)
+		assert.Equal(t, strings.ToLower(input), copyConverted(t, input, blockSize, NewLowerCase), "block size %d", blockSize)
+		assert.Equal(t, strings.TrimSpace(input), copyConverted(t, input, blockSize, NewTrimSpace), "block size %d", blockSize)
+	}
+}
+
+func TestTrimSpaceWholeStream(t *testing.T) {
+	// пробелы внутри потока сохраняются, даже если блок заканчивается или начинается с них
+	assert.Equal(t, "a  　 b", copyConverted(t, " 　 a  　 b 　 ", 2, NewTrimSpace))
+	assert.Equal(t, "", copyConverted(t, " 　\n ", 1, NewTrimSpace))
+
+	// обрезанная руна в конце потока не теряется
+	assert.Equal(t, "a \xe3\x80", copyConverted(t, "a \xe3\x80", 1, NewTrimSpace))
+	assert.Equal(t, strings.ToUpper("a\xe3\x80"), copyConverted(t, "a\xe3\x80", 1, NewUpperCase))
+}
@@ -2,7 +2,10 @@ module lecture03_homework
 
 go 1.19
 
-require github.com/stretchr/testify v1.8.2
+require (
+	github.com/stretchr/testify v1.8.2
+	golang.org/x/text v0.9.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
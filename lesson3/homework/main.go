@@ -1,15 +1,15 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"math"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 )
 
 // Options хранит настройки чтения и записи
@@ -19,7 +19,21 @@ type Options struct {
 	Conv      map[string]bool // параметры форматирования
 	Offset    int64           // кол-во игнорируемых байт
 	Limit     int64           // максимальное кол-во считываемых байт
-	BlockSize int64           // максимальное кол-во байт, хранимых в памяти одновременно
+	BlockSize int64           // максимальное кол-во байт, читаемых и записываемых за один раз
+
+	InputBlockSize  int64  // размер блока чтения, по умолчанию BlockSize
+	OutputBlockSize int64  // размер блока записи, если задан - вывод собирается в блоки этого размера
+	Count           int64  // максимальное кол-во читаемых блоков, 0 - без ограничения
+	Seek            int64  // кол-во байт, пропускаемых в начале вывода
+	Status          string // какую статистику печатать в stderr, см. status*
+	Stats           *Stats // если задана, Copy учитывает в ней блоки чтения
+
+	Conversions []string // преобразования текста из Conv в порядке, заданном в -conv
+	Decompress  []string // распаковки ввода из Conv в порядке, заданном в -conv
+
+	Digests    []string // контрольные суммы: sha256, crc32
+	DigestOf   string   // поток, для которого считаются суммы: input, output или both
+	DigestFile string   // файл для сумм, по умолчанию stderr
 }
 
 // SetDefault устанавливает значения настроек по умолчанию
@@ -30,80 +44,36 @@ func (opts *Options) SetDefault() {
 	opts.Offset = 0
 	opts.Limit = math.MaxInt
 	opts.BlockSize = -1
+	opts.DigestOf = digestOfOutput
 }
 
-type upperCase struct {
-	output io.Writer
-}
-
-func NewUpperCase(output io.Writer) io.Writer {
-	return &upperCase{output}
-}
-
-func (u *upperCase) Write(p []byte) (int, error) {
-	data := bytes.ToUpper(p)
-	return u.output.Write(data)
-}
-
-type trimSpace struct {
-	output io.Writer
-}
-
-func NewTrimSpace(output io.Writer) io.Writer {
-	return &trimSpace{output}
-}
-
-func (u *trimSpace) Write(p []byte) (int, error) {
-	data := bytes.TrimSpace(p)
-	return u.output.Write(data)
-}
-
-type lowerCase struct {
-	output io.Writer
-}
-
-func NewLowerCase(output io.Writer) io.Writer {
-	return &lowerCase{output}
-}
-
-func (u *lowerCase) Write(p []byte) (int, error) {
-	data := bytes.ToLower(p)
-	return u.output.Write(data)
-}
-
-// SectionReader считывает limit байт, пропуская при этом offset байт
-func SectionReader(in io.Reader, offset int64, limit int64) ([]byte, error) {
-	var (
-		err  error
-		data []byte
-	)
-
-	reader := bufio.NewReader(in)
-	// пропуск offset байт из начала ввода
-	_, err = reader.Discard(int(offset))
-	if err != nil {
-		return data, errors.New("offset is bigger than file size." +
-			" unable to read the file")
-	}
+// значения status. Без status статистика не печатается, как и раньше, а в синтаксисе dd
+// по умолчанию используется statusDefault
+const (
+	statusNone     = "none"     // ничего не печатать
+	statusDefault  = "default"  // итог: блоки, объем, время и скорость
+	statusNoXfer   = "noxfer"   // итог без объема, времени и скорости
+	statusProgress = "progress" // строка прогресса во время копирования и итог
+)
 
-	// по умолчанию limit равен -1, поэтому стоит такое условие
-	// точно известно, что если limit > 0 изначально, то в какой-то момент либо закончится файл,
-	// либо limit станет равен нулю и ввод завершится
-	for limit != 0 {
-		readedByte, err := reader.ReadByte()
+// blockConvs - параметры conv, которые меняют чтение и запись блоков, а не текст
+var blockConvs = map[string]bool{"notrunc": true, "sync": true, "noerror": true, "fsync": true, "sparse": true}
 
-		if err == io.EOF {
-			break
+// addConv добавляет параметры conv из списка через запятую. Преобразования текста
+// и распаковки запоминаются в порядке первого упоминания
+func (opts *Options) addConv(list string) {
+	for _, arg := range strings.Split(list, ",") {
+		if arg == "" || opts.Conv[arg] {
+			continue
 		}
-
-		if err != nil {
-			return data, errors.New("unable to read byte")
+		if conversions[arg] != nil {
+			opts.Conversions = append(opts.Conversions, arg)
 		}
-		data = append(data, readedByte)
-		limit--
+		if decompressors[arg] != nil {
+			opts.Decompress = append(opts.Decompress, arg)
+		}
+		opts.Conv[arg] = true
 	}
-
-	return data, err
 }
 
 // validateFlags проверяет все флаги на валидность
@@ -116,15 +86,17 @@ func validateFlags(options *Options) error {
 				" unable to read the file", options.From)
 		}
 
-		// проверка того, что размер файла больше кол-ва игнорируемых байт
+		// проверка того, что размер файла больше кол-ва игнорируемых байт. При распаковке
+		// offset отсчитывается в распакованных данных и проверяется при копировании
 		f, _ := os.Stat(options.From)
-		if f.Size() < options.Offset && options.Offset > 0 {
+		if f.Size() < options.Offset && options.Offset > 0 && len(options.Decompress) == 0 {
 			return errors.New("offset is bigger than file size." +
 				" unable to read the file")
 		}
 	}
 
-	if options.To != "" {
+	// с conv=notrunc запись идет поверх существующего файла, только если это запрошено явно
+	if options.To != "" && !options.Conv["notrunc"] {
 		// проверка того, что не существует файла, в который будут записываться данные
 		_, err := os.Stat(options.To)
 		if !errors.Is(err, os.ErrNotExist) {
@@ -134,9 +106,8 @@ func validateFlags(options *Options) error {
 	}
 
 	// проверка того, что все опции, переданные во флаг conv корректны
-	validArgs := map[string]bool{"trim_spaces": true, "upper_case": true, "lower_case": true}
 	for arg := range options.Conv {
-		if !validArgs[arg] {
+		if conversions[arg] == nil && decompressors[arg] == nil && !blockConvs[arg] {
 			return fmt.Errorf("conv arg <%s> is not correct", arg)
 		}
 	}
@@ -159,30 +130,104 @@ func validateFlags(options *Options) error {
 		return errors.New("the block size must be positive")
 	}
 
+	if options.InputBlockSize < 0 || options.OutputBlockSize < 0 {
+		return errors.New("the input and output block sizes must be positive")
+	}
+
+	if options.Count < 0 {
+		return errors.New("the count of blocks to read must be positive")
+	}
+
+	if options.Seek < 0 {
+		return errors.New("the value of seek must be positive")
+	}
+
+	for _, name := range options.Digests {
+		if digestAlgorithms[name] == nil {
+			return fmt.Errorf("digest <%s> is not correct", name)
+		}
+	}
+
+	switch options.DigestOf {
+	case digestOfInput, digestOfOutput, digestOfBoth:
+	default:
+		return fmt.Errorf("digest stream <%s> is not correct", options.DigestOf)
+	}
+
+	if options.DigestFile != "" {
+		if len(options.Digests) == 0 {
+			return errors.New("digest file is set, but no digest is requested")
+		}
+		if _, err := os.Stat(options.DigestFile); !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("the file on path %s is already exist."+
+				" unable to write digests", options.DigestFile)
+		}
+	}
+
+	switch options.Status {
+	case "", statusNone, statusDefault, statusNoXfer, statusProgress:
+	default:
+		return fmt.Errorf("status <%s> is not correct", options.Status)
+	}
+
 	return nil
 }
 
-// ParseFlags парсит параметры и возвращает ошибку, если они не валидны
-func ParseFlags() (*Options, error) {
+// ParseFlags парсит параметры args и возвращает ошибку, если они не валидны. Кроме флагов
+// поддерживаются операнды GNU dd вида key=value (if=, of=, bs=, skip=, count=, conv= и т.д.),
+// их можно чередовать с флагами
+func ParseFlags(args []string) (*Options, error) {
 	var opts Options
 	opts.SetDefault()
 
-	flag.StringVar(&opts.From, "from", "", "file to read. by default - stdin")
-	flag.StringVar(&opts.To, "to", "", "file to write. by default - stdout")
-	flag.Int64Var(&opts.Offset, "offset", 0, "count of bytes to skip. by default - 0")
-	flag.Int64Var(&opts.Limit, "limit", -1, "max count of bytes to read. by default - -1")
-	flag.Int64Var(&opts.BlockSize, "block-size", -1, "max count of bytes to read and write."+
-		" by default - -1")
-	conv := flag.String("conv", "", "conversions over text: upper_case, lower_case and trim_spaces")
+	offset, limit, blockSize := sizeValue(0), sizeValue(-1), sizeValue(-1)
 
-	flag.Parse()
+	fs := flag.NewFlagSet("dd", flag.ContinueOnError)
+	fs.StringVar(&opts.From, "from", "", "file to read. by default - stdin")
+	fs.StringVar(&opts.To, "to", "", "file to write. by default - stdout")
+	fs.Var(&offset, "offset", "count of bytes to skip, suffixes K, M, G and so on are allowed. by default - 0")
+	fs.Var(&limit, "limit", "max count of bytes to read. by default - -1")
+	fs.Var(&blockSize, "block-size", "max count of bytes to read and write."+
+		" by default - -1")
+	fs.StringVar(&opts.Status, "status", "", "statistics to print to stderr: none, default, noxfer or progress."+
+		" by default - none")
+	digests := fs.String("digest", "", "comma-separated checksums to print: sha256, crc32")
+	fs.StringVar(&opts.DigestOf, "digest-of", digestOfOutput, "stream to checksum: input (data read after"+
+		" -offset and decompression), output (data written) or both. by default - output")
+	fs.StringVar(&opts.DigestFile, "digest-file", "", "file to write checksums. by default - stderr")
+	conv := fs.String("conv", "", "comma-separated conversions applied in the given order: "+
+		strings.Join(conversionNames(), ", "))
+
+	// flag останавливается на первом аргументе без дефиса, поэтому операнды dd
+	// забираются по одному, а разбор флагов продолжается после них
+	var operands []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return &opts, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		if !isOperand(args[0]) {
+			return &opts, fmt.Errorf("unexpected argument %q", args[0])
+		}
+		operands = append(operands, args[0])
+		args = args[1:]
+	}
 
-	for _, arg := range strings.Split(*conv, ",") {
-		if arg != "" {
-			opts.Conv[arg] = true
+	opts.Offset, opts.Limit, opts.BlockSize = int64(offset), int64(limit), int64(blockSize)
+	opts.addConv(*conv)
+	for _, name := range strings.Split(*digests, ",") {
+		if name != "" {
+			opts.Digests = append(opts.Digests, name)
 		}
 	}
 
+	if err := applyOperands(&opts, operands); err != nil {
+		return &opts, err
+	}
+
 	err := validateFlags(&opts)
 
 	return &opts, err
@@ -214,9 +259,108 @@ func OpenFile(filePath string, readMode bool) (io.ReadWriteCloser, error) {
 	return stream, err
 }
 
+// OpenOutput открывает поток вывода с учетом conv=notrunc, conv=fsync и opts.Seek.
+// Без notrunc файл пишется атомарно: данные попадают в opts.To только в Close, а Abort
+// удаляет их. С notrunc существующий файл меняется на месте и не обрезается
+func OpenOutput(opts *Options) (io.WriteCloser, error) {
+	if opts.To == "" {
+		if opts.Seek > 0 {
+			if _, err := os.Stdout.Seek(opts.Seek, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+		return os.Stdout, nil
+	}
+
+	// без notrunc вывод пишется во временный файл и появляется в opts.To целиком
+	if !opts.Conv["notrunc"] {
+		f, err := createAtomic(opts.To)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Seek > 0 {
+			if _, err = f.Seek(opts.Seek, io.SeekStart); err != nil {
+				f.Abort()
+				return nil, err
+			}
+		}
+		return f, nil
+	}
+
+	f, err := os.OpenFile(opts.To, os.O_WRONLY|os.O_CREATE, 0o666)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Seek > 0 {
+		if _, err = f.Seek(opts.Seek, io.SeekStart); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+	}
+
+	if opts.Conv["fsync"] {
+		return syncFile{f}, nil
+	}
+	return f, nil
+}
+
+// streamName возвращает имя потока для вывода контрольных сумм, "-" - stdin или stdout
+func streamName(path string) string {
+	if path == "" {
+		return "-"
+	}
+	return path
+}
+
+// writeDigests печатает суммы в новый файл path или в stderr, если path не задан
+func writeDigests(path string, digests []*Digest) error {
+	if len(digests) == 0 {
+		return nil
+	}
+	if path == "" {
+		return WriteDigests(os.Stderr, digests)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o666)
+	if err != nil {
+		return err
+	}
+	if err = WriteDigests(f, digests); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// expectedSize возвращает, сколько байт будет скопировано, или 0, если это неизвестно
+// заранее. Объем известен только для обычного входного файла
+func expectedSize(opts *Options) int64 {
+	if opts.From == "" || len(opts.Decompress) > 0 {
+		return 0
+	}
+
+	info, err := os.Stat(opts.From)
+	if err != nil || !info.Mode().IsRegular() {
+		return 0
+	}
+
+	size := info.Size() - opts.Offset
+	if opts.Limit >= 0 && opts.Limit < size {
+		size = opts.Limit
+	}
+	if opts.Count > 0 && opts.Count <= size/opts.inputBlockSize() {
+		size = opts.Count * opts.inputBlockSize()
+	}
+	if size < 0 {
+		return 0
+	}
+	return size
+}
+
 func main() {
 	// парсинг флагов
-	opts, err := ParseFlags()
+	opts, err := ParseFlags(os.Args[1:])
 	if err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, "Error: ", err)
 		os.Exit(1)
@@ -229,36 +373,100 @@ func main() {
 		os.Exit(1)
 	}
 
-	// создание потока вывода outputStream
-	outputStream, err := OpenFile(opts.To, false)
+	// распаковка ввода до пропуска offset
+	input, err := NewDecompressChain(inputStream, opts.Decompress)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "unable to read from input: ", err)
+		os.Exit(1)
+	}
+
+	// создание потока вывода outputStream после всех стадий ввода: ошибка ввода не оставляет
+	// временный файл вывода
+	outputStream, err := OpenOutput(opts)
 	if err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, "unable to write in output: ", err)
 		os.Exit(1)
 	}
 
-	// создание объекта типа Writer
-	writer := io.Writer(outputStream)
-	if opts.Conv["trim_spaces"] {
-		writer = NewTrimSpace(writer)
+	// при ошибке или прерывании недописанный вывод удаляется
+	abort := func() {
+		if a, ok := outputStream.(Aborter); ok {
+			a.Abort()
+		}
 	}
-	if opts.Conv["lower_case"] {
-		writer = NewLowerCase(writer)
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-interrupts
+		abort()
+		_, _ = fmt.Fprintln(os.Stderr, "interrupted: ", sig)
+		os.Exit(1)
+	}()
+
+	// контрольные суммы прочитанных и записанных данных
+	var inputDigests, outputDigests []*Digest
+	if opts.DigestOf != digestOfOutput {
+		inputDigests = NewDigests(opts.Digests, streamName(opts.From))
+	}
+	if opts.DigestOf != digestOfInput {
+		outputDigests = NewDigests(opts.Digests, streamName(opts.To))
+	}
+
+	// статистика считает блоки чтения в Copy и записи в outputStream
+	stats := NewStats(expectedSize(opts))
+	opts.Stats = stats
+
+	// цепочка преобразований поверх outputStream, Close дописывает буферизованные данные
+	// и закрывает outputStream
+	writer := outputStream
+	if opts.Conv["sparse"] {
+		writer = NewSparseWriter(writer, opts.outputBlockSize())
+	}
+	writer = NewDigestWriter(writer, outputDigests)
+	writer = stats.Writer(writer, opts.outputBlockSize())
+	if opts.OutputBlockSize > 0 {
+		writer = NewBlockWriter(writer, opts.OutputBlockSize)
+	}
+	writer = NewConversionChain(writer, opts.Conversions)
+	writer = NewDigestWriter(writer, inputDigests)
+
+	// статистика печатается только в stderr и не смешивается с данными в stdout
+	var reporter *Reporter
+	if opts.Status != statusNone {
+		reporter = StartReporter(stats, os.Stderr, opts.Status == statusProgress, progressInterval)
 	}
-	if opts.Conv["upper_case"] {
-		writer = NewUpperCase(writer)
+	report := func() {
+		if reporter != nil {
+			reporter.Stop()
+		}
+		switch opts.Status {
+		case statusDefault, statusProgress:
+			stats.WriteRecords(os.Stderr)
+			stats.WriteTransfer(os.Stderr)
+		case statusNoXfer:
+			stats.WriteRecords(os.Stderr)
+		}
 	}
 
-	// чтение данных из inputStream
-	data, err := SectionReader(inputStream, opts.Offset, opts.Limit)
-	if err != io.EOF && err != nil {
-		_, _ = fmt.Fprintln(os.Stderr, "error with reading: ", err)
+	// потоковое копирование блоками
+	if _, err = Copy(writer, input, opts); err != nil {
+		abort()
+		report()
+		_, _ = fmt.Fprintln(os.Stderr, "error with copying: ", err)
 		os.Exit(1)
 	}
 
-	// запись данных в outputStream
-	_, err = writer.Write(data)
-	if err != io.EOF && err != nil {
+	if err = writer.Close(); err != nil {
+		abort()
+		report()
 		_, _ = fmt.Fprintln(os.Stderr, "error with writing: ", err)
 		os.Exit(1)
 	}
+
+	report()
+
+	if err = writeDigests(opts.DigestFile, append(inputDigests, outputDigests...)); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "unable to write digests: ", err)
+		os.Exit(1)
+	}
 }
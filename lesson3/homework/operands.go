@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ddBlockSize - размер блока по умолчанию в синтаксисе dd
+const ddBlockSize = 512
+
+// sizeSuffixes - множители суффиксов размеров, как в GNU dd
+var sizeSuffixes = map[string]int64{
+	"":  1,
+	"c": 1,
+	"w": 2,
+	"b": 512,
+
+	"kB": 1000, "K": 1 << 10, "k": 1 << 10, "KiB": 1 << 10,
+	"MB": 1000 * 1000, "M": 1 << 20, "MiB": 1 << 20,
+	"GB": 1000 * 1000 * 1000, "G": 1 << 30, "GiB": 1 << 30,
+	"TB": 1000 * 1000 * 1000 * 1000, "T": 1 << 40, "TiB": 1 << 40,
+}
+
+// ParseSize разбирает размер в байтах с необязательным суффиксом: 512, 4K, 1MiB, 2kB, 10b
+func ParseSize(s string) (int64, error) {
+	i := len(s)
+	for i > 0 && (s[i-1] < '0' || s[i-1] > '9') {
+		i--
+	}
+
+	mult, ok := sizeSuffixes[s[i:]]
+	if !ok {
+		return 0, fmt.Errorf("invalid size suffix in %q", s)
+	}
+
+	n, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	if n > 0 && n > (1<<63-1)/mult || n < 0 && n < (-1<<63)/mult {
+		return 0, fmt.Errorf("size %q is too big", s)
+	}
+	return n * mult, nil
+}
+
+// sizeValue - флаг с размером, понимающий суффиксы ParseSize
+type sizeValue int64
+
+func (v *sizeValue) String() string {
+	return strconv.FormatInt(int64(*v), 10)
+}
+
+func (v *sizeValue) Set(s string) error {
+	n, err := ParseSize(s)
+	if err != nil {
+		return err
+	}
+	*v = sizeValue(n)
+	return nil
+}
+
+// isOperand сообщает, что аргумент записан в синтаксисе dd: key=value без дефиса
+func isOperand(arg string) bool {
+	key, _, ok := strings.Cut(arg, "=")
+	return ok && key != "" && !strings.HasPrefix(arg, "-")
+}
+
+// applyOperands применяет операнды dd к opts. skip и count считаются в блоках ввода, seek -
+// в блоках вывода, поэтому пересчитываются в байты после разбора всех операндов
+func applyOperands(opts *Options, operands []string) error {
+	var skip, seek int64
+
+	for _, operand := range operands {
+		key, value, _ := strings.Cut(operand, "=")
+
+		var err error
+		switch key {
+		case "if":
+			opts.From = value
+		case "of":
+			opts.To = value
+		case "bs":
+			opts.BlockSize, err = ParseSize(value)
+		case "ibs":
+			opts.InputBlockSize, err = ParseSize(value)
+		case "obs":
+			opts.OutputBlockSize, err = ParseSize(value)
+		case "skip":
+			skip, err = ParseSize(value)
+		case "seek":
+			seek, err = ParseSize(value)
+		case "count":
+			opts.Count, err = ParseSize(value)
+			// Count == 0 в Options - без ограничения, а count=0 не копирует ничего
+			if err == nil && opts.Count == 0 {
+				opts.Limit = 0
+			}
+		case "conv":
+			opts.addConv(value)
+		case "status":
+			opts.Status = value
+		default:
+			return fmt.Errorf("unknown operand %q", operand)
+		}
+
+		if err != nil {
+			return fmt.Errorf("operand %s: %w", key, err)
+		}
+	}
+
+	// как в GNU dd, без bs блоки ввода и вывода по 512 байт, а вывод собирается в блоки
+	if len(operands) > 0 && opts.BlockSize <= 0 {
+		if opts.InputBlockSize == 0 {
+			opts.InputBlockSize = ddBlockSize
+		}
+		if opts.OutputBlockSize == 0 {
+			opts.OutputBlockSize = ddBlockSize
+		}
+	}
+
+	// в синтаксисе dd итоговая статистика печатается по умолчанию
+	if len(operands) > 0 && opts.Status == "" {
+		opts.Status = statusDefault
+	}
+
+	if skip < 0 || seek < 0 {
+		return errors.New("skip and seek must be positive")
+	}
+	opts.Offset += skip * opts.inputBlockSize()
+	opts.Seek += seek * opts.outputBlockSize()
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSize(t *testing.T) {
+	for s, want := range map[string]int64{
+		"0": 0, "17": 17, "3c": 3, "2w": 4, "2b": 1024,
+		"1K": 1024, "1k": 1024, "1KiB": 1024, "1kB": 1000,
+		"2M": 2 << 20, "1MB": 1000 * 1000, "1G": 1 << 30, "1T": 1 << 40,
+		"-90": -90,
+	} {
+		n, err := ParseSize(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, want, n, s)
+	}
+
+	for _, s := range []string{"", "K", "qweqwe", "1X", "1.5K", "9000000000T"} {
+		_, err := ParseSize(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestParseOperands(t *testing.T) {
+	opts, err := ParseFlags([]string{"bs=1K", "skip=2", "count=3", "-conv", "upper_case", "conv=sync,noerror", "status=progress"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1024), opts.BlockSize)
+	assert.Equal(t, int64(2048), opts.Offset)
+	assert.Equal(t, int64(3), opts.Count)
+	assert.Equal(t, "progress", opts.Status)
+	assert.Equal(t, map[string]bool{"upper_case": true, "sync": true, "noerror": true}, opts.Conv)
+
+	// без bs блоки по 512 байт, как в GNU dd
+	opts, err = ParseFlags([]string{"skip=1", "seek=2", "obs=1K"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(512), opts.Offset)
+	assert.Equal(t, int64(2048), opts.Seek)
+	assert.Equal(t, int64(512), opts.InputBlockSize)
+
+	// суффиксы работают и во флагах
+	opts, err = ParseFlags([]string{"-offset", "1K", "-block-size", "4k"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1024), opts.Offset)
+	assert.Equal(t, int64(4096), opts.BlockSize)
+
+	opts, err = ParseFlags([]string{"count=0"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), opts.Limit)
+
+	for _, args := range [][]string{
+		{"foo=bar"}, {"bs=1Q"}, {"status=loud"}, {"conv=block"}, {"ibs=-1"},
+		{"skip=-1"}, {"input.txt"}, {"-offset", "-90"},
+	} {
+		_, err = ParseFlags(args)
+		assert.Error(t, err, args)
+	}
+}
+
+func TestCopyCountAndSync(t *testing.T) {
+	out := &blockWriter{}
+	n, err := Copy(out, strings.NewReader(testInput), &Options{Limit: -1, InputBlockSize: 5, Count: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(15), n)
+	assert.Equal(t, testInput[:15], out.String())
+
+	// неполный последний блок дополняется нулями
+	out = &blockWriter{}
+	n, err = Copy(out, strings.NewReader("abcdefg"), &Options{Limit: -1, BlockSize: 4, Conv: map[string]bool{"sync": true}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), n)
+	assert.Equal(t, "abcdefg\x00", out.String())
+}
+
+// failingReader возвращает ошибку на каждом втором чтении
+type failingReader struct {
+	io.Reader
+	reads int
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	r.reads++
+	if r.reads%2 == 0 {
+		return 0, errors.New("bad block")
+	}
+	return r.Reader.Read(p)
+}
+
+func TestCopyNoError(t *testing.T) {
+	_, err := Copy(&bytes.Buffer{}, &failingReader{Reader: strings.NewReader("abcdefgh")}, &Options{Limit: -1, BlockSize: 2})
+	assert.Error(t, err)
+
+	stderr := &bytes.Buffer{}
+	errOutput = stderr
+	defer func() { errOutput = os.Stderr }()
+
+	out := &bytes.Buffer{}
+	_, err = Copy(out, &failingReader{Reader: strings.NewReader("abcdefgh")},
+		&Options{Limit: -1, BlockSize: 2, Conv: map[string]bool{"noerror": true, "sync": true}})
+	assert.NoError(t, err)
+	assert.Equal(t, "ab\x00\x00cd\x00\x00ef\x00\x00gh\x00\x00", out.String())
+	assert.Contains(t, stderr.String(), "bad block")
+}
+
+func TestBlockWriter(t *testing.T) {
+	out := &blockWriter{}
+	w := NewBlockWriter(out, 4)
+
+	for _, s := range []string{"a", "bcdef", "ghijklmnop", "q"} {
+		_, err := w.Write([]byte(s))
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, "abcdefghijklmnop", out.String())
+	assert.Equal(t, 4, out.maxWrite)
+
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "abcdefghijklmnopq", out.String())
+}
+
+func TestOpenOutputSeek(t *testing.T) {
+	name := path.Join(t.TempDir(), "output.txt")
+	assert.NoError(t, os.WriteFile(name, []byte("0123456789"), 0o600))
+
+	// существующий файл меняется только с conv=notrunc
+	_, err := ParseFlags([]string{"of=" + name, "seek=2", "bs=2"})
+	assert.Error(t, err)
+
+	opts, err := ParseFlags([]string{"of=" + name, "seek=2", "bs=2", "conv=notrunc,fsync"})
+	assert.NoError(t, err)
+
+	w, err := OpenOutput(opts)
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("ab"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	data, err := os.ReadFile(name)
+	assert.NoError(t, err)
+	assert.Equal(t, "0123ab6789", string(data))
+
+	// без notrunc новый файл начинается с дыры
+	name = path.Join(t.TempDir(), "new.txt")
+	opts, err = ParseFlags([]string{"of=" + name, "seek=3", "bs=1"})
+	assert.NoError(t, err)
+	w, err = OpenOutput(opts)
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("x"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	data, err = os.ReadFile(name)
+	assert.NoError(t, err)
+	assert.Equal(t, "\x00\x00\x00x", string(data))
+}
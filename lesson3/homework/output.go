@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Aborter - поток вывода, который можно отменить: недописанные данные удаляются
+type Aborter interface {
+	Abort()
+}
+
+// atomicFile пишет во временный файл в каталоге path. Close сбрасывает данные на диск
+// и переименовывает временный файл в path, поэтому неудачное копирование не оставляет
+// частично записанный path. Abort удаляет временный файл
+type atomicFile struct {
+	*os.File
+	path string
+	once sync.Once
+	err  error
+}
+
+// createAtomic создает временный файл для атомарной записи в path. В отличие от
+// os.CreateTemp права файла такие же, как у os.Create
+func createAtomic(path string) (*atomicFile, error) {
+	dir, name := filepath.Split(path)
+
+	for try := 0; ; try++ {
+		suffix := make([]byte, 6)
+		if _, err := rand.Read(suffix); err != nil {
+			return nil, err
+		}
+
+		tmp := filepath.Join(dir, "."+name+"."+hex.EncodeToString(suffix)+".tmp")
+		f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o666)
+		if errors.Is(err, os.ErrExist) && try < 100 {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &atomicFile{File: f, path: path}, nil
+	}
+}
+
+func (f *atomicFile) Close() error {
+	f.once.Do(func() {
+		f.err = f.commit()
+		if f.err != nil {
+			_ = os.Remove(f.File.Name())
+		}
+	})
+	return f.err
+}
+
+func (f *atomicFile) commit() error {
+	if err := f.File.Sync(); err != nil {
+		_ = f.File.Close()
+		return err
+	}
+	if err := f.File.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.File.Name(), f.path); err != nil {
+		return err
+	}
+
+	// переименование тоже должно попасть на диск. Не на всех платформах каталог
+	// можно открыть и синхронизировать, поэтому ошибка игнорируется
+	if dir, err := os.Open(filepath.Dir(f.path)); err == nil {
+		_ = dir.Sync()
+		_ = dir.Close()
+	}
+	return nil
+}
+
+func (f *atomicFile) Abort() {
+	f.once.Do(func() {
+		_ = f.File.Close()
+		_ = os.Remove(f.File.Name())
+		f.err = os.ErrClosed
+	})
+}
+
+// sparseFile - поток вывода, в котором можно оставлять дыры
+type sparseFile interface {
+	io.Writer
+	io.Seeker
+	Truncate(size int64) error
+	Stat() (os.FileInfo, error)
+}
+
+// sparseWriter пропускает через Seek блоки из одних нулей вместо их записи, как dd
+// с conv=sparse. С conv=notrunc на месте пропущенных блоков остаются старые данные
+type sparseWriter struct {
+	output    sparseFile
+	blockSize int64
+	hole      int64 // сколько байт нулей пропущено и еще не учтено в позиции файла
+}
+
+// NewSparseWriter возвращает sparseWriter поверх output, если в нем можно перемещаться,
+// иначе - output без изменений
+func NewSparseWriter(output io.WriteCloser, blockSize int64) io.WriteCloser {
+	f, ok := output.(sparseFile)
+	if !ok {
+		return output
+	}
+	if _, err := f.Seek(0, io.SeekCurrent); err != nil {
+		return output
+	}
+	return &sparseWriter{output: f, blockSize: blockSize}
+}
+
+func (s *sparseWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p
+		if int64(len(chunk)) > s.blockSize {
+			chunk = chunk[:s.blockSize]
+		}
+
+		if isZero(chunk) {
+			s.hole += int64(len(chunk))
+		} else {
+			if err := s.seekHole(); err != nil {
+				return written, err
+			}
+			if _, err := s.output.Write(chunk); err != nil {
+				return written, err
+			}
+		}
+
+		written += len(chunk)
+		p = p[len(chunk):]
+	}
+	return written, nil
+}
+
+// seekHole перемещается за пропущенные нули
+func (s *sparseWriter) seekHole() error {
+	if s.hole == 0 {
+		return nil
+	}
+	_, err := s.output.Seek(s.hole, io.SeekCurrent)
+	s.hole = 0
+	return err
+}
+
+// Close продлевает файл, если он заканчивается пропущенными нулями
+func (s *sparseWriter) Close() error {
+	if s.hole > 0 {
+		if err := s.seekHole(); err != nil {
+			return err
+		}
+		pos, err := s.output.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		info, err := s.output.Stat()
+		if err != nil {
+			return err
+		}
+		if info.Size() < pos {
+			if err = s.output.Truncate(pos); err != nil {
+				return err
+			}
+		}
+	}
+	return closeOutput(s.output)
+}
+
+// zeroBlock - нули для сравнения в isZero
+var zeroBlock = make([]byte, 4096)
+
+// isZero сообщает, что p состоит из одних нулей
+func isZero(p []byte) bool {
+	for len(p) > 0 {
+		n := len(p)
+		if n > len(zeroBlock) {
+			n = len(zeroBlock)
+		}
+		if !bytes.Equal(p[:n], zeroBlock[:n]) {
+			return false
+		}
+		p = p[n:]
+	}
+	return true
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// dirEntries возвращает имена файлов в каталоге dir
+func dirEntries(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestAtomicOutput(t *testing.T) {
+	dir := t.TempDir()
+	name := path.Join(dir, "output.txt")
+
+	w, err := OpenOutput(&Options{To: name, Conv: map[string]bool{}})
+	assert.NoError(t, err)
+	_, err = io.WriteString(w, "hello")
+	assert.NoError(t, err)
+
+	// до Close данные лежат только во временном файле
+	assert.NoFileExists(t, name)
+	assert.Len(t, dirEntries(t, dir), 1)
+
+	assert.NoError(t, w.Close())
+	data, err := os.ReadFile(name)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+	assert.Equal(t, []string{"output.txt"}, dirEntries(t, dir))
+
+	// Abort после Close ничего не удаляет
+	w.(Aborter).Abort()
+	assert.FileExists(t, name)
+
+	name = path.Join(dir, "aborted.txt")
+	w, err = OpenOutput(&Options{To: name, Conv: map[string]bool{}})
+	assert.NoError(t, err)
+	_, err = io.WriteString(w, "partial")
+	assert.NoError(t, err)
+	w.(Aborter).Abort()
+	assert.Error(t, w.Close())
+	assert.Equal(t, []string{"output.txt"}, dirEntries(t, dir))
+}
+
+// countingFile считает байты, действительно записанные в файл
+type countingFile struct {
+	*os.File
+	written int
+}
+
+func (f *countingFile) Write(p []byte) (int, error) {
+	f.written += len(p)
+	return f.File.Write(p)
+}
+
+func TestSparseWriter(t *testing.T) {
+	zeros := make([]byte, 8)
+	input := append(append(append([]byte("data"), zeros...), "more"...), zeros...)
+
+	for _, blockSize := range []int64{1, 4, 6} {
+		name := path.Join(t.TempDir(), "sparse.img")
+		f, err := os.Create(name)
+		assert.NoError(t, err)
+
+		out := &countingFile{File: f}
+		w := NewSparseWriter(out, blockSize)
+		_, err = Copy(w, bytes.NewReader(input), &Options{Limit: -1, BlockSize: blockSize})
+		assert.NoError(t, err)
+		assert.NoError(t, w.Close())
+
+		data, err := os.ReadFile(name)
+		assert.NoError(t, err)
+		assert.Equal(t, input, data, "block size %d", blockSize)
+		assert.Less(t, out.written, len(input), "block size %d", blockSize)
+	}
+
+	// без возможности перемещаться вывод не меняется
+	buf := &bytes.Buffer{}
+	w := NewSparseWriter(nopCloser{buf}, 4)
+	assert.Equal(t, nopCloser{buf}, w)
+}
+
+func TestOutputRemovedOnFailure(t *testing.T) {
+	binPath := composeBinaryPath()
+	cmd := exec.Command("go", "build", "-o", binPath, "./")
+	assert.NoError(t, cmd.Run())
+	defer os.Remove(binPath)
+
+	// обрезанный архив: ошибка чтения в середине копирования
+	compressed := &bytes.Buffer{}
+	gz := gzip.NewWriter(compressed)
+	_, err := io.WriteString(gz, strings.Repeat(testInput, 100))
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+
+	dir := t.TempDir()
+	cmd = exec.Command(binPath, "-to", path.Join(dir, "out.txt"), "-conv", "gunzip", "-block-size", "64")
+	cmd.Stdin = bytes.NewReader(compressed.Bytes()[:compressed.Len()-100])
+	assert.Error(t, cmd.Run())
+	assert.Empty(t, dirEntries(t, dir))
+
+	// неверный заголовок архива: вывод не создается
+	cmd = exec.Command(binPath, "-to", path.Join(dir, "out.txt"), "-conv", "gunzip")
+	cmd.Stdin = strings.NewReader(testInput)
+	assert.Error(t, cmd.Run())
+	assert.Empty(t, dirEntries(t, dir))
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	// прерывание во время чтения из stdin
+	r, w, err := os.Pipe()
+	assert.NoError(t, err)
+	defer w.Close()
+
+	cmd = exec.Command(binPath, "-to", path.Join(dir, "out.txt"))
+	cmd.Stdin = r
+	assert.NoError(t, cmd.Start())
+	_ = r.Close()
+
+	_, err = io.WriteString(w, testInput)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return len(dirEntries(t, dir)) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, cmd.Process.Signal(os.Interrupt))
+	assert.Error(t, cmd.Wait())
+	assert.Empty(t, dirEntries(t, dir))
+}
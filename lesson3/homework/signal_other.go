@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// infoSignals - на платформах без SIGUSR1 статистика по сигналу не печатается
+var infoSignals []os.Signal
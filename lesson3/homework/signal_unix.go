@@ -0,0 +1,11 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// infoSignals - сигналы, по которым печатается текущая статистика копирования
+var infoSignals = []os.Signal{syscall.SIGUSR1}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"strings"
+)
+
+// Decompressor оборачивает поток ввода распаковкой. Распаковка идет до skip и limit,
+// поэтому -offset и -limit отсчитываются в распакованных данных
+type Decompressor func(input io.Reader) (io.Reader, error)
+
+// decompressors - распаковки, доступные в -conv. Сжатие - обычные преобразования
+// вывода из conversions
+var decompressors = map[string]Decompressor{
+	"gunzip": func(input io.Reader) (io.Reader, error) {
+		return gzip.NewReader(input)
+	},
+	"zlib_decompress": func(input io.Reader) (io.Reader, error) {
+		return zlib.NewReader(input)
+	},
+}
+
+// NewDecompressChain оборачивает input распаковками names в порядке перечисления
+func NewDecompressChain(input io.Reader, names []string) (io.Reader, error) {
+	for _, name := range names {
+		var err error
+		if input, err = decompressors[name](input); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return input, nil
+}
+
+// compressWriter сжимает данные в output. Close дописывает конец сжатого потока
+// и закрывает output
+type compressWriter struct {
+	io.WriteCloser
+	output io.Writer
+}
+
+func (c *compressWriter) Close() error {
+	if err := c.WriteCloser.Close(); err != nil {
+		return err
+	}
+	return closeOutput(c.output)
+}
+
+func NewGzip(output io.Writer) io.WriteCloser {
+	return &compressWriter{WriteCloser: gzip.NewWriter(output), output: output}
+}
+
+func NewZlib(output io.Writer) io.WriteCloser {
+	return &compressWriter{WriteCloser: zlib.NewWriter(output), output: output}
+}
+
+// digestAlgorithms - контрольные суммы, доступные в -digest
+var digestAlgorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"crc32":  func() hash.Hash { return crc32.NewIEEE() },
+}
+
+// значения -digest-of
+const (
+	digestOfInput  = "input"
+	digestOfOutput = "output"
+	digestOfBoth   = "both"
+)
+
+// Digest - контрольная сумма потока path
+type Digest struct {
+	hash.Hash
+	Algorithm string
+	Path      string
+}
+
+// NewDigests создает по одной контрольной сумме каждого алгоритма для потока path
+func NewDigests(algorithms []string, path string) []*Digest {
+	digests := make([]*Digest, 0, len(algorithms))
+	for _, name := range algorithms {
+		digests = append(digests, &Digest{Hash: digestAlgorithms[name](), Algorithm: name, Path: path})
+	}
+	return digests
+}
+
+// String форматирует сумму, как sha256sum --tag: SHA256 (path) = hex
+func (d *Digest) String() string {
+	return fmt.Sprintf("%s (%s) = %x", strings.ToUpper(d.Algorithm), d.Path, d.Sum(nil))
+}
+
+// digestWriter передает данные в output и считает по ним контрольные суммы
+type digestWriter struct {
+	output  io.Writer
+	digests []*Digest
+}
+
+func NewDigestWriter(output io.Writer, digests []*Digest) io.WriteCloser {
+	return &digestWriter{output: output, digests: digests}
+}
+
+func (d *digestWriter) Write(p []byte) (int, error) {
+	n, err := d.output.Write(p)
+	for _, digest := range d.digests {
+		_, _ = digest.Write(p[:n])
+	}
+	return n, err
+}
+
+func (d *digestWriter) Close() error {
+	return closeOutput(d.output)
+}
+
+// WriteDigests печатает суммы по одной в строке
+func WriteDigests(w io.Writer, digests []*Digest) error {
+	for _, digest := range digests {
+		if _, err := fmt.Fprintln(w, digest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
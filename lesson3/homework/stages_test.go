@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	for _, c := range []struct{ compress, decompress string }{{"gzip", "gunzip"}, {"zlib", "zlib_decompress"}} {
+		for _, blockSize := range []int64{1, 7, 4096} {
+			// сжатие - последнее преобразование вывода
+			compressed := copyConverted(t, testInput, blockSize, func(output io.Writer) io.WriteCloser {
+				return NewConversionChain(nopCloser{output}, []string{"upper_case", c.compress})
+			})
+
+			input, err := NewDecompressChain(strings.NewReader(compressed), []string{c.decompress})
+			assert.NoError(t, err)
+
+			// offset и limit отсчитываются в распакованных данных
+			out := &bytes.Buffer{}
+			_, err = Copy(out, input, &Options{Offset: 5, Limit: 100, BlockSize: blockSize})
+			assert.NoError(t, err)
+			assert.Equal(t, strings.ToUpper(testInput)[5:105], out.String(), "%s, block size %d", c.compress, blockSize)
+		}
+	}
+
+	_, err := NewDecompressChain(strings.NewReader(testInput), []string{"gunzip"})
+	assert.Error(t, err)
+}
+
+func TestDigestWriter(t *testing.T) {
+	digests := NewDigests([]string{"sha256", "crc32"}, "-")
+	out := &bytes.Buffer{}
+	w := NewDigestWriter(out, digests)
+
+	for _, s := range []string{"a", "b", "c"} {
+		_, err := io.WriteString(w, s)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "abc", out.String())
+
+	report := &bytes.Buffer{}
+	assert.NoError(t, WriteDigests(report, digests))
+	assert.Equal(t, "SHA256 (-) = ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad\n"+
+		"CRC32 (-) = 352441c2\n", report.String())
+}
+
+func TestDigestFlags(t *testing.T) {
+	opts, err := ParseFlags([]string{"-digest", "sha256,crc32", "conv=gunzip,upper_case"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sha256", "crc32"}, opts.Digests)
+	assert.Equal(t, digestOfOutput, opts.DigestOf)
+	assert.Equal(t, []string{"gunzip"}, opts.Decompress)
+	assert.Equal(t, []string{"upper_case"}, opts.Conversions)
+
+	for _, args := range [][]string{
+		{"-digest", "md5"}, {"-digest", "sha256", "-digest-of", "all"}, {"-digest-file", "sums.txt"},
+		{"-digest", "sha256", "-digest-file", "main.go"},
+	} {
+		_, err = ParseFlags(args)
+		assert.Error(t, err, args)
+	}
+}
+
+func TestCompressAndDigestIntegration(t *testing.T) {
+	binPath := composeBinaryPath()
+	cmd := exec.Command("go", "build", "-o", binPath, "./")
+	assert.NoError(t, cmd.Run())
+	defer os.Remove(binPath)
+
+	dir := t.TempDir()
+	outPath, sumsPath := path.Join(dir, "out.gz"), path.Join(dir, "out.sums")
+
+	cmd = exec.Command(binPath, "-to", outPath, "-conv", "gzip", "-digest", "sha256,crc32",
+		"-digest-of", "both", "-digest-file", sumsPath)
+	cmd.Stdin = strings.NewReader(testInput)
+	assert.NoError(t, cmd.Run())
+
+	compressed, err := os.ReadFile(outPath)
+	assert.NoError(t, err)
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	assert.NoError(t, err)
+	data, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, testInput, string(data))
+
+	sums, err := os.ReadFile(sumsPath)
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("SHA256 (-) = %x\nCRC32 (-) = %08x\nSHA256 (%s) = %x\nCRC32 (%s) = %08x\n",
+		sha256.Sum256([]byte(testInput)), crc32.ChecksumIEEE([]byte(testInput)),
+		outPath, sha256.Sum256(compressed), outPath, crc32.ChecksumIEEE(compressed)), string(sums))
+
+	// распаковка обратно в stdout с проверкой суммы ввода в stderr
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd = exec.Command(binPath, "-from", outPath, "-conv", "gunzip", "-digest", "crc32", "-digest-of", "input")
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	assert.NoError(t, cmd.Run())
+	assert.Equal(t, testInput, stdout.String())
+	assert.Equal(t, fmt.Sprintf("CRC32 (%s) = %08x\n", outPath, crc32.ChecksumIEEE([]byte(testInput))), stderr.String())
+}
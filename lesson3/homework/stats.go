@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// progressInterval - как часто status=progress обновляет строку прогресса
+const progressInterval = time.Second
+
+// Stats - статистика копирования в духе dd: полные и неполные блоки чтения и записи
+// и записанные байты. Счетчики атомарные, так как отчет печатается из другой горутины
+type Stats struct {
+	fullIn, partialIn   atomic.Int64
+	fullOut, partialOut atomic.Int64
+	bytes               atomic.Int64
+
+	total int64 // сколько байт ожидается, 0 - неизвестно
+	start time.Time
+	now   func() time.Time
+}
+
+// NewStats создает статистику и начинает отсчет времени. total - ожидаемый объем
+// в байтах, 0 - если он неизвестен
+func NewStats(total int64) *Stats {
+	return &Stats{total: total, start: time.Now(), now: time.Now}
+}
+
+// addIn учитывает чтение n байт в блок размера size
+func (s *Stats) addIn(n int, size int) {
+	switch {
+	case n == size:
+		s.fullIn.Add(1)
+	case n > 0:
+		s.partialIn.Add(1)
+	}
+}
+
+// Writer считает записи в output как блоки размера blockSize и записанные байты
+func (s *Stats) Writer(output io.Writer, blockSize int64) io.WriteCloser {
+	return &statsWriter{output: output, stats: s, blockSize: blockSize}
+}
+
+type statsWriter struct {
+	output    io.Writer
+	stats     *Stats
+	blockSize int64
+}
+
+func (w *statsWriter) Write(p []byte) (int, error) {
+	n, err := w.output.Write(p)
+	if n > 0 {
+		w.stats.bytes.Add(int64(n))
+		if int64(n) >= w.blockSize {
+			w.stats.fullOut.Add(1)
+		} else {
+			w.stats.partialOut.Add(1)
+		}
+	}
+	return n, err
+}
+
+func (w *statsWriter) Close() error {
+	return closeOutput(w.output)
+}
+
+// WriteRecords печатает кол-во блоков, как dd: "N+M records in/out"
+func (s *Stats) WriteRecords(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "%d+%d records in\n", s.fullIn.Load(), s.partialIn.Load())
+	_, _ = fmt.Fprintf(w, "%d+%d records out\n", s.fullOut.Load(), s.partialOut.Load())
+}
+
+// WriteTransfer печатает объем, время и скорость копирования
+func (s *Stats) WriteTransfer(w io.Writer) {
+	bytes, elapsed := s.bytes.Load(), s.now().Sub(s.start)
+	_, _ = fmt.Fprintf(w, "%d bytes (%s) copied, %.3f s, %s/s\n",
+		bytes, humanSize(bytes), elapsed.Seconds(), humanSize(rate(bytes, elapsed)))
+}
+
+// WriteProgress печатает строку прогресса поверх предыдущей. ETA выводится, если
+// известен ожидаемый объем
+func (s *Stats) WriteProgress(w io.Writer) {
+	bytes, elapsed := s.bytes.Load(), s.now().Sub(s.start)
+	speed := rate(bytes, elapsed)
+
+	line := fmt.Sprintf("%d bytes (%s) copied, %.0f s, %s/s", bytes, humanSize(bytes), elapsed.Seconds(), humanSize(speed))
+	if s.total > 0 && speed > 0 && bytes < s.total {
+		eta := time.Duration(float64(s.total-bytes) / float64(speed) * float64(time.Second))
+		line += fmt.Sprintf(", ETA %s", eta.Round(time.Second))
+	}
+	_, _ = fmt.Fprintf(w, "\r%s\033[K", line)
+}
+
+// rate возвращает скорость в байтах в секунду
+func rate(bytes int64, elapsed time.Duration) int64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return int64(float64(bytes) / elapsed.Seconds())
+}
+
+// humanSize форматирует размер в десятичных единицах, как dd: 512 B, 1.5 kB, 3.2 MB
+func humanSize(n int64) string {
+	const units = "kMGTPE"
+	if n < 1000 {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	size, unit := float64(n)/1000, 0
+	for size >= 1000 && unit < len(units)-1 {
+		size /= 1000
+		unit++
+	}
+	return fmt.Sprintf("%.1f %cB", size, units[unit])
+}
+
+// Reporter печатает статистику во время копирования: строку прогресса раз в interval
+// и полный отчет по сигналам infoSignals (SIGUSR1)
+type Reporter struct {
+	stats    *Stats
+	output   io.Writer
+	progress bool
+
+	printed bool // на экране незавершенная строка прогресса
+	ticker  *time.Ticker
+	signals chan os.Signal
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// StartReporter запускает печать статистики в output. При progress == false строка
+// прогресса не печатается, но отчет по сигналу остается
+func StartReporter(stats *Stats, output io.Writer, progress bool, interval time.Duration) *Reporter {
+	r := &Reporter{
+		stats:    stats,
+		output:   output,
+		progress: progress,
+		signals:  make(chan os.Signal, 1),
+		done:     make(chan struct{}),
+	}
+	if len(infoSignals) > 0 {
+		signal.Notify(r.signals, infoSignals...)
+	}
+
+	var tick <-chan time.Time
+	if progress {
+		r.ticker = time.NewTicker(interval)
+		tick = r.ticker.C
+	}
+
+	r.wg.Add(1)
+	go r.run(tick)
+
+	return r
+}
+
+func (r *Reporter) run(tick <-chan time.Time) {
+	defer r.wg.Done()
+	for {
+		select {
+		case <-tick:
+			r.stats.WriteProgress(r.output)
+			r.printed = true
+		case <-r.signals:
+			r.endLine()
+			r.stats.WriteRecords(r.output)
+			r.stats.WriteTransfer(r.output)
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// endLine завершает строку прогресса, чтобы следующий вывод начался с новой строки
+func (r *Reporter) endLine() {
+	if r.printed {
+		_, _ = fmt.Fprintln(r.output)
+		r.printed = false
+	}
+}
+
+// Stop останавливает печать и завершает строку прогресса. После Stop в output можно
+// писать итоговый отчет
+func (r *Reporter) Stop() {
+	signal.Stop(r.signals)
+	if r.ticker != nil {
+		r.ticker.Stop()
+	}
+	close(r.done)
+	r.wg.Wait()
+
+	if r.progress {
+		r.stats.WriteProgress(r.output)
+		r.printed = true
+	}
+	r.endLine()
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// syncBuffer - буфер, в который Reporter пишет из своей горутины
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// fixedStats возвращает статистику, по часам которой с начала копирования прошло elapsed
+func fixedStats(total int64, elapsed time.Duration) *Stats {
+	stats := NewStats(total)
+	stats.now = func() time.Time { return stats.start.Add(elapsed) }
+	return stats
+}
+
+func TestStatsRecords(t *testing.T) {
+	stats := fixedStats(0, 2*time.Second)
+	out := &bytes.Buffer{}
+	w := NewBlockWriter(stats.Writer(out, 4), 4)
+
+	// 10 байт блоками по 3 на входе и по 4 на выходе
+	_, err := Copy(w, strings.NewReader("0123456789"), &Options{Limit: -1, BlockSize: 3, Stats: stats})
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "0123456789", out.String())
+
+	report := &bytes.Buffer{}
+	stats.WriteRecords(report)
+	stats.WriteTransfer(report)
+	assert.Equal(t, "3+1 records in\n2+1 records out\n10 bytes (10 B) copied, 2.000 s, 5 B/s\n", report.String())
+}
+
+func TestStatsProgress(t *testing.T) {
+	stats := fixedStats(10_000_000, 2*time.Second)
+	stats.bytes.Store(4_000_000)
+
+	out := &bytes.Buffer{}
+	stats.WriteProgress(out)
+	assert.Equal(t, "\r4000000 bytes (4.0 MB) copied, 2 s, 2.0 MB/s, ETA 3s\033[K", out.String())
+
+	// без ожидаемого объема ETA не печатается
+	stats.total = 0
+	out.Reset()
+	stats.WriteProgress(out)
+	assert.NotContains(t, out.String(), "ETA")
+}
+
+func TestHumanSize(t *testing.T) {
+	assert.Equal(t, "999 B", humanSize(999))
+	assert.Equal(t, "1.5 kB", humanSize(1500))
+	assert.Equal(t, "1.0 GB", humanSize(1_000_000_000))
+}
+
+func TestReporter(t *testing.T) {
+	stats := fixedStats(0, time.Second)
+	out := &syncBuffer{}
+
+	r := StartReporter(stats, out, true, time.Millisecond)
+	assert.Eventually(t, func() bool { return strings.Contains(out.String(), "copied") }, time.Second, time.Millisecond)
+
+	if len(infoSignals) > 0 {
+		p, err := os.FindProcess(os.Getpid())
+		assert.NoError(t, err)
+		assert.NoError(t, p.Signal(infoSignals[0]))
+		assert.Eventually(t, func() bool { return strings.Contains(out.String(), "0+0 records in\n") }, time.Second, time.Millisecond)
+	}
+
+	r.Stop()
+	assert.True(t, strings.HasSuffix(out.String(), "\n"))
+}
+
+func TestStatusKeepsStdout(t *testing.T) {
+	binPath := composeBinaryPath()
+	cmd := exec.Command("go", "build", "-o", binPath, "./")
+	assert.NoError(t, cmd.Run())
+	defer os.Remove(binPath)
+
+	for _, args := range [][]string{{"status=progress", "bs=7"}, {"-status", "noxfer"}, {"bs=7"}} {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		cmd = exec.Command(binPath, args...)
+		cmd.Stdin = strings.NewReader(testInput)
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+
+		assert.NoError(t, cmd.Run(), args)
+		assert.Equal(t, testInput, stdout.String(), args)
+		assert.Contains(t, stderr.String(), "records out", args)
+	}
+
+	stderr := &bytes.Buffer{}
+	cmd = exec.Command(binPath, "status=none")
+	cmd.Stdin, cmd.Stderr = strings.NewReader(testInput), stderr
+	assert.NoError(t, cmd.Run())
+	assert.Empty(t, stderr.String())
+}
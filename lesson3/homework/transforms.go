@@ -0,0 +1,330 @@
+package main
+
+import (
+	"io"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Conversion оборачивает поток вывода преобразованием текста. Close возвращенного
+// writer'а дописывает буферизованные данные и закрывает output
+type Conversion func(output io.Writer) io.WriteCloser
+
+// conversions - преобразования, доступные в -conv
+var conversions = map[string]Conversion{
+	"upper_case":     NewUpperCase,
+	"lower_case":     NewLowerCase,
+	"trim_spaces":    NewTrimSpace,
+	"title_case":     NewTitleCase,
+	"swab":           NewSwab,
+	"crlf_to_lf":     NewCRLFToLF,
+	"lf_to_crlf":     NewLFToCRLF,
+	"nfc":            newTransform(norm.NFC),
+	"nfd":            newTransform(norm.NFD),
+	"cp1251_to_utf8": newTransform(charmap.Windows1251.NewDecoder()),
+	"koi8r_to_utf8":  newTransform(charmap.KOI8R.NewDecoder()),
+	"strip_ansi":     NewStripANSI,
+	"gzip":           NewGzip,
+	"zlib":           NewZlib,
+}
+
+// conversionNames возвращает отсортированные имена преобразований для справки
+func conversionNames() []string {
+	names := make([]string, 0, len(conversions))
+	for name := range conversions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewConversionChain оборачивает output преобразованиями names так, что данные проходят
+// их в порядке перечисления
+func NewConversionChain(output io.WriteCloser, names []string) io.WriteCloser {
+	writer := output
+	for i := len(names) - 1; i >= 0; i-- {
+		writer = conversions[names[i]](writer)
+	}
+	return writer
+}
+
+// transformWriter - преобразование из golang.org/x/text. transform.Writer сам придерживает
+// данные, разрезанные границей блока, но не закрывает output
+type transformWriter struct {
+	*transform.Writer
+	output io.Writer
+}
+
+func newTransform(t transform.Transformer) Conversion {
+	return func(output io.Writer) io.WriteCloser {
+		return &transformWriter{Writer: transform.NewWriter(output, t), output: output}
+	}
+}
+
+func (t *transformWriter) Close() error {
+	if err := t.Writer.Close(); err != nil {
+		return err
+	}
+	return closeOutput(t.output)
+}
+
+// titleCase переводит первую букву каждого слова в заглавную, а остальные - в строчные
+type titleCase struct {
+	output io.Writer
+	runes  runeBuffer
+	inWord bool // последняя записанная руна - часть слова
+	buf    []byte
+}
+
+func NewTitleCase(output io.Writer) io.WriteCloser {
+	return &titleCase{output: output}
+}
+
+func (t *titleCase) Write(p []byte) (int, error) {
+	if err := t.write(t.runes.complete(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (t *titleCase) write(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	t.buf = t.buf[:0]
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		switch {
+		case r == utf8.RuneError && size < 2:
+			// невалидный байт переносится как есть
+			t.buf = append(t.buf, data[0])
+			t.inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			if t.inWord {
+				t.buf = utf8.AppendRune(t.buf, unicode.ToLower(r))
+			} else {
+				t.buf = utf8.AppendRune(t.buf, unicode.ToTitle(r))
+			}
+			t.inWord = true
+		default:
+			t.buf = append(t.buf, data[:size]...)
+			t.inWord = false
+		}
+		data = data[size:]
+	}
+
+	_, err := t.output.Write(t.buf)
+	return err
+}
+
+func (t *titleCase) Close() error {
+	if err := t.write(t.runes.flush()); err != nil {
+		return err
+	}
+	return closeOutput(t.output)
+}
+
+// swab меняет местами соседние руны, как dd conv=swab меняет байты. Нечетная руна
+// придерживается до следующей записи, а в конце потока записывается без пары
+type swab struct {
+	output io.Writer
+	runes  runeBuffer
+	odd    []byte // руна без пары
+	buf    []byte
+}
+
+func NewSwab(output io.Writer) io.WriteCloser {
+	return &swab{output: output}
+}
+
+func (s *swab) Write(p []byte) (int, error) {
+	data := s.runes.complete(p)
+	if len(data) == 0 {
+		return len(p), nil
+	}
+
+	s.buf = s.buf[:0]
+	for len(data) > 0 {
+		_, size := utf8.DecodeRune(data)
+		if s.odd == nil {
+			s.odd = append([]byte(nil), data[:size]...)
+		} else {
+			s.buf = append(append(s.buf, data[:size]...), s.odd...)
+			s.odd = nil
+		}
+		data = data[size:]
+	}
+
+	if len(s.buf) > 0 {
+		if _, err := s.output.Write(s.buf); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (s *swab) Close() error {
+	// незавершенная руна в конце потока считается отдельной руной
+	rest := s.odd
+	if tail := s.runes.flush(); len(tail) > 0 {
+		rest = append(tail, s.odd...)
+	}
+	if len(rest) > 0 {
+		if _, err := s.output.Write(rest); err != nil {
+			return err
+		}
+	}
+	return closeOutput(s.output)
+}
+
+// crlfToLF заменяет CRLF на LF. \r в конце записи придерживается: следующая запись
+// может начаться с \n
+type crlfToLF struct {
+	output io.Writer
+	cr     bool
+	buf    []byte
+}
+
+func NewCRLFToLF(output io.Writer) io.WriteCloser {
+	return &crlfToLF{output: output}
+}
+
+func (c *crlfToLF) Write(p []byte) (int, error) {
+	c.buf = c.buf[:0]
+	for _, b := range p {
+		if c.cr && b != '\n' {
+			c.buf = append(c.buf, '\r')
+		}
+		c.cr = b == '\r'
+		if !c.cr {
+			c.buf = append(c.buf, b)
+		}
+	}
+
+	if len(c.buf) > 0 {
+		if _, err := c.output.Write(c.buf); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (c *crlfToLF) Close() error {
+	if c.cr {
+		if _, err := c.output.Write([]byte{'\r'}); err != nil {
+			return err
+		}
+		c.cr = false
+	}
+	return closeOutput(c.output)
+}
+
+// lfToCRLF заменяет одиночные LF на CRLF, уже существующие CRLF не меняются
+type lfToCRLF struct {
+	output io.Writer
+	cr     bool // последний записанный байт - \r
+	buf    []byte
+}
+
+func NewLFToCRLF(output io.Writer) io.WriteCloser {
+	return &lfToCRLF{output: output}
+}
+
+func (l *lfToCRLF) Write(p []byte) (int, error) {
+	l.buf = l.buf[:0]
+	for _, b := range p {
+		if b == '\n' && !l.cr {
+			l.buf = append(l.buf, '\r')
+		}
+		l.buf = append(l.buf, b)
+		l.cr = b == '\r'
+	}
+
+	if _, err := l.output.Write(l.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (l *lfToCRLF) Close() error {
+	return closeOutput(l.output)
+}
+
+// состояния разбора escape-последовательностей в stripANSI
+const (
+	ansiText   = iota
+	ansiEscape // после ESC
+	ansiCSI    // после ESC [, до финального байта 0x40-0x7E
+	ansiOSC    // после ESC ], до BEL или ESC \
+	ansiOSCEsc // ESC внутри OSC
+)
+
+// stripANSI удаляет escape-последовательности терминала (цвета, перемещение курсора,
+// заголовок окна). Состояние разбора переносится между записями, поэтому
+// последовательность может быть разрезана границей блока
+type stripANSI struct {
+	output io.Writer
+	state  int
+	buf    []byte
+}
+
+func NewStripANSI(output io.Writer) io.WriteCloser {
+	return &stripANSI{output: output}
+}
+
+func (s *stripANSI) Write(p []byte) (int, error) {
+	s.buf = s.buf[:0]
+	for _, b := range p {
+		switch s.state {
+		case ansiText:
+			if b == 0x1b {
+				s.state = ansiEscape
+			} else {
+				s.buf = append(s.buf, b)
+			}
+		case ansiEscape:
+			switch b {
+			case '[':
+				s.state = ansiCSI
+			case ']':
+				s.state = ansiOSC
+			default:
+				// двухсимвольная последовательность, например ESC c
+				s.state = ansiText
+			}
+		case ansiCSI:
+			if b >= 0x40 && b <= 0x7e {
+				s.state = ansiText
+			}
+		case ansiOSC:
+			if b == 0x07 {
+				s.state = ansiText
+			} else if b == 0x1b {
+				s.state = ansiOSCEsc
+			}
+		case ansiOSCEsc:
+			if b == '\\' {
+				s.state = ansiText
+			} else {
+				s.state = ansiOSC
+			}
+		}
+	}
+
+	if len(s.buf) > 0 {
+		if _, err := s.output.Write(s.buf); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (s *stripANSI) Close() error {
+	return closeOutput(s.output)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestTransformsAcrossBlocks(t *testing.T) {
+	cp1251, err := charmap.Windows1251.NewEncoder().String("Привет, мир!")
+	assert.NoError(t, err)
+	koi8r, err := charmap.KOI8R.NewEncoder().String("Ёжик в тумане")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		conv     string
+		input    string
+		expected string
+	}{
+		{"title_case", "hELLO wORLD, машинное-обучение 3d 😊ёлка", "Hello World, Машинное-Обучение 3d 😊Ёлка"},
+		{"swab", "ab日本😊🎉e", "ba本日🎉😊e"},
+		{"swab", "ab\xe3\x80", "ba\xe3\x80"},
+		{"swab", "a\xe3\x80", "\xe3\x80a"},
+		{"crlf_to_lf", "a\r\nb\r\r\nc\rd\r", "a\nb\r\nc\rd\r"},
+		{"lf_to_crlf", "a\nb\r\nc\n\n", "a\r\nb\r\nc\r\n\r\n"},
+		{"nfc", "été й", "été й"},
+		{"nfc", norm.NFD.String("été й"), norm.NFC.String("été й")},
+		{"cp1251_to_utf8", cp1251, "Привет, мир!"},
+		{"koi8r_to_utf8", koi8r, "Ёжик в тумане"},
+		{"strip_ansi", "\x1b[1;31mred\x1b[0m \x1b]0;title\x07plain \x1b]8;;url\x1b\\link\x1bc!", "red plain link!"},
+	}
+
+	for _, tt := range tests {
+		for blockSize := int64(1); blockSize <= 5; blockSize++ {
+			actual := copyConverted(t, tt.input, blockSize, conversions[tt.conv])
+			assert.Equal(t, tt.expected, actual, "%s, block size %d", tt.conv, blockSize)
+		}
+	}
+}
+
+func TestConversionChainOrder(t *testing.T) {
+	opts, err := ParseFlags([]string{"-conv", "swab,upper_case", "conv=trim_spaces,sync,swab"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"swab", "upper_case", "trim_spaces"}, opts.Conversions)
+
+	// преобразования применяются в порядке перечисления
+	out := &bytes.Buffer{}
+	w := NewConversionChain(nopCloser{out}, []string{"crlf_to_lf", "lf_to_crlf"})
+	_, err = io.WriteString(w, "a\r\nb\n")
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "a\r\nb\r\n", out.String())
+
+	out.Reset()
+	w = NewConversionChain(nopCloser{out}, []string{"title_case", "swab"})
+	_, err = io.WriteString(w, "ab cd")
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "bAC d", out.String())
+
+	_, err = ParseFlags([]string{"-conv", "rot13"})
+	assert.Error(t, err)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -2,8 +2,12 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 // Result represents the Size function result
@@ -20,155 +24,424 @@ type DirSizer interface {
 	Size(ctx context.Context, d Dir) (Result, error)
 }
 
+// TreeSizer считает размер, как du: с разбивкой по директориям
+type TreeSizer interface {
+	DirSizer
+	// Tree calculate a size of given Dir with per-dir breakdown,
+	// will return a partial Tree and error if happened
+	Tree(ctx context.Context, d Dir) (*Tree, error)
+}
+
 // sizer implement the DirSizer interface
 type sizer struct {
 	// maxWorkersCount number of workers for asynchronous run
-	// by default - 4
+	// of Dir.Ls, by default - 4
 	maxWorkersCount int
+
+	// statConcurrency number of workers for asynchronous run
+	// of File.Stat, by default - 4
+	statConcurrency int
+
+	// maxDepth max depth of dirs to walk, the root dir has depth 0
+	// by default - -1, no limit
+	maxDepth int
+
+	// topN number of the largest files and dirs in Tree
+	// by default - 10
+	topN int
+
+	// callback receives partial results during the walk
+	callback func(Event)
+
+	// errorMode defines what to do when Ls or Stat fails
+	// by default - ErrorModeStrict
+	errorMode ErrorMode
 }
 
+// ErrorMode определяет, что делать с ошибками Ls и Stat
+type ErrorMode int
+
+const (
+	// ErrorModeStrict останавливает весь обход на первой ошибке
+	ErrorModeStrict ErrorMode = iota
+	// ErrorModeContinue пропускает файлы и директории с ошибками, например удаленные
+	// во время обхода или недоступные по правам. Size возвращает размер остальных
+	// и все ошибки с путями, объединенные в одну ошибку
+	ErrorModeContinue
+)
+
+// SizerOption настраивает sizer в NewSizer
+type SizerOption func(*sizer)
+
 // NewSizer returns new DirSizer instance
-func NewSizer() DirSizer {
-	return &sizer{
+func NewSizer(options ...SizerOption) DirSizer {
+	return newSizer(options)
+}
+
+// NewTreeSizer returns new TreeSizer instance
+func NewTreeSizer(options ...SizerOption) TreeSizer {
+	return newSizer(options)
+}
+
+func newSizer(options []SizerOption) *sizer {
+	s := &sizer{
 		maxWorkersCount: 4,
+		statConcurrency: 4,
+		maxDepth:        -1,
+		topN:            10,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	// хотя бы один worker каждого вида нужен, иначе обход не завершится
+	if s.maxWorkersCount < 1 {
+		s.maxWorkersCount = 1
+	}
+	if s.statConcurrency < 1 {
+		s.statConcurrency = 1
+	}
+
+	return s
+}
+
+// WithWorkers задает кол-во горутин, одновременно вызывающих Dir.Ls
+func WithWorkers(n int) SizerOption {
+	return func(s *sizer) {
+		s.maxWorkersCount = n
+	}
+}
+
+// WithStatConcurrency задает кол-во горутин, одновременно вызывающих File.Stat
+func WithStatConcurrency(n int) SizerOption {
+	return func(s *sizer) {
+		s.statConcurrency = n
+	}
+}
+
+// WithMaxDepth ограничивает глубину обхода: 0 - только файлы корневой директории,
+// отрицательное значение - без ограничения
+func WithMaxDepth(depth int) SizerOption {
+	return func(s *sizer) {
+		s.maxDepth = depth
+	}
+}
+
+// WithTopN задает, сколько самых больших файлов и директорий вернет Tree
+func WithTopN(n int) SizerOption {
+	return func(s *sizer) {
+		s.topN = n
+	}
+}
+
+// WithCallback задает функцию, которая получает частичные результаты во время обхода:
+// каждый обработанный файл и каждую полностью обработанную директорию. Вызовы fn
+// не пересекаются, но идут из горутин обхода, поэтому fn должна быть быстрой
+func WithCallback(fn func(Event)) SizerOption {
+	return func(s *sizer) {
+		s.callback = fn
+	}
+}
+
+// WithErrorMode задает режим обработки ошибок Ls и Stat
+func WithErrorMode(mode ErrorMode) SizerOption {
+	return func(s *sizer) {
+		s.errorMode = mode
+	}
+}
+
+// dirJob это директория в очереди обхода
+type dirJob struct {
+	dir  Dir
+	node *dirNode
+}
+
+// fileJob это файл в очереди на Stat и директория, в которой он лежит
+type fileJob struct {
+	file File
+	node *dirNode
+}
+
+// walk хранит общее состояние одного вызова Size или Tree
+type walk struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	size, count atomic.Int64
+
+	// stats собирает разбивку для Tree и WithCallback, nil - если она не нужна
+	stats *collector
+
+	errorMode ErrorMode
+
+	errOnce sync.Once
+	err     error
+
+	mu      sync.Mutex // мьютекс для errs
+	skipped []error    // пропущенные в ErrorModeContinue ошибки
+}
+
+// fail запоминает первую ошибку и останавливает обход
+func (w *walk) fail(err error) {
+	w.errOnce.Do(func() {
+		w.err = err
+		w.cancel()
+	})
+}
+
+// failed обрабатывает ошибку op объекта obj. В ErrorModeContinue ошибка запоминается
+// вместе с путем, объект пропускается, и failed возвращает true, иначе обход
+// останавливается. Ошибки контекста останавливают обход в любом режиме
+func (w *walk) failed(op string, obj interface{ Name() string }, err error) bool {
+	if w.errorMode != ErrorModeContinue || w.ctx.Err() != nil ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		w.fail(fmt.Errorf("%s: %w", op, err))
+		return false
+	}
+
+	err = pathError(op, obj.Name(), err)
+	w.mu.Lock()
+	w.skipped = append(w.skipped, err)
+	w.mu.Unlock()
+	return true
+}
+
+// pathError добавляет к err операцию и путь, если их там еще нет
+func pathError(op, path string, err error) error {
+	var pe *fs.PathError
+	if errors.As(err, &pe) && pe.Path == path {
+		return err
 	}
+	return &fs.PathError{Op: op, Path: path, Err: err}
 }
 
-// worker обрабатывает указатели на массивы файлов из канала filesChan.
-// увеличивает кол-во обработанных файлов count и общий вес файлов count,
-// используя атомарные инструкции
-func worker(ctx context.Context, filesChan chan *[]File, wg *sync.WaitGroup, m *sync.Mutex,
-	size *int64, count *int64, runtimeError *error) {
+// lsWorker вызывает Ls для директорий из dirJobs. Файлы передаются в fileJobs,
+// а поддиректории возвращаются в found, чтобы run поставил их в очередь.
+// В found отправляется ответ на каждую задачу, даже если Ls завершился с ошибкой
+func (a *sizer) lsWorker(w *walk, dirJobs <-chan dirJob, fileJobs chan<- fileJob, found chan<- []dirJob, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	for {
+	for job := range dirJobs {
+		found <- a.ls(w, job, fileJobs)
+	}
+}
+
+// ls обрабатывает одну директорию и возвращает ее поддиректории для обхода
+func (a *sizer) ls(w *walk, job dirJob, fileJobs chan<- fileJob) []dirJob {
+	if w.ctx.Err() != nil {
+		return nil
+	}
+
+	dirs, files, err := job.dir.Ls(w.ctx)
+	if err != nil {
+		// пропущенная директория считается обработанной, чтобы родительские
+		// директории в Tree получили свой размер
+		if w.failed("ls", job.dir, err) {
+			job.node.done(w)
+		}
+		return nil
+	}
+
+	var children []dirJob
+	if a.maxDepth < 0 || job.node.depth < a.maxDepth {
+		children = make([]dirJob, 0, len(dirs))
+		for _, d := range dirs {
+			children = append(children, dirJob{dir: d, node: job.node.child(d)})
+		}
+	}
+
+	// директория будет готова, когда обработаются все ее файлы и поддиректории
+	job.node.pending.Add(int64(len(files) + len(children)))
+
+	// fileJobs ограничен, поэтому медленный Stat притормаживает листинг
+	for _, f := range files {
 		select {
-		case <-ctx.Done():
-			// если контекст закрылся, то завершаем работу
-			return
-		case files, ok := <-filesChan:
-			// если канал закрылся, то завершаем работу
-			if !ok {
-				return
-			}
+		case fileJobs <- fileJob{file: f, node: job.node}:
+		case <-w.ctx.Done():
+			return nil
+		}
+	}
+
+	job.node.done(w)
+	return children
+}
+
+// statWorker вызывает Stat для файлов из fileJobs и суммирует их размеры
+func statWorker(w *walk, fileJobs <-chan fileJob, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	for job := range fileJobs {
+		// после ошибки оставшиеся файлы только вычитываются из канала
+		if w.ctx.Err() != nil {
+			continue
+		}
 
-			// проходим по всем файлам
-			for _, file := range *files {
-				s, err := file.Stat(ctx)
-				// если произошла ошибка при работе с файлом,
-				// то записываем ошибку в runtimeError и останавливаем горутину
-				if err != nil {
-					m.Lock()
-					*runtimeError = fmt.Errorf("file does not exist: %v", err)
-					m.Unlock()
-					return
-				}
-				// увеличиваем size и count
-				m.Lock()
-				*size += s
-				*count++
-				m.Unlock()
+		s, err := job.file.Stat(w.ctx)
+		if err != nil {
+			if w.failed("stat", job.file, err) {
+				job.node.done(w)
 			}
+			continue
+		}
+		w.size.Add(s)
+		w.count.Add(1)
+
+		job.node.size.Add(s)
+		job.node.count.Add(1)
+		if w.stats != nil {
+			w.stats.file(job.file.Name(), s, w)
 		}
+		job.node.done(w)
 	}
 }
 
-// dirsAdder это горутина, которая добавляет файлы из директории d в канал файлов.
-// При этом она может вызывать себя рекурсивно,
-// если dirAddersCount не превосходит maxWorkersCount
-func dirsAdder(ctx context.Context, filesChan chan *[]File, wg *sync.WaitGroup, m *sync.Mutex,
-	dir Dir, dirAddersCount *int64, maxWorkersCount int, runtimeError *error) {
-	// после завершения необходимо уменьшить счетчик работающих adder'ов на 1
-	defer func() {
-		m.Lock()
-		*dirAddersCount--
-		m.Unlock()
-		wg.Done()
-	}()
-
-	// очередь для директорий
-	// будет использоваться для обхода директории в ширину
-	queue := make([]Dir, 1)
-	queue[0] = dir
-	for len(queue) != 0 {
-		dirs, files, err := queue[0].Ls(ctx)
-		// передаем в канал новые файлы
-		// здесь может произойти deadlock, если не запущен хотя бы 1 worker,
-		// который читает этот канал
-		filesChan <- &files
-		if err != nil {
-			m.Lock()
-			*runtimeError = fmt.Errorf("file does not exist: %v", err)
-			m.Unlock()
-			return
+// run обходит директорию d. Директории распределяются между lsWorker'ами через
+// ограниченную очередь dirJobs, а найденные файлы идут statWorker'ам через
+// ограниченную очередь fileJobs, поэтому задержки Ls и Stat перекрываются независимо
+func (a *sizer) run(ctx context.Context, d Dir, stats *collector) (*walk, *dirNode) {
+	ctxWithCancel, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w := &walk{ctx: ctxWithCancel, cancel: cancel, stats: stats, errorMode: a.errorMode}
+	root := &dirNode{dir: d}
+	root.pending.Store(1)
+
+	var (
+		lsWG, statWG sync.WaitGroup
+		dirJobs      = make(chan dirJob, a.maxWorkersCount)
+		fileJobs     = make(chan fileJob, 2*a.statConcurrency)
+		found        = make(chan []dirJob)
+	)
+
+	for i := 0; i < a.statConcurrency; i++ {
+		statWG.Add(1)
+		go statWorker(w, fileJobs, &statWG)
+	}
+	for i := 0; i < a.maxWorkersCount; i++ {
+		lsWG.Add(1)
+		go a.lsWorker(w, dirJobs, fileJobs, found, &lsWG)
+	}
+
+	// обход в ширину: pending - директории, еще не отданные worker'ам,
+	// inFlight - отданные, но еще не обработанные
+	pending := []dirJob{{dir: d, node: root}}
+	inFlight := 0
+	for {
+		// после ошибки новые директории не раздаются, ждем только начатые.
+		// Условие выхода проверяется после очистки: поддиректории, найденные последним Ls
+		// после отмены, не должны оставлять цикл ждать found
+		if w.ctx.Err() != nil {
+			pending = nil
+		}
+		if len(pending) == 0 && inFlight == 0 {
+			break
 		}
 
-		// удаляем первый элемент из очереди
-		// т.к. он уже обработан
-		queue = queue[1:]
-
-		if len(dirs) != 0 {
-			for _, d := range dirs {
-				m.Lock()
-				if *dirAddersCount < int64(maxWorkersCount) {
-					// Если у нас есть пространство для создания еще одной горутины, то мы создаём ее
-					*dirAddersCount++
-					wg.Add(1)
-					go dirsAdder(ctx, filesChan, wg, m, d, dirAddersCount, maxWorkersCount, runtimeError)
-				} else {
-					// Если мы не можем создать еще одну горутину, то тогда
-					// функция сама обработает все папки из директории
-					queue = append(queue, d)
-				}
-				m.Unlock()
-			}
+		var (
+			jobs chan<- dirJob
+			next dirJob
+		)
+		if len(pending) > 0 {
+			jobs, next = dirJobs, pending[0]
+		}
+
+		select {
+		case jobs <- next:
+			pending = pending[1:]
+			inFlight++
+		case children := <-found:
+			inFlight--
+			pending = append(pending, children...)
 		}
 	}
+
+	// когда все директории обработаны, worker'ы завершаются по закрытию каналов
+	close(dirJobs)
+	lsWG.Wait()
+	close(fileJobs)
+	statWG.Wait()
+
+	if w.err == nil {
+		w.err = ctx.Err()
+	}
+	if len(w.skipped) > 0 {
+		w.err = joinErrors(append([]error{w.err}, w.skipped...)...)
+	}
+	return w, root
 }
 
 // Size возвращает структуру Result, которая характеризует директорию d.
 // Также функция может вернуть ошибку, которая произошла во время работы
 func (a *sizer) Size(ctx context.Context, d Dir) (Result, error) {
-	var (
-		size, count  int64          // размер и кол-во файлов соответственно
-		workersWG    sync.WaitGroup // wait группа worker'ов
-		addersWG     sync.WaitGroup // wait группа adder'ов
-		filesChan    chan *[]File   // поток с файлами
-		addersCount  int64          // кол-во горутин-adder'ов
-		runtimeError error          // сюда будем записывать ошибку, которая могла произойти в рантайме
-		m            sync.Mutex     // мьютекс для блокировки записи в runtimeError
-	)
+	var stats *collector
+	if a.callback != nil {
+		stats = newCollector(a.topN, a.callback)
+	}
 
-	// контекст с отменой используется в горутинах worker и adder
-	ctxWithCancel, cancel := context.WithCancel(ctx)
+	w, _ := a.run(ctx, d, stats)
 
-	defer cancel()
+	return Result{
+		w.size.Load(),
+		w.count.Load(),
+	}, w.err
+}
 
-	// поток файлов является буферизированным каналом с размером максимального кол-ва горутин
-	filesChan = make(chan *[]File, a.maxWorkersCount)
+// Tree возвращает разбивку размера директории d по поддиректориям, самые большие
+// файлы и директории и размеры по расширениям. При ошибке возвращается
+// частичный результат
+func (a *sizer) Tree(ctx context.Context, d Dir) (*Tree, error) {
+	stats := newCollector(a.topN, a.callback)
+	w, root := a.run(ctx, d, stats)
+	return stats.tree(root), w.err
+}
+
+// joinedError объединяет несколько ошибок, как errors.Join из go 1.20:
+// errors.Is и errors.As проверяют каждую из них
+type joinedError struct {
+	errs []error
+}
 
-	// половину горутин выделяем для worker'ов
-	for i := 1; i <= a.maxWorkersCount/2; i++ {
-		workersWG.Add(1)
-		go worker(ctxWithCancel, filesChan, &workersWG, &m, &size, &count, &runtimeError)
+// joinErrors возвращает ошибку из всех errs, кроме nil, или nil, если таких нет
+func joinErrors(errs ...error) error {
+	e := &joinedError{}
+	for _, err := range errs {
+		if err != nil {
+			e.errs = append(e.errs, err)
+		}
+	}
+	if len(e.errs) == 0 {
+		return nil
 	}
+	return e
+}
 
-	// запускаем adder, который будет добавлять в канал файлов новые файлы
-	// так же dirsAdder может рекурсивно вызывать себя в качестве горутины,
-	// поэтому мы оставили свободное место размером maxWorkersCount/2
-	addersWG.Add(1)
-	go dirsAdder(ctxWithCancel, filesChan, &addersWG, &m, d, &addersCount, a.maxWorkersCount/2, &runtimeError)
+func (e *joinedError) Error() string {
+	msgs := make([]string, 0, len(e.errs))
+	for _, err := range e.errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
 
-	addersWG.Wait()
-	// когда все adder'ы закончили работу и добавили все файлы из директории в канал,
-	// то его можно закрыть, тем самым обеспечив возможность выхода worker'ам
-	close(filesChan)
+func (e *joinedError) Is(target error) bool {
+	for _, err := range e.errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
 
-	// ожидаем, пока все worker'ы закончат свою работу
-	workersWG.Wait()
+func (e *joinedError) As(target any) bool {
+	for _, err := range e.errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
 
-	return Result{
-		size,
-		count,
-	}, runtimeError
+func (e *joinedError) Unwrap() []error {
+	return e.errs
 }
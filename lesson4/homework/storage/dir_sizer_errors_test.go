@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DirSizerErrorMode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	errDenied := errors.New("permission denied")
+	errVanished := errors.New("file vanished")
+
+	// getBrokenSet returns a root dir with one good file, one failing file,
+	// one failing dir and the dummy set
+	getBrokenSet := func() Dir {
+		f := NewMockFile(ctrl)
+		f.EXPECT().Name().Return("/broken/gone.txt").AnyTimes()
+		f.EXPECT().Stat(gomock.Any()).Return(int64(0), errVanished).MaxTimes(1)
+
+		d := NewMockDir(ctrl)
+		d.EXPECT().Name().Return("/broken/private").AnyTimes()
+		d.EXPECT().Ls(gomock.Any()).Return(nil, nil, errDenied).MaxTimes(1)
+
+		return NewDummyDir("/broken", 0, []Dir{d, getDummySet()}, []File{
+			NewDummyFile("/broken/ok.txt", 100), f,
+		})
+	}
+
+	t.Run("ok, continue on error", func(t *testing.T) {
+		sizer := NewSizer(WithErrorMode(ErrorModeContinue))
+
+		result, err := sizer.Size(context.Background(), getBrokenSet())
+		assert.Equal(t, Result{Size: 37254162 + 100, Count: 15}, result)
+
+		assert.ErrorIs(t, err, errDenied)
+		assert.ErrorIs(t, err, errVanished)
+
+		var pathErr *fs.PathError
+		assert.ErrorAs(t, err, &pathErr)
+		assert.ErrorContains(t, err, "ls /broken/private: permission denied")
+		assert.ErrorContains(t, err, "stat /broken/gone.txt: file vanished")
+	})
+
+	t.Run("ok, continue on error, tree", func(t *testing.T) {
+		tree, err := NewTreeSizer(WithErrorMode(ErrorModeContinue)).Tree(context.Background(), getBrokenSet())
+		assert.Error(t, err)
+		// пропущенные объекты не мешают посчитать размер корня
+		assert.Equal(t, Result{Size: 37254162 + 100, Count: 15}, tree.Root.Result)
+		assert.Equal(t, Result{}, tree.Root.Dirs[0].Result)
+	})
+
+	t.Run("fail, strict mode", func(t *testing.T) {
+		_, err := NewSizer(WithErrorMode(ErrorModeStrict)).Size(context.Background(), getBrokenSet())
+		assert.Error(t, err)
+		assert.True(t, errors.Is(err, errDenied) != errors.Is(err, errVanished), "only the first error is returned")
+	})
+
+	t.Run("fail, context error stops the walk", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		result, err := NewSizer(WithErrorMode(ErrorModeContinue)).Size(ctx, getDummySet())
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, result.Count, int64(14))
+	})
+
+	t.Run("fail, cancel during the last listing", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// Ls корня отменяет контекст, но все равно возвращает поддиректории
+		root := NewMockDir(ctrl)
+		root.EXPECT().Name().Return("/root").AnyTimes()
+		root.EXPECT().Ls(gomock.Any()).DoAndReturn(func(context.Context) ([]Dir, []File, error) {
+			cancel()
+			return []Dir{getDummySet()}, nil, nil
+		})
+
+		done := make(chan error)
+		go func() {
+			_, err := NewSizer().Size(ctx, root)
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			assert.ErrorIs(t, err, context.Canceled)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Size did not return after cancel")
+		}
+	})
+
+	t.Run("ok, vanished local file", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("symlinks require privileges on windows")
+		}
+
+		td := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(td, "ok.txt"), []byte("hello"), 0o600))
+		gone := filepath.Join(td, "gone.txt")
+		assert.NoError(t, os.Symlink(filepath.Join(td, "nowhere"), gone))
+
+		result, err := NewSizer(WithErrorMode(ErrorModeContinue)).Size(context.Background(), NewLocalDir(td))
+		assert.Equal(t, Result{Size: 5, Count: 1}, result)
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+
+		// путь из os.Stat не дублируется
+		assert.EqualError(t, err, "stat "+gone+": no such file or directory")
+	})
+}
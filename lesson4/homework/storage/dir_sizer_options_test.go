@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// slowFile is a dummy file with a Stat latency
+type slowFile struct {
+	File
+	latency time.Duration
+}
+
+func (f *slowFile) Stat(ctx context.Context) (int64, error) {
+	time.Sleep(f.latency)
+	return f.File.Stat(ctx)
+}
+
+// countingDir counts concurrent Ls calls
+type countingDir struct {
+	Dir
+	active, maxActive *atomic.Int64
+}
+
+func (d *countingDir) Ls(ctx context.Context) ([]Dir, []File, error) {
+	n := d.active.Add(1)
+	defer d.active.Add(-1)
+	for {
+		m := d.maxActive.Load()
+		if n <= m || d.maxActive.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	return d.Dir.Ls(ctx)
+}
+
+// getDummyTree returns a tree with fanout subdirs per dir and files files of size 1
+// per dir, depth levels deep. Ls and Stat sleep for latency
+func getDummyTree(name string, depth, fanout, files int, latency time.Duration) Dir {
+	var dirs []Dir
+	if depth > 0 {
+		for i := 0; i < fanout; i++ {
+			dirs = append(dirs, getDummyTree(fmt.Sprintf("%s/d%d", name, i), depth-1, fanout, files, latency))
+		}
+	}
+
+	fs := make([]File, 0, files)
+	for i := 0; i < files; i++ {
+		fs = append(fs, &slowFile{File: NewDummyFile(fmt.Sprintf("%s/f%d.txt", name, i), 1), latency: latency})
+	}
+
+	return NewDummyDir(name, latency, dirs, fs)
+}
+
+// treeCount returns a number of files in getDummyTree
+func treeCount(depth, fanout, files int) int64 {
+	dirs, level := 1, 1
+	for i := 0; i < depth; i++ {
+		level *= fanout
+		dirs += level
+	}
+	return int64(dirs * files)
+}
+
+func Test_DirSizerOptions(t *testing.T) {
+	t.Run("ok, workers and stat concurrency", func(t *testing.T) {
+		for _, n := range []int{0, 1, 3, 16} {
+			sizer := NewSizer(WithWorkers(n), WithStatConcurrency(n))
+
+			result, err := sizer.Size(context.Background(), getDummySet())
+			assert.NoError(t, err)
+			assert.Equal(t, int64(14), result.Count)
+			assert.Equal(t, int64(37254162), result.Size)
+		}
+	})
+
+	t.Run("ok, max depth", func(t *testing.T) {
+		root := getDummyTree("/root", 3, 2, 2, 0)
+		for depth, count := range map[int]int64{0: 2, 1: 6, 2: 14, 3: 30, -1: 30} {
+			result, err := NewSizer(WithMaxDepth(depth)).Size(context.Background(), root)
+			assert.NoError(t, err)
+			assert.Equal(t, count, result.Count, "depth %d", depth)
+			assert.Equal(t, count, result.Size, "depth %d", depth)
+		}
+	})
+
+	t.Run("ok, workers limit Ls concurrency", func(t *testing.T) {
+		var active, maxActive atomic.Int64
+		dirs := make([]Dir, 0, 20)
+		for i := 0; i < 20; i++ {
+			dirs = append(dirs, &countingDir{
+				Dir:    NewDummyDir(fmt.Sprintf("/root/d%d", i), 5*time.Millisecond, nil, nil),
+				active: &active, maxActive: &maxActive,
+			})
+		}
+
+		_, err := NewSizer(WithWorkers(3)).Size(context.Background(), NewDummyDir("/root", 0, dirs, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), maxActive.Load())
+	})
+
+	t.Run("fail, context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err := NewSizer().Size(ctx, getDummyTree("/root", 4, 4, 4, 5*time.Millisecond))
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func Benchmark_DirSizer(b *testing.B) {
+	const depth, fanout, files = 3, 4, 4
+	root := getDummyTree("/root", depth, fanout, files, time.Millisecond)
+	count := treeCount(depth, fanout, files)
+
+	for _, n := range []int{1, 2, 4, 8, 16, 32} {
+		b.Run(fmt.Sprintf("workers=%d", n), func(b *testing.B) {
+			sizer := NewSizer(WithWorkers(n), WithStatConcurrency(n))
+			for i := 0; i < b.N; i++ {
+				result, err := sizer.Size(context.Background(), root)
+				if err != nil || result.Count != count {
+					b.Fatalf("unexpected result %v, %v", result, err)
+				}
+			}
+		})
+	}
+}
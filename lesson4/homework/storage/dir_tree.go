@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"container/heap"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DirStat represents a cumulative size of a dir with all its subdirs
+type DirStat struct {
+	// Name is a fully qualified dir name
+	Name string
+	// Depth of the dir, the root dir has depth 0
+	Depth int
+	// Result is a total size and count of files in the dir and its subdirs
+	Result
+	// Dirs are subdirs of the dir
+	Dirs []*DirStat
+}
+
+// Entry represents a file or a dir in the top of the largest ones
+type Entry struct {
+	Name  string
+	Size  int64
+	IsDir bool
+}
+
+// Tree represents the Tree function result
+type Tree struct {
+	// Root is a breakdown of the root dir
+	Root *DirStat
+	// TopFiles are the largest files, from the largest one
+	TopFiles []Entry
+	// TopDirs are the largest subdirs by cumulative size, from the largest one
+	TopDirs []Entry
+	// Extensions is a size and count of files by lower-cased extension,
+	// files without extension are under ""
+	Extensions map[string]Result
+}
+
+// Event is a partial result passed to WithCallback
+type Event struct {
+	// File is set when a file is processed
+	File *Entry
+	// Dir is set when a dir is processed with all its subdirs, Dir.Dirs is empty
+	Dir *DirStat
+	// Total is a size and count of files processed so far
+	Total Result
+}
+
+// dirNode накапливает размер директории во время обхода
+type dirNode struct {
+	dir      Dir
+	depth    int
+	parent   *dirNode
+	children []*dirNode
+
+	size, count atomic.Int64
+
+	// pending - сколько еще не обработано: Ls самой директории, ее файлы и поддиректории
+	pending atomic.Int64
+}
+
+// child создает узел поддиректории d
+func (n *dirNode) child(d Dir) *dirNode {
+	c := &dirNode{dir: d, depth: n.depth + 1, parent: n}
+	c.pending.Store(1)
+	n.children = append(n.children, c)
+	return c
+}
+
+// done отмечает обработку одной части директории. Когда обработано все,
+// размер директории добавляется к родительской
+func (n *dirNode) done(w *walk) {
+	if n.pending.Add(-1) != 0 {
+		return
+	}
+
+	if w.stats != nil {
+		w.stats.dir(n, w)
+	}
+	if n.parent != nil {
+		n.parent.size.Add(n.size.Load())
+		n.parent.count.Add(n.count.Load())
+		n.parent.done(w)
+	}
+}
+
+// stat возвращает накопленный размер директории со всеми поддиректориями
+func (n *dirNode) stat() *DirStat {
+	s := &DirStat{
+		Name:   n.dir.Name(),
+		Depth:  n.depth,
+		Result: Result{Size: n.size.Load(), Count: n.count.Load()},
+	}
+	for _, c := range n.children {
+		s.Dirs = append(s.Dirs, c.stat())
+	}
+	return s
+}
+
+// entryHeap - min-heap записей по размеру для выбора top-N
+type entryHeap []Entry
+
+func (h entryHeap) Len() int           { return len(h) }
+func (h entryHeap) Less(i, j int) bool { return entryLess(h[i], h[j]) }
+func (h entryHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *entryHeap) Push(x any)        { *h = append(*h, x.(Entry)) }
+func (h *entryHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+// entryLess сравнивает записи по размеру, при равенстве - по имени, чтобы топ
+// не зависел от порядка обхода
+func entryLess(a, b Entry) bool {
+	if a.Size != b.Size {
+		return a.Size < b.Size
+	}
+	return a.Name > b.Name
+}
+
+// push добавляет e, оставляя в куче не больше n самых больших записей
+func (h *entryHeap) push(e Entry, n int) {
+	if n <= 0 {
+		return
+	}
+	if h.Len() < n {
+		heap.Push(h, e)
+		return
+	}
+	if entryLess((*h)[0], e) {
+		(*h)[0] = e
+		heap.Fix(h, 0)
+	}
+}
+
+// sorted возвращает записи от самой большой
+func (h entryHeap) sorted() []Entry {
+	entries := append([]Entry(nil), h...)
+	sort.Slice(entries, func(i, j int) bool { return entryLess(entries[j], entries[i]) })
+	return entries
+}
+
+// collector собирает топы и размеры по расширениям и вызывает callback.
+// Вызывается из worker'ов, поэтому все под одним мьютексом
+type collector struct {
+	mu         sync.Mutex
+	topN       int
+	files      entryHeap
+	dirs       entryHeap
+	extensions map[string]Result
+	callback   func(Event)
+}
+
+func newCollector(topN int, callback func(Event)) *collector {
+	return &collector{topN: topN, extensions: make(map[string]Result), callback: callback}
+}
+
+// file учитывает обработанный файл
+func (c *collector) file(name string, size int64, w *walk) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := Entry{Name: name, Size: size}
+	c.files.push(e, c.topN)
+
+	ext := strings.ToLower(filepath.Ext(name))
+	r := c.extensions[ext]
+	r.Size += size
+	r.Count++
+	c.extensions[ext] = r
+
+	if c.callback != nil {
+		c.callback(Event{File: &e, Total: Result{Size: w.size.Load(), Count: w.count.Load()}})
+	}
+}
+
+// dir учитывает полностью обработанную директорию. Корень в топ не попадает
+func (c *collector) dir(n *dirNode, w *walk) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := &DirStat{
+		Name:   n.dir.Name(),
+		Depth:  n.depth,
+		Result: Result{Size: n.size.Load(), Count: n.count.Load()},
+	}
+	if n.parent != nil {
+		c.dirs.push(Entry{Name: s.Name, Size: s.Size, IsDir: true}, c.topN)
+	}
+
+	if c.callback != nil {
+		c.callback(Event{Dir: s, Total: Result{Size: w.size.Load(), Count: w.count.Load()}})
+	}
+}
+
+// tree возвращает итог обхода с корнем root
+func (c *collector) tree(root *dirNode) *Tree {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return &Tree{
+		Root:       root.stat(),
+		TopFiles:   c.files.sorted(),
+		TopDirs:    c.dirs.sorted(),
+		Extensions: c.extensions,
+	}
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DirSizerTree(t *testing.T) {
+	t.Run("ok, per-dir breakdown", func(t *testing.T) {
+		tree, err := NewTreeSizer(WithTopN(3)).Tree(context.Background(), getDummySet())
+		assert.NoError(t, err)
+
+		assert.Equal(t, "/root", tree.Root.Name)
+		assert.Equal(t, Result{Size: 37254162, Count: 14}, tree.Root.Result)
+		assert.Len(t, tree.Root.Dirs, 1)
+
+		foo := tree.Root.Dirs[0]
+		assert.Equal(t, "/root/foo", foo.Name)
+		assert.Equal(t, 1, foo.Depth)
+		assert.Equal(t, Result{Size: 37254162 - 1249 - 3523 - 8542 - 21230, Count: 10}, foo.Result)
+		assert.Len(t, foo.Dirs, 3)
+		assert.Equal(t, Result{Size: 6353 + 235621, Count: 2}, foo.Dirs[0].Result)
+
+		assert.Equal(t, []Entry{
+			{Name: "/root/foo/f8.txt", Size: 35415264},
+			{Name: "/root/foo/f5.txt", Size: 735635},
+			{Name: "/root/foo/f6.txt", Size: 372650},
+		}, tree.TopFiles)
+		assert.Equal(t, []Entry{
+			{Name: "/root/foo", Size: foo.Size, IsDir: true},
+			{Name: "/root/foo/baz", Size: 76504 + 252446, IsDir: true},
+			{Name: "/root/foo/bar", Size: 6353 + 235621, IsDir: true},
+		}, tree.TopDirs)
+	})
+
+	t.Run("ok, extensions", func(t *testing.T) {
+		root := NewDummyDir("/root", 0, nil, []File{
+			NewDummyFile("/root/a.txt", 1),
+			NewDummyFile("/root/b.TXT", 2),
+			NewDummyFile("/root/c.tar.gz", 4),
+			NewDummyFile("/root/Makefile", 8),
+		})
+
+		tree, err := NewTreeSizer().Tree(context.Background(), root)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]Result{
+			".txt": {Size: 3, Count: 2},
+			".gz":  {Size: 4, Count: 1},
+			"":     {Size: 8, Count: 1},
+		}, tree.Extensions)
+		assert.Empty(t, tree.TopDirs)
+	})
+
+	t.Run("ok, callback", func(t *testing.T) {
+		var files, dirs atomic.Int64
+		var last Event
+		sizer := NewSizer(WithCallback(func(e Event) {
+			if e.File != nil {
+				files.Add(1)
+			}
+			if e.Dir != nil {
+				dirs.Add(1)
+				// директория готова только вместе с поддиректориями
+				if e.Dir.Name == "/root/foo" {
+					assert.Equal(t, int64(10), e.Dir.Count)
+				}
+			}
+			last = e
+		}))
+
+		result, err := sizer.Size(context.Background(), getDummySet())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(14), files.Load())
+		assert.Equal(t, int64(5), dirs.Load())
+
+		// последним завершается корень
+		assert.Equal(t, "/root", last.Dir.Name)
+		assert.Equal(t, result, last.Total)
+	})
+
+	t.Run("fail, partial tree", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		tree, err := NewTreeSizer().Tree(ctx, getDummySet())
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, "/root", tree.Root.Name)
+		assert.Equal(t, Result{Size: 1249 + 3523 + 8542 + 21230, Count: 4}, tree.Root.Result)
+	})
+}
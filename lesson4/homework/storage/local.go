@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// SymlinkMode defines how NewLocalDir handles symbolic links
+type SymlinkMode int
+
+const (
+	// SymlinksAsFiles treats every symlink as a file with the size of its target,
+	// a symlink to a dir fails on Stat. It is the default
+	SymlinksAsFiles SymlinkMode = iota
+	// SymlinksSkip ignores symlinks
+	SymlinksSkip
+	// SymlinksFollow follows symlinks to files and dirs, every dir is visited once,
+	// so symlink cycles are not walked, and every file is counted once by device
+	// and inode, like du -L: a symlink and its target or several hardlinks count once
+	SymlinksFollow
+)
+
+// LocalOption configures NewLocalDir
+type LocalOption func(*localConfig)
+
+// WithSymlinks sets how symlinks are handled
+func WithSymlinks(mode SymlinkMode) LocalOption {
+	return func(c *localConfig) {
+		c.symlinks = mode
+	}
+}
+
+// WithHardlinksOnce counts a file with several hardlinks once
+func WithHardlinksOnce() LocalOption {
+	return func(c *localConfig) {
+		c.hardlinks = true
+	}
+}
+
+// WithAllocatedSize makes Stat return a size of allocated blocks instead of
+// the apparent size, like du does by default
+func WithAllocatedSize() LocalOption {
+	return func(c *localConfig) {
+		c.allocated = true
+	}
+}
+
+// WithOneFileSystem skips dirs on other file systems than the root, like du -x
+func WithOneFileSystem() LocalOption {
+	return func(c *localConfig) {
+		c.oneFS = true
+	}
+}
+
+// fileID однозначно определяет файл: устройство и inode
+type fileID struct {
+	dev, ino uint64
+}
+
+// localConfig - общие настройки и состояние обхода одного дерева localDir.
+// Состояние сбрасывается при каждом Ls корня, поэтому каждый обход начинается заново
+type localConfig struct {
+	symlinks  SymlinkMode
+	hardlinks bool
+	allocated bool
+	oneFS     bool
+
+	// root - имя корня дерева
+	root string
+	// rootDev - устройство корня для WithOneFileSystem
+	rootDev uint64
+
+	mu sync.Mutex
+	// dirs - уже обойденные директории при SymlinksFollow
+	dirs map[fileID]struct{}
+	// links - уже посчитанные файлы с несколькими жесткими ссылками или, при SymlinksFollow, все файлы
+	links map[fileID]struct{}
+}
+
+func newLocalConfig(root string, options []LocalOption) *localConfig {
+	c := &localConfig{root: root}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// reset начинает новый обход: забывает обойденные директории и посчитанные жесткие ссылки
+func (c *localConfig) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.dirs = make(map[fileID]struct{})
+	c.links = make(map[fileID]struct{})
+
+	// ошибку вернет Ls корня
+	if info, err := os.Stat(c.root); err == nil {
+		if id, _, _, ok := fileStat(info); ok {
+			c.rootDev = id.dev
+			c.dirs[id] = struct{}{}
+		}
+	}
+}
+
+// first отмечает id в seen и возвращает true, если он встретился впервые
+func (c *localConfig) first(seen map[fileID]struct{}, id fileID) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := seen[id]; ok {
+		return false
+	}
+	seen[id] = struct{}{}
+	return true
+}
+
+// inspect сообщает, нужна ли ls информация о цели каждого объекта
+func (c *localConfig) inspect() bool {
+	return c.symlinks == SymlinksFollow || c.hardlinks || c.oneFS
+}
+
+// ls разбирает содержимое директории name с учетом настроек.
+// Объекты, которые не удалось разобрать, возвращаются файлами,
+// чтобы ошибку вернул их Stat
+func (c *localConfig) ls(name string, entries []fs.DirEntry) (dirs []Dir, files []File) {
+	for _, e := range entries {
+		path := filepath.Join(name, e.Name())
+		file := &localFile{name: path, cfg: c}
+
+		if e.Type()&fs.ModeSymlink != 0 {
+			if c.symlinks == SymlinksSkip {
+				continue
+			}
+			if c.symlinks != SymlinksFollow {
+				files = append(files, file)
+				continue
+			}
+		}
+
+		if !c.inspect() {
+			if e.IsDir() {
+				dirs = append(dirs, &localDir{name: path, cfg: c})
+			} else {
+				files = append(files, file)
+			}
+			continue
+		}
+
+		// информация о цели ссылки, а не о самой ссылке
+		info, err := os.Stat(path)
+		if err != nil {
+			files = append(files, file)
+			continue
+		}
+		id, nlink, _, ok := fileStat(info)
+
+		if !info.IsDir() {
+			// при SymlinksFollow каждый файл считается один раз, даже если до него ведут ссылки
+			once := c.symlinks == SymlinksFollow || c.hardlinks && nlink >= 2
+			if !ok || !once || c.first(c.links, id) {
+				files = append(files, file)
+			}
+			continue
+		}
+
+		switch {
+		case !ok:
+		case c.oneFS && id.dev != c.rootDev:
+			continue
+		case c.symlinks == SymlinksFollow && !c.first(c.dirs, id):
+			// директория уже обойдена по другому пути
+			continue
+		}
+		dirs = append(dirs, &localDir{name: path, cfg: c})
+	}
+	return dirs, files
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package storage
+
+import "io/fs"
+
+// fileStat на платформах без syscall.Stat_t ничего не знает о файле:
+// жесткие ссылки и границы файловых систем не учитываются, размер - видимый
+func fileStat(fs.FileInfo) (id fileID, nlink uint64, blocks int64, ok bool) {
+	return fileID{}, 0, 0, false
+}
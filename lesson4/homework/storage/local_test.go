@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// getLocalSet creates a tree with a symlink cycle, a symlink to a file and a hardlink:
+//
+//	root/a.txt (5 bytes), root/hard.txt -> a.txt (hardlink)
+//	root/sub/b.txt (3 bytes), root/sub/loop -> root, root/sub/link.txt -> sub/b.txt
+func getLocalSet(t *testing.T) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on windows")
+	}
+
+	root := t.TempDir()
+	sub := filepath.Join(root, "sub")
+	assert.NoError(t, os.Mkdir(sub, 0o700))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("hello"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(sub, "b.txt"), []byte("bye"), 0o600))
+	assert.NoError(t, os.Link(filepath.Join(root, "a.txt"), filepath.Join(root, "hard.txt")))
+	assert.NoError(t, os.Symlink(root, filepath.Join(sub, "loop")))
+	assert.NoError(t, os.Symlink(filepath.Join(sub, "b.txt"), filepath.Join(sub, "link.txt")))
+	return root
+}
+
+func Test_LocalDirOptions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("fail, symlink to dir as file", func(t *testing.T) {
+		_, err := NewSizer().Size(ctx, NewLocalDir(getLocalSet(t)))
+		assert.ErrorContains(t, err, "is a directory, not a file")
+	})
+
+	t.Run("ok, skip symlinks", func(t *testing.T) {
+		result, err := NewSizer().Size(ctx, NewLocalDir(getLocalSet(t), WithSymlinks(SymlinksSkip)))
+		assert.NoError(t, err)
+		assert.Equal(t, Result{Size: 5 + 5 + 3, Count: 3}, result)
+	})
+
+	t.Run("ok, follow symlinks with a cycle", func(t *testing.T) {
+		result, err := NewSizer().Size(ctx, NewLocalDir(getLocalSet(t), WithSymlinks(SymlinksFollow)))
+		assert.NoError(t, err)
+		// loop ведет в уже обойденный корень, link.txt и hard.txt ведут к уже посчитанным файлам
+		assert.Equal(t, Result{Size: 5 + 3, Count: 2}, result)
+	})
+
+	t.Run("ok, hardlinks and followed symlinks once", func(t *testing.T) {
+		root := NewLocalDir(getLocalSet(t), WithSymlinks(SymlinksFollow), WithHardlinksOnce())
+		result, err := NewSizer().Size(ctx, root)
+		assert.NoError(t, err)
+		// ссылка на b.txt ведет к уже посчитанному файлу, как в du -L
+		assert.Equal(t, Result{Size: 5 + 3, Count: 2}, result)
+	})
+
+	t.Run("ok, walk the same dir twice", func(t *testing.T) {
+		root := NewLocalDir(getLocalSet(t), WithSymlinks(SymlinksFollow), WithHardlinksOnce())
+		for i := 0; i < 2; i++ {
+			result, err := NewSizer().Size(ctx, root)
+			assert.NoError(t, err)
+			assert.Equal(t, Result{Size: 5 + 3, Count: 2}, result, "walk %d", i+1)
+		}
+	})
+
+	t.Run("ok, one file system", func(t *testing.T) {
+		result, err := NewSizer().Size(ctx, NewLocalDir(getLocalSet(t), WithSymlinks(SymlinksSkip), WithOneFileSystem()))
+		assert.NoError(t, err)
+		assert.Equal(t, Result{Size: 5 + 5 + 3, Count: 3}, result)
+	})
+
+	t.Run("ok, allocated size", func(t *testing.T) {
+		root := getLocalSet(t)
+		// разреженный файл занимает меньше, чем его видимый размер
+		sparse := filepath.Join(root, "sub", "sparse.img")
+		f, err := os.Create(sparse)
+		assert.NoError(t, err)
+		assert.NoError(t, f.Truncate(1<<20))
+		assert.NoError(t, f.Close())
+
+		apparent, err := NewLocalFile(sparse).Stat(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1<<20), apparent)
+
+		result, err := NewSizer().Size(ctx, NewLocalDir(root, WithSymlinks(SymlinksSkip), WithAllocatedSize()))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), result.Count)
+		assert.Less(t, result.Size, int64(1<<20))
+		assert.Zero(t, result.Size%512)
+	})
+}
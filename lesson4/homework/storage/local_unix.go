@@ -0,0 +1,19 @@
+//go:build unix
+
+package storage
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileStat возвращает идентификатор файла, число жестких ссылок
+// и число выделенных 512-байтных блоков
+func fileStat(info fs.FileInfo) (id fileID, nlink uint64, blocks int64, ok bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, 0, 0, false
+	}
+	// типы полей Stat_t зависят от платформы, поэтому явные преобразования
+	return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, uint64(st.Nlink), int64(st.Blocks), true
+}
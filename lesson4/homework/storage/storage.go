@@ -29,10 +29,17 @@ type Dir interface {
 
 type localDir struct {
 	name string
+	// cfg is shared by all dirs and files of one tree, nil - no options
+	cfg *localConfig
 }
 
-func NewLocalDir(root string) Dir {
-	return &localDir{name: root}
+// NewLocalDir returns a Dir of the local file system.
+// Options define how symlinks, hardlinks and mount points are handled
+func NewLocalDir(root string, options ...LocalOption) Dir {
+	if len(options) == 0 {
+		return &localDir{name: root}
+	}
+	return &localDir{name: root, cfg: newLocalConfig(root, options)}
 }
 
 func (d *localDir) Name() string {
@@ -50,6 +57,14 @@ func (d *localDir) Ls(ctx context.Context) (dirs []Dir, files []File, err error)
 		return
 	}
 
+	if d.cfg != nil {
+		if d.name == d.cfg.root {
+			d.cfg.reset()
+		}
+		dirs, files = d.cfg.ls(d.name, entry)
+		return dirs, files, nil
+	}
+
 	for _, e := range entry {
 		if e.IsDir() {
 			dirs = append(dirs, NewLocalDir(filepath.Join(d.name, e.Name())))
@@ -63,6 +78,7 @@ func (d *localDir) Ls(ctx context.Context) (dirs []Dir, files []File, err error)
 
 type localFile struct {
 	name string
+	cfg  *localConfig
 }
 
 func NewLocalFile(name string) File {
@@ -91,6 +107,11 @@ func (f *localFile) Stat(ctx context.Context) (size int64, err error) {
 	}
 
 	size = info.Size()
+	if f.cfg != nil && f.cfg.allocated {
+		if _, _, blocks, ok := fileStat(info); ok {
+			size = blocks * 512
+		}
+	}
 	return
 }
 